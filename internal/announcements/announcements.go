@@ -0,0 +1,158 @@
+package announcements
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Header carries the most severe active header announcement on API responses
+const Header = "X-OPGL-Announcement"
+
+// Announcement severities, least to most severe
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// severityRanks orders severities so the most severe announcement is listed first
+var severityRanks = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// Announcement is a message broadcast to API clients between two times
+type Announcement struct {
+	// ID identifies the announcement so clients can remember which ones they've shown
+	ID      string `yaml:"id" json:"id"`
+	Message string `yaml:"message" json:"message"`
+	// Severity is info, warning or critical (info when omitted)
+	Severity string `yaml:"severity" json:"severity"`
+	// StartsAt is when the announcement becomes active (immediately when omitted)
+	StartsAt time.Time `yaml:"startsAt" json:"startsAt,omitzero"`
+	// EndsAt is when the announcement stops being active (never when omitted)
+	EndsAt time.Time `yaml:"endsAt" json:"endsAt,omitzero"`
+	// Header also sends the announcement in the X-OPGL-Announcement response header
+	Header bool `yaml:"header" json:"-"`
+}
+
+// active reports whether the announcement is shown at now
+func (announcement *Announcement) active(now time.Time) bool {
+	if !announcement.StartsAt.IsZero() && now.Before(announcement.StartsAt) {
+		return false
+	}
+	return announcement.EndsAt.IsZero() || now.Before(announcement.EndsAt)
+}
+
+// Validate checks announcement IDs, severities and time ranges, filling in the default severity
+// Header announcements must be printable ASCII so they fit in a response header.
+func Validate(announcements []Announcement) error {
+	ids := make(map[string]bool, len(announcements))
+	for i := range announcements {
+		announcement := &announcements[i]
+		if announcement.ID == "" || strings.ContainsAny(announcement.ID, "=,; ") {
+			return fmt.Errorf("announcement %d: id must be non-empty and contain no '=', ',', ';' or spaces", i+1)
+		}
+		if ids[announcement.ID] {
+			return fmt.Errorf("announcement %s: declared more than once", announcement.ID)
+		}
+		ids[announcement.ID] = true
+
+		if strings.TrimSpace(announcement.Message) == "" {
+			return fmt.Errorf("announcement %s: message is required", announcement.ID)
+		}
+		if announcement.Severity == "" {
+			announcement.Severity = SeverityInfo
+		}
+		if _, known := severityRanks[announcement.Severity]; !known {
+			return fmt.Errorf("announcement %s: severity must be info, warning or critical", announcement.ID)
+		}
+		if !announcement.StartsAt.IsZero() && !announcement.EndsAt.IsZero() && !announcement.EndsAt.After(announcement.StartsAt) {
+			return fmt.Errorf("announcement %s: endsAt must be after startsAt", announcement.ID)
+		}
+		if announcement.Header && !isHeaderSafe(announcement.ID+announcement.Message) {
+			return fmt.Errorf("announcement %s: header announcements must be printable ASCII", announcement.ID)
+		}
+	}
+	return nil
+}
+
+// isHeaderSafe reports whether value contains only printable ASCII
+func isHeaderSafe(value string) bool {
+	for _, character := range value {
+		if character < 0x20 || character > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Board serves the currently active announcements
+type Board struct {
+	announcements []Announcement
+	// now is replaceable so tests can move through announcement windows
+	now func() time.Time
+}
+
+// NewBoard creates a Board for validated announcements
+func NewBoard(announcements []Announcement) *Board {
+	return &Board{announcements: announcements, now: time.Now}
+}
+
+// Active returns the announcements active at now, most severe first, then earliest started
+func (board *Board) Active(now time.Time) []Announcement {
+	active := make([]Announcement, 0, len(board.announcements))
+	for _, announcement := range board.announcements {
+		if announcement.active(now) {
+			active = append(active, announcement)
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		if severityRanks[active[i].Severity] != severityRanks[active[j].Severity] {
+			return severityRanks[active[i].Severity] > severityRanks[active[j].Severity]
+		}
+		return active[i].StartsAt.Before(active[j].StartsAt)
+	})
+	return active
+}
+
+// HasHeaders reports whether any announcement is sent in the response header
+func (board *Board) HasHeaders() bool {
+	for _, announcement := range board.announcements {
+		if announcement.Header {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderValue formats the most severe active header announcement, or "" when there is none
+// The value is `severity=warning; id=maint-oct; message="..."` with the message quoted.
+func (board *Board) HeaderValue() string {
+	for _, announcement := range board.Active(board.now()) {
+		if !announcement.Header {
+			continue
+		}
+		quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(announcement.Message)
+		return "severity=" + announcement.Severity + "; id=" + announcement.ID + `; message="` + quoted + `"`
+	}
+	return ""
+}
+
+// Handler serves GET /api/v1/announcements with the active announcements
+func (board *Board) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		// Short caching keeps polling clients cheap while windows still open and close promptly
+		writer.Header().Set("Cache-Control", "public, max-age=60")
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"announcements": board.Active(board.now()),
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package announcements
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// windowStart is the start of the maintenance announcement used across tests
+var windowStart = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestBoard creates a board with a maintenance warning, an open-ended info and a critical outage
+func newTestBoard(t *testing.T) *Board {
+	testAnnouncements := []Announcement{
+		{ID: "maintenance", Message: `Maintenance "soon"`, Severity: SeverityWarning, StartsAt: windowStart, EndsAt: windowStart.Add(time.Hour), Header: true},
+		{ID: "new-feature", Message: "Ranked stats are live"},
+		{ID: "outage", Message: "Analysis is down", Severity: SeverityCritical, StartsAt: windowStart.Add(30 * time.Minute)},
+	}
+	if err := Validate(testAnnouncements); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return NewBoard(testAnnouncements)
+}
+
+// TestBoard_Active tests time windows and severity ordering
+func TestBoard_Active(t *testing.T) {
+	board := newTestBoard(t)
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		expected []string
+	}{
+		{"before the window", windowStart.Add(-time.Minute), []string{"new-feature"}},
+		{"inside the window", windowStart.Add(10 * time.Minute), []string{"maintenance", "new-feature"}},
+		{"critical first", windowStart.Add(45 * time.Minute), []string{"outage", "maintenance", "new-feature"}},
+		{"after the window", windowStart.Add(time.Hour), []string{"outage", "new-feature"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			active := board.Active(testCase.now)
+			ids := make([]string, len(active))
+			for i, announcement := range active {
+				ids[i] = announcement.ID
+			}
+			if len(ids) != len(testCase.expected) {
+				t.Fatalf("Expected %v, got %v", testCase.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != testCase.expected[i] {
+					t.Errorf("Expected %v, got %v", testCase.expected, ids)
+				}
+			}
+		})
+	}
+}
+
+// TestBoard_HeaderValue tests that only header announcements are sent, with the message quoted
+func TestBoard_HeaderValue(t *testing.T) {
+	board := newTestBoard(t)
+
+	board.now = func() time.Time { return windowStart.Add(-time.Minute) }
+	if headerValue := board.HeaderValue(); headerValue != "" {
+		t.Errorf("Expected no header outside the window, got '%s'", headerValue)
+	}
+
+	board.now = func() time.Time { return windowStart.Add(45 * time.Minute) }
+	expected := `severity=warning; id=maintenance; message="Maintenance \"soon\""`
+	if headerValue := board.HeaderValue(); headerValue != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, headerValue)
+	}
+}
+
+// TestBoard_Handler tests the JSON listing
+func TestBoard_Handler(t *testing.T) {
+	board := newTestBoard(t)
+	board.now = func() time.Time { return windowStart.Add(10 * time.Minute) }
+
+	responseRecorder := httptest.NewRecorder()
+	board.Handler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/api/v1/announcements", nil))
+
+	var response struct {
+		Announcements []map[string]interface{} `json:"announcements"`
+	}
+	json.Unmarshal(responseRecorder.Body.Bytes(), &response)
+	if len(response.Announcements) != 2 {
+		t.Fatalf("Expected 2 announcements, got %s", responseRecorder.Body.String())
+	}
+	if response.Announcements[0]["severity"] != "warning" || response.Announcements[0]["endsAt"] != "2026-11-01T01:00:00Z" {
+		t.Errorf("Unexpected first announcement %v", response.Announcements[0])
+	}
+	if _, exists := response.Announcements[1]["startsAt"]; exists {
+		t.Errorf("Expected no startsAt for an announcement without one, got %v", response.Announcements[1])
+	}
+}
+
+// TestValidate_Invalid tests rejected announcements
+func TestValidate_Invalid(t *testing.T) {
+	testCases := map[string]Announcement{
+		"missing id":        {Message: "hello"},
+		"id with spaces":    {ID: "a b", Message: "hello"},
+		"missing message":   {ID: "a"},
+		"unknown severity":  {ID: "a", Message: "hello", Severity: "urgent"},
+		"inverted window":   {ID: "a", Message: "hello", StartsAt: windowStart, EndsAt: windowStart},
+		"non-ASCII header":  {ID: "a", Message: "Wartung läuft", Header: true},
+		"newline in header": {ID: "a", Message: "one\ntwo", Header: true},
+	}
+
+	for name, announcement := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate([]Announcement{announcement}); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	if err := Validate([]Announcement{{ID: "a", Message: "one"}, {ID: "a", Message: "two"}}); err == nil {
+		t.Error("Expected error for duplicate ids")
+	}
+}
@@ -0,0 +1,258 @@
+package anomaly
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+)
+
+// Signals a window can raise against its consumer's baseline
+const (
+	SignalRequestRate = "request_rate"
+	SignalErrorRatio  = "error_ratio"
+	SignalEndpointMix = "endpoint_mix"
+)
+
+// Detection thresholds
+// A window is only judged once the baseline has seen warmupWindows windows and the window
+// itself has minWindowRequests requests, so quiet keys never trip the detector.
+const (
+	warmupWindows     = 5
+	minWindowRequests = 20
+	rateSpikeFactor   = 5.0
+	errorRatioJump    = 0.5
+	endpointMixShift  = 0.5
+	baselineWeight    = 0.2
+	idleWindows       = 60
+)
+
+// Config holds detector settings
+type Config struct {
+	// Window is the length of the windows compared against each consumer's baseline
+	Window time.Duration
+	// SuspendSeverity suspends consumers whose anomaly reaches this severity (0 never suspends)
+	// Severity is the number of signals raised in the same window, from 1 to 3.
+	SuspendSeverity int
+	// SuspendDuration is how long an automatic suspension lasts
+	SuspendDuration time.Duration
+}
+
+// Anomaly describes a window that deviated from its consumer's baseline
+type Anomaly struct {
+	Consumer string
+	Signals  []string
+	Severity int
+	Requests int
+	Errors   int
+	// SuspendedUntil is set when the anomaly suspended the consumer
+	SuspendedUntil time.Time
+}
+
+// Detector keeps rolling per-consumer baselines of request rate, error ratio and endpoint mix
+// Each completed window is compared with the baseline before being folded into it, so a
+// sustained change alerts once and then becomes the new normal.
+type Detector struct {
+	config    Config
+	eventBus  *events.Bus
+	now       func() time.Time
+	mutex     sync.Mutex
+	consumers map[string]*consumerState
+	// lastEviction is when idle consumers were last forgotten
+	lastEviction time.Time
+}
+
+// consumerState is one consumer's current window and baseline
+type consumerState struct {
+	windowStart    time.Time
+	requests       int
+	errors         int
+	routes         map[string]int
+	baselineWindow int
+	baselineRate   float64
+	baselineErrors float64
+	baselineMix    map[string]float64
+	suspendedUntil time.Time
+}
+
+// NewDetector creates a Detector publishing anomalies to eventBus, which may be nil
+func NewDetector(config Config, eventBus *events.Bus) *Detector {
+	return &Detector{
+		config:    config,
+		eventBus:  eventBus,
+		now:       time.Now,
+		consumers: make(map[string]*consumerState),
+	}
+}
+
+// Suspended reports whether the consumer is automatically suspended, and until when
+func (detector *Detector) Suspended(consumer string) (time.Time, bool) {
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	state, exists := detector.consumers[consumer]
+	if !exists || !detector.now().Before(state.suspendedUntil) {
+		return time.Time{}, false
+	}
+	return state.suspendedUntil, true
+}
+
+// Observe records one completed request of a consumer
+// A status of 400 or above counts as an error.
+func (detector *Detector) Observe(consumer string, route string, statusCode int) {
+	detector.mutex.Lock()
+	now := detector.now()
+
+	state, exists := detector.consumers[consumer]
+	if !exists {
+		detector.evictIdle(now)
+		state = &consumerState{windowStart: now, routes: make(map[string]int)}
+		detector.consumers[consumer] = state
+	}
+
+	var detected *Anomaly
+	if now.Sub(state.windowStart) >= detector.config.Window {
+		detected = detector.closeWindow(consumer, state, now)
+	}
+
+	state.requests++
+	if statusCode >= 400 {
+		state.errors++
+	}
+	state.routes[route]++
+	detector.mutex.Unlock()
+
+	if detected != nil {
+		detector.publish(detected)
+	}
+}
+
+// closeWindow judges the finished window, folds it into the baseline and starts a new one
+// Windows without any requests in between count towards the baseline as empty windows.
+func (detector *Detector) closeWindow(consumer string, state *consumerState, now time.Time) *Anomaly {
+	var detected *Anomaly
+	if state.baselineWindow >= warmupWindows && state.requests >= minWindowRequests {
+		detected = detector.judge(consumer, state)
+	}
+
+	state.fold()
+	state.requests = 0
+	state.errors = 0
+	state.routes = make(map[string]int)
+
+	elapsedWindows := int(now.Sub(state.windowStart) / detector.config.Window)
+	for i := 1; i < elapsedWindows && i < warmupWindows; i++ {
+		state.fold()
+	}
+	state.windowStart = now
+
+	if detected != nil && detector.config.SuspendSeverity > 0 && detected.Severity >= detector.config.SuspendSeverity {
+		state.suspendedUntil = now.Add(detector.config.SuspendDuration)
+		detected.SuspendedUntil = state.suspendedUntil
+	}
+	return detected
+}
+
+// judge compares the window with the baseline and returns the raised signals, if any
+func (detector *Detector) judge(consumer string, state *consumerState) *Anomaly {
+	var signals []string
+
+	if float64(state.requests) > rateSpikeFactor*math.Max(state.baselineRate, 1) {
+		signals = append(signals, SignalRequestRate)
+	}
+
+	errorRatio := float64(state.errors) / float64(state.requests)
+	if errorRatio-state.baselineErrors > errorRatioJump {
+		signals = append(signals, SignalErrorRatio)
+	}
+
+	// Total variation distance between the window's endpoint shares and the baseline's
+	distance := 0.0
+	for route, share := range state.baselineMix {
+		distance += math.Abs(float64(state.routes[route])/float64(state.requests) - share)
+	}
+	for route, count := range state.routes {
+		if _, known := state.baselineMix[route]; !known {
+			distance += float64(count) / float64(state.requests)
+		}
+	}
+	if distance/2 > endpointMixShift {
+		signals = append(signals, SignalEndpointMix)
+	}
+
+	if len(signals) == 0 {
+		return nil
+	}
+	return &Anomaly{
+		Consumer: consumer,
+		Signals:  signals,
+		Severity: len(signals),
+		Requests: state.requests,
+		Errors:   state.errors,
+	}
+}
+
+// fold blends the current window into the exponentially weighted baseline
+func (state *consumerState) fold() {
+	weight := baselineWeight
+	if state.baselineWindow == 0 {
+		weight = 1
+	}
+	state.baselineWindow++
+
+	errorRatio := 0.0
+	if state.requests > 0 {
+		errorRatio = float64(state.errors) / float64(state.requests)
+	}
+	state.baselineRate += weight * (float64(state.requests) - state.baselineRate)
+	state.baselineErrors += weight * (errorRatio - state.baselineErrors)
+
+	if state.requests == 0 {
+		return
+	}
+	if state.baselineMix == nil {
+		state.baselineMix = make(map[string]float64)
+	}
+	for route := range state.baselineMix {
+		state.baselineMix[route] *= 1 - weight
+	}
+	for route, count := range state.routes {
+		state.baselineMix[route] += weight * float64(count) / float64(state.requests)
+	}
+}
+
+// evictIdle forgets consumers that haven't been seen for idleWindows windows and aren't suspended
+// It runs at most once per window when a new consumer arrives, so the map can't grow with one-off keys.
+func (detector *Detector) evictIdle(now time.Time) {
+	if now.Sub(detector.lastEviction) < detector.config.Window {
+		return
+	}
+	detector.lastEviction = now
+
+	for consumer, state := range detector.consumers {
+		if now.Sub(state.windowStart) > idleWindows*detector.config.Window && !now.Before(state.suspendedUntil) {
+			delete(detector.consumers, consumer)
+		}
+	}
+}
+
+// publish emits the anomaly.detected event, and apikey.suspended when the consumer was suspended
+func (detector *Detector) publish(detected *Anomaly) {
+	detector.eventBus.Publish(events.TypeAnomalyDetected, map[string]interface{}{
+		"apiKeyFingerprint": detected.Consumer,
+		"signals":           detected.Signals,
+		"severity":          detected.Severity,
+		"requests":          detected.Requests,
+		"errors":            detected.Errors,
+		"window":            detector.config.Window.String(),
+	})
+
+	if !detected.SuspendedUntil.IsZero() {
+		detector.eventBus.Publish(events.TypeAPIKeySuspended, map[string]interface{}{
+			"apiKeyFingerprint": detected.Consumer,
+			"severity":          detected.Severity,
+			"suspendedUntil":    detected.SuspendedUntil,
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+)
+
+// testClock is a controllable clock for detector tests
+type testClock struct {
+	current time.Time
+}
+
+func (clock *testClock) now() time.Time {
+	return clock.current
+}
+
+// newTestDetector creates a detector with one-minute windows driven by clock
+func newTestDetector(clock *testClock, suspendSeverity int) *Detector {
+	detector := NewDetector(Config{Window: time.Minute, SuspendSeverity: suspendSeverity, SuspendDuration: 15 * time.Minute}, nil)
+	detector.now = clock.now
+	return detector
+}
+
+// observeWindow records one window of traffic and moves the clock to the next window
+func observeWindow(detector *Detector, clock *testClock, consumer string, route string, requests int, errors int) {
+	for i := 0; i < requests; i++ {
+		statusCode := 200
+		if i < errors {
+			statusCode = 500
+		}
+		detector.Observe(consumer, route, statusCode)
+	}
+	clock.current = clock.current.Add(time.Minute)
+}
+
+// warmUp builds a steady baseline of 30 successful /summoner requests per window
+func warmUp(detector *Detector, clock *testClock, consumer string) {
+	for i := 0; i < warmupWindows+1; i++ {
+		observeWindow(detector, clock, consumer, "/api/v1/summoner", 30, 0)
+	}
+}
+
+// TestDetector_SteadyTraffic tests that traffic matching the baseline raises nothing
+func TestDetector_SteadyTraffic(t *testing.T) {
+	clock := &testClock{current: time.Unix(1700000000, 0)}
+	detector := newTestDetector(clock, 1)
+	warmUp(detector, clock, "key-a")
+
+	detector.mutex.Lock()
+	state := detector.consumers["key-a"]
+	detected := detector.judge("key-a", state)
+	detector.mutex.Unlock()
+
+	if detected != nil {
+		t.Errorf("Expected no anomaly for steady traffic, got %v", detected.Signals)
+	}
+}
+
+// TestDetector_Signals tests each signal against a warmed-up baseline
+func TestDetector_Signals(t *testing.T) {
+	testCases := []struct {
+		name           string
+		route          string
+		requests       int
+		errors         int
+		expectedSignal string
+	}{
+		{"request rate spike", "/api/v1/summoner", 300, 0, SignalRequestRate},
+		{"error ratio jump", "/api/v1/summoner", 30, 25, SignalErrorRatio},
+		{"endpoint mix shift", "/api/v1/analyze", 30, 0, SignalEndpointMix},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clock := &testClock{current: time.Unix(1700000000, 0)}
+			detector := newTestDetector(clock, 0)
+			warmUp(detector, clock, "key-a")
+
+			// Record the window inside the clock's current minute, then judge it directly
+			for i := 0; i < testCase.requests; i++ {
+				statusCode := 200
+				if i < testCase.errors {
+					statusCode = 500
+				}
+				detector.Observe("key-a", testCase.route, statusCode)
+			}
+			detector.mutex.Lock()
+			detected := detector.judge("key-a", detector.consumers["key-a"])
+			detector.mutex.Unlock()
+
+			if detected == nil || len(detected.Signals) != 1 || detected.Signals[0] != testCase.expectedSignal {
+				t.Errorf("Expected signal %s, got %v", testCase.expectedSignal, detected)
+			}
+		})
+	}
+}
+
+// TestDetector_Suspension tests that an anomaly at the suspend severity suspends the consumer
+func TestDetector_Suspension(t *testing.T) {
+	clock := &testClock{current: time.Unix(1700000000, 0)}
+	detector := newTestDetector(clock, 2)
+	warmUp(detector, clock, "key-a")
+
+	// A burst of failing requests to a new endpoint raises all three signals
+	observeWindow(detector, clock, "key-a", "/api/v1/analyze", 300, 300)
+	detector.Observe("key-a", "/api/v1/summoner", 200)
+
+	suspendedUntil, suspended := detector.Suspended("key-a")
+	if !suspended || !suspendedUntil.Equal(clock.current.Add(15*time.Minute)) {
+		t.Fatalf("Expected key-a to be suspended for 15 minutes, got %v (%v)", suspended, suspendedUntil)
+	}
+	if _, suspended := detector.Suspended("key-b"); suspended {
+		t.Error("Expected other keys not to be suspended")
+	}
+
+	clock.current = clock.current.Add(16 * time.Minute)
+	if _, suspended := detector.Suspended("key-a"); suspended {
+		t.Error("Expected the suspension to expire")
+	}
+}
+
+// TestDetector_NoSuspensionBelowSeverity tests that lower-severity anomalies only alert
+func TestDetector_NoSuspensionBelowSeverity(t *testing.T) {
+	clock := &testClock{current: time.Unix(1700000000, 0)}
+	detector := newTestDetector(clock, 3)
+	warmUp(detector, clock, "key-a")
+
+	observeWindow(detector, clock, "key-a", "/api/v1/summoner", 300, 0)
+	detector.Observe("key-a", "/api/v1/summoner", 200)
+
+	if _, suspended := detector.Suspended("key-a"); suspended {
+		t.Error("Expected a single-signal anomaly not to suspend at severity 3")
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// Limits applied to every JSON request body
+const (
+	maxRequestBodyBytes = 64 << 10
+	maxJSONDepth        = 8
+)
+
+// decodeJSONBody strictly decodes the request body into target
+// Unknown fields, trailing data, oversized bodies, and deeply nested documents are
+// rejected with a message naming the problem, so client typos like "regionn" don't
+// surface later as a confusing missing-field error.
+func decodeJSONBody(writer http.ResponseWriter, request *http.Request, target interface{}) *apierrors.APIError {
+	body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxRequestBodyBytes))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return apierrors.InvalidRequestBody(fmt.Sprintf("Request body must be at most %d bytes", maxRequestBodyBytes))
+		}
+		return apierrors.InvalidRequestBody("Unable to read request body")
+	}
+
+	if exceedsJSONDepth(body, maxJSONDepth) {
+		return apierrors.InvalidRequestBody(fmt.Sprintf("JSON nesting must be at most %d levels deep", maxJSONDepth))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(target); err != nil {
+		return decodeError(err)
+	}
+
+	// Reject a second document or trailing garbage after the object; More would miss a stray } or ]
+	if _, err := decoder.Token(); err != io.EOF {
+		return apierrors.InvalidRequestBody("Request body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+// decodeError converts an encoding/json error into a precise client message
+func decodeError(err error) *apierrors.APIError {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		return apierrors.InvalidRequestBody(fmt.Sprintf("Invalid JSON format at position %d", syntaxError.Offset))
+	case errors.As(err, &typeError):
+		if typeError.Field == "" {
+			return apierrors.InvalidRequestBody("Request body must be a JSON object")
+		}
+		return apierrors.InvalidRequestBody(fmt.Sprintf("Field '%s' must be of type %s", typeError.Field, jsonTypeName(typeError.Type.Kind())))
+	case errors.Is(err, io.EOF):
+		return apierrors.InvalidRequestBody("Request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apierrors.InvalidRequestBody("Invalid JSON format: unexpected end of input")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		fieldName := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apierrors.InvalidRequestBody(fmt.Sprintf("Unknown field '%s'", fieldName))
+	default:
+		return apierrors.InvalidRequestBody("Invalid JSON format")
+	}
+}
+
+// jsonTypeName maps a Go kind to the JSON type clients should send
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
+
+// exceedsJSONDepth reports whether objects and arrays in body nest deeper than maxDepth
+// Brackets inside strings are skipped; malformed JSON is left for the decoder to report.
+func exceedsJSONDepth(body []byte, maxDepth int) bool {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, character := range body {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && character == '\\':
+			escaped = true
+		case character == '"':
+			inString = !inString
+		case inString:
+		case character == '{' || character == '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case character == '}' || character == ']':
+			depth--
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/validation"
+)
+
+// TestDecodeJSONBody_Valid tests that a well-formed body is decoded
+func TestDecodeJSONBody_Valid(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/matches", strings.NewReader(`{"region":"na","gameName":"TestPlayer","tagLine":"NA1","count":5}`))
+
+	var matchRequest validation.MatchRequest
+	if apiError := decodeJSONBody(httptest.NewRecorder(), request, &matchRequest); apiError != nil {
+		t.Fatalf("Unexpected error: %v", apiError)
+	}
+
+	if matchRequest.Count != 5 {
+		t.Errorf("Expected count 5, got %d", matchRequest.Count)
+	}
+}
+
+// TestDecodeJSONBody_Errors tests the messages returned for malformed bodies
+func TestDecodeJSONBody_Errors(t *testing.T) {
+	testCases := []struct {
+		name            string
+		body            string
+		expectedMessage string
+	}{
+		{"unknown field", `{"regionn":"na"}`, "Unknown field 'regionn'"},
+		{"wrong type", `{"count":"ten"}`, "Field 'count' must be of type number"},
+		{"not an object", `["na"]`, "Request body must be a JSON object"},
+		{"empty body", ``, "Request body is empty"},
+		{"truncated", `{"region":`, "Invalid JSON format: unexpected end of input"},
+		{"syntax error", `{"region" "na"}`, "Invalid JSON format at position"},
+		{"trailing data", `{"region":"na"} {}`, "Request body must contain a single JSON object"},
+		{"trailing brace", `{"region":"na"}}`, "Request body must contain a single JSON object"},
+		{"trailing bracket", `{"region":"na"}]`, "Request body must contain a single JSON object"},
+		{"too deep", `{"region":[[[[[[[[[]]]]]]]]]}`, "JSON nesting must be at most"},
+		{"too large", `{"gameName":"` + strings.Repeat("a", maxRequestBodyBytes) + `"}`, "Request body must be at most"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/matches", strings.NewReader(testCase.body))
+
+			var matchRequest validation.MatchRequest
+			apiError := decodeJSONBody(httptest.NewRecorder(), request, &matchRequest)
+			if apiError == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if !strings.HasPrefix(apiError.Message, testCase.expectedMessage) {
+				t.Errorf("Expected message starting with %q, got %q", testCase.expectedMessage, apiError.Message)
+			}
+		})
+	}
+}
+
+// TestExceedsJSONDepth_IgnoresBracketsInStrings tests that brackets inside strings don't count
+func TestExceedsJSONDepth_IgnoresBracketsInStrings(t *testing.T) {
+	body := []byte(`{"gameName":"[[[[[[[[[[\"{{{{"}`)
+
+	if exceedsJSONDepth(body, 2) {
+		t.Error("Expected brackets inside strings to be ignored")
+	}
+}
@@ -5,25 +5,61 @@ import (
 	"net/http"
 
 	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/health"
 	"github.com/OPGLOL/opgl-gateway-service/internal/models"
 	"github.com/OPGLOL/opgl-gateway-service/internal/proxy"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
 	"github.com/OPGLOL/opgl-gateway-service/internal/validation"
 )
 
+// defaultMatchCount is the number of matches fetched when the client doesn't ask for a count
+const defaultMatchCount = 20
+
+// MatchCountLimit bounds the count requested from /matches
+// The zero value allows up to validation.MaxMatchCount and rejects larger counts.
+type MatchCountLimit struct {
+	// Max is the largest count forwarded to the data service (0 means validation.MaxMatchCount)
+	Max int
+	// Clamp lowers counts above Max to Max instead of rejecting them with MATCH_COUNT_EXCEEDED
+	Clamp bool
+}
+
 // Handler manages HTTP request handlers for the gateway
 type Handler struct {
-	serviceProxy proxy.ServiceProxyInterface
+	serviceProxy    proxy.ServiceProxyInterface
+	eventBus        *events.Bus
+	healthChecker   *health.Checker
+	matchCountLimit MatchCountLimit
 }
 
 // NewHandler creates a new Handler instance
-func NewHandler(serviceProxy proxy.ServiceProxyInterface) *Handler {
+// eventBus may be nil, in which case no domain events are published
+// healthChecker may be nil, in which case /health reports only the gateway itself
+func NewHandler(serviceProxy proxy.ServiceProxyInterface, eventBus *events.Bus, healthChecker *health.Checker, matchCountLimit MatchCountLimit) *Handler {
+	if matchCountLimit.Max <= 0 || matchCountLimit.Max > validation.MaxMatchCount {
+		matchCountLimit.Max = validation.MaxMatchCount
+	}
+
 	return &Handler{
-		serviceProxy: serviceProxy,
+		serviceProxy:    serviceProxy,
+		eventBus:        eventBus,
+		healthChecker:   healthChecker,
+		matchCountLimit: matchCountLimit,
 	}
 }
 
 // HealthCheck handles health check requests
+// With a health checker the response includes per-upstream reachability and a
+// healthy/degraded verdict. The status code stays 200 while the gateway itself
+// is serving, so container health checks don't restart it over a backend outage.
 func (handler *Handler) HealthCheck(writer http.ResponseWriter, request *http.Request) {
+	if handler.healthChecker != nil {
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(handler.healthChecker.Check(request.Context()))
+		return
+	}
+
 	response := map[string]string{
 		"status":  "healthy",
 		"service": "opgl-gateway",
@@ -32,15 +68,55 @@ func (handler *Handler) HealthCheck(writer http.ResponseWriter, request *http.Re
 	json.NewEncoder(writer).Encode(response)
 }
 
+// readinessResponse is the body of the readiness probe
+type readinessResponse struct {
+	Status    string                           `json:"status"`
+	Upstreams map[string]health.UpstreamStatus `json:"upstreams,omitempty"`
+}
+
+// ReadinessCheck handles readiness probes
+// It answers 503 while a required upstream is down so load balancers stop routing
+// to this instance and shed load instead of queuing requests that would fail.
+// Without a health checker the gateway is always ready.
+func (handler *Handler) ReadinessCheck(writer http.ResponseWriter, request *http.Request) {
+	response := readinessResponse{Status: health.StatusReady}
+	statusCode := http.StatusOK
+	if handler.healthChecker != nil {
+		ready, report := handler.healthChecker.Ready(request.Context())
+		response.Upstreams = report.Upstreams
+		if !ready {
+			response.Status = health.StatusNotReady
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(statusCode)
+	json.NewEncoder(writer).Encode(response)
+}
+
+// defaultRegion fills in the default region of the request host's tenant when the client sent none
+func defaultRegion(request *http.Request, region string) string {
+	if tenant := tenants.FromContext(request.Context()); region == "" && tenant != nil {
+		return tenant.DefaultRegion
+	}
+	return region
+}
+
 // GetSummoner proxies summoner requests to opgl-data service using Riot ID
 func (handler *Handler) GetSummoner(writer http.ResponseWriter, request *http.Request) {
 	var summonerRequest validation.SummonerRequest
 
-	if err := json.NewDecoder(request.Body).Decode(&summonerRequest); err != nil {
-		apierrors.WriteError(writer, apierrors.InvalidRequestBody("Invalid JSON format"))
+	if decodeErr := decodeJSONBody(writer, request, &summonerRequest); decodeErr != nil {
+		apierrors.WriteError(writer, decodeErr)
 		return
 	}
 
+	// Normalize the Riot ID so equivalent spellings reach the data service identically
+	summonerRequest.GameName, summonerRequest.TagLine = validation.NormalizeRiotID(summonerRequest.GameName, summonerRequest.TagLine)
+	summonerRequest.Region = defaultRegion(request, summonerRequest.Region)
+
 	// Validate request
 	validationResult := validation.ValidateSummonerRequest(&summonerRequest)
 	if !validationResult.IsValid() {
@@ -51,20 +127,16 @@ func (handler *Handler) GetSummoner(writer http.ResponseWriter, request *http.Re
 	// Normalize region to lowercase for consistent API calls
 	normalizedRegion := validation.NormalizeRegion(summonerRequest.Region)
 
-	summoner, err := handler.serviceProxy.GetSummonerByRiotID(normalizedRegion, summonerRequest.GameName, summonerRequest.TagLine)
+	summoner, err := handler.serviceProxy.GetSummonerByRiotID(request.Context(), normalizedRegion, summonerRequest.GameName, summonerRequest.TagLine)
 	if err != nil {
-		// Check if the error is already an APIError
-		if apiErr, ok := err.(*apierrors.APIError); ok {
-			apierrors.WriteError(writer, apiErr)
-			return
-		}
-		// Wrap unknown errors as internal errors
-		apierrors.WriteError(writer, apierrors.InternalError("An unexpected error occurred"))
+		// Upstream failures (e.g. unknown player) keep their code; anything else is an internal error
+		apierrors.WriteError(writer, apierrors.FromError(err))
 		return
 	}
 
+	// Map to the external representation so the PUUID never leaves the gateway
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(summoner)
+	json.NewEncoder(writer).Encode(models.NewSummonerResponse(summoner))
 }
 
 // GetMatches proxies match history requests to opgl-data service
@@ -72,11 +144,23 @@ func (handler *Handler) GetSummoner(writer http.ResponseWriter, request *http.Re
 func (handler *Handler) GetMatches(writer http.ResponseWriter, request *http.Request) {
 	var matchRequest validation.MatchRequest
 
-	if err := json.NewDecoder(request.Body).Decode(&matchRequest); err != nil {
-		apierrors.WriteError(writer, apierrors.InvalidRequestBody("Invalid JSON format"))
+	if decodeErr := decodeJSONBody(writer, request, &matchRequest); decodeErr != nil {
+		apierrors.WriteError(writer, decodeErr)
 		return
 	}
 
+	// Enforce the configured match count limit before general validation
+	if matchRequest.Count > handler.matchCountLimit.Max {
+		if !handler.matchCountLimit.Clamp {
+			apierrors.WriteError(writer, apierrors.MatchCountExceeded(handler.matchCountLimit.Max))
+			return
+		}
+		matchRequest.Count = handler.matchCountLimit.Max
+	}
+
+	matchRequest.GameName, matchRequest.TagLine = validation.NormalizeRiotID(matchRequest.GameName, matchRequest.TagLine)
+	matchRequest.Region = defaultRegion(request, matchRequest.Region)
+
 	// Validate request
 	validationResult := validation.ValidateMatchRequest(&matchRequest)
 	if !validationResult.IsValid() {
@@ -84,11 +168,11 @@ func (handler *Handler) GetMatches(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	// Normalize region and set default count
+	// Normalize region and set default count (never above the configured maximum)
 	normalizedRegion := validation.NormalizeRegion(matchRequest.Region)
 	count := matchRequest.Count
 	if count <= 0 {
-		count = 20
+		count = min(defaultMatchCount, handler.matchCountLimit.Max)
 	}
 
 	var matches []models.Match
@@ -96,36 +180,34 @@ func (handler *Handler) GetMatches(writer http.ResponseWriter, request *http.Req
 
 	// Check if PUUID is provided for direct lookup
 	if matchRequest.PUUID != "" {
-		matches, err = handler.serviceProxy.GetMatchesByPUUID(normalizedRegion, matchRequest.PUUID, count)
+		matches, err = handler.serviceProxy.GetMatchesByPUUID(request.Context(), normalizedRegion, matchRequest.PUUID, count)
 	} else {
 		// Use Riot ID lookup
-		matches, err = handler.serviceProxy.GetMatchesByRiotID(normalizedRegion, matchRequest.GameName, matchRequest.TagLine, count)
+		matches, err = handler.serviceProxy.GetMatchesByRiotID(request.Context(), normalizedRegion, matchRequest.GameName, matchRequest.TagLine, count)
 	}
 
 	if err != nil {
-		// Check if the error is already an APIError
-		if apiErr, ok := err.(*apierrors.APIError); ok {
-			apierrors.WriteError(writer, apiErr)
-			return
-		}
-		// Wrap unknown errors as internal errors
-		apierrors.WriteError(writer, apierrors.InternalError("An unexpected error occurred"))
+		// Check if the error is already an APIError, possibly wrapped
+		apierrors.WriteError(writer, apierrors.FromError(err))
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(matches)
+	json.NewEncoder(writer).Encode(models.NewMatchResponses(matches))
 }
 
 // AnalyzePlayer orchestrates player analysis by calling both data and cortex services using Riot ID
 func (handler *Handler) AnalyzePlayer(writer http.ResponseWriter, request *http.Request) {
 	var analyzeRequest validation.AnalyzeRequest
 
-	if err := json.NewDecoder(request.Body).Decode(&analyzeRequest); err != nil {
-		apierrors.WriteError(writer, apierrors.InvalidRequestBody("Invalid JSON format"))
+	if decodeErr := decodeJSONBody(writer, request, &analyzeRequest); decodeErr != nil {
+		apierrors.WriteError(writer, decodeErr)
 		return
 	}
 
+	analyzeRequest.GameName, analyzeRequest.TagLine = validation.NormalizeRiotID(analyzeRequest.GameName, analyzeRequest.TagLine)
+	analyzeRequest.Region = defaultRegion(request, analyzeRequest.Region)
+
 	// Validate request
 	validationResult := validation.ValidateAnalyzeRequest(&analyzeRequest)
 	if !validationResult.IsValid() {
@@ -137,38 +219,45 @@ func (handler *Handler) AnalyzePlayer(writer http.ResponseWriter, request *http.
 	normalizedRegion := validation.NormalizeRegion(analyzeRequest.Region)
 
 	// Step 1: Get summoner data from opgl-data
-	summoner, err := handler.serviceProxy.GetSummonerByRiotID(normalizedRegion, analyzeRequest.GameName, analyzeRequest.TagLine)
+	summoner, err := handler.serviceProxy.GetSummonerByRiotID(request.Context(), normalizedRegion, analyzeRequest.GameName, analyzeRequest.TagLine)
 	if err != nil {
-		if apiErr, ok := err.(*apierrors.APIError); ok {
-			apierrors.WriteError(writer, apiErr)
-			return
-		}
-		apierrors.WriteError(writer, apierrors.InternalError("An unexpected error occurred"))
+		apierrors.WriteError(writer, apierrors.FromError(err))
 		return
 	}
 
 	// Step 2: Get match history from opgl-data (using internal method with PUUID)
-	matches, err := handler.serviceProxy.GetMatchesByPUUID(normalizedRegion, summoner.PUUID, 20)
+	matches, err := handler.serviceProxy.GetMatchesByPUUID(request.Context(), normalizedRegion, summoner.PUUID, defaultMatchCount)
 	if err != nil {
-		if apiErr, ok := err.(*apierrors.APIError); ok {
-			apierrors.WriteError(writer, apiErr)
-			return
-		}
-		apierrors.WriteError(writer, apierrors.InternalError("An unexpected error occurred"))
+		apierrors.WriteError(writer, apierrors.FromError(err))
 		return
 	}
 
 	// Step 3: Send data to opgl-cortex-engine for analysis
-	analysisResult, err := handler.serviceProxy.AnalyzePlayer(summoner, matches)
+	analysisResult, err := handler.serviceProxy.AnalyzePlayer(request.Context(), summoner, matches)
 	if err != nil {
-		if apiErr, ok := err.(*apierrors.APIError); ok {
-			apierrors.WriteError(writer, apiErr)
-			return
-		}
-		apierrors.WriteError(writer, apierrors.InternalError("An unexpected error occurred"))
+		apierrors.WriteError(writer, apierrors.FromError(err))
 		return
 	}
 
+	// Step 4: Notify downstream consumers (IDs and metadata only, never the full analysis)
+	handler.publishAnalysisCompleted(normalizedRegion, summoner, matches, analysisResult)
+
 	writer.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(writer).Encode(analysisResult)
 }
+
+// publishAnalysisCompleted emits an analysis.completed event for a finished analysis
+func (handler *Handler) publishAnalysisCompleted(region string, summoner *models.Summoner, matches []models.Match, analysisResult *models.AnalysisResult) {
+	matchIDs := make([]string, len(matches))
+	for i, match := range matches {
+		matchIDs[i] = match.MatchID
+	}
+
+	handler.eventBus.Publish(events.TypeAnalysisCompleted, map[string]interface{}{
+		"region":     region,
+		"summonerId": summoner.ID,
+		"matchIds":   matchIDs,
+		"matchCount": len(matches),
+		"analyzedAt": analysisResult.AnalyzedAt,
+	})
+}
@@ -2,14 +2,22 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/health"
 	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
 )
 
 // MockServiceProxy is a mock implementation of ServiceProxyInterface for testing
@@ -20,28 +28,28 @@ type MockServiceProxy struct {
 	AnalyzePlayerFunc       func(summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error)
 }
 
-func (m *MockServiceProxy) GetSummonerByRiotID(region, gameName, tagLine string) (*models.Summoner, error) {
+func (m *MockServiceProxy) GetSummonerByRiotID(ctx context.Context, region, gameName, tagLine string) (*models.Summoner, error) {
 	if m.GetSummonerByRiotIDFunc != nil {
 		return m.GetSummonerByRiotIDFunc(region, gameName, tagLine)
 	}
 	return nil, nil
 }
 
-func (m *MockServiceProxy) GetMatchesByRiotID(region, gameName, tagLine string, count int) ([]models.Match, error) {
+func (m *MockServiceProxy) GetMatchesByRiotID(ctx context.Context, region, gameName, tagLine string, count int) ([]models.Match, error) {
 	if m.GetMatchesByRiotIDFunc != nil {
 		return m.GetMatchesByRiotIDFunc(region, gameName, tagLine, count)
 	}
 	return nil, nil
 }
 
-func (m *MockServiceProxy) GetMatchesByPUUID(region, puuid string, count int) ([]models.Match, error) {
+func (m *MockServiceProxy) GetMatchesByPUUID(ctx context.Context, region, puuid string, count int) ([]models.Match, error) {
 	if m.GetMatchesByPUUIDFunc != nil {
 		return m.GetMatchesByPUUIDFunc(region, puuid, count)
 	}
 	return nil, nil
 }
 
-func (m *MockServiceProxy) AnalyzePlayer(summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error) {
+func (m *MockServiceProxy) AnalyzePlayer(ctx context.Context, summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error) {
 	if m.AnalyzePlayerFunc != nil {
 		return m.AnalyzePlayerFunc(summoner, matches)
 	}
@@ -51,7 +59,7 @@ func (m *MockServiceProxy) AnalyzePlayer(summoner *models.Summoner, matches []mo
 // TestNewHandler tests the NewHandler constructor
 func TestNewHandler(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	if handler == nil {
 		t.Fatal("Expected handler to not be nil")
@@ -111,6 +119,76 @@ func TestHealthCheckContentType(t *testing.T) {
 	}
 }
 
+// TestHealthCheck_WithUpstreams tests that the health report includes upstream status
+func TestHealthCheck_WithUpstreams(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	handler := NewHandler(&MockServiceProxy{}, nil, health.NewChecker(health.Upstream{Name: "data", URL: failingServer.URL}), MatchCountLimit{})
+
+	request := httptest.NewRequest("POST", "/health", nil)
+	responseRecorder := httptest.NewRecorder()
+	handler.HealthCheck(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(responseRecorder.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if report.Status != health.StatusDegraded {
+		t.Errorf("Expected status '%s', got '%s'", health.StatusDegraded, report.Status)
+	}
+
+	if report.Upstreams["data"].Status != health.UpstreamDown {
+		t.Errorf("Expected data upstream '%s', got '%s'", health.UpstreamDown, report.Upstreams["data"].Status)
+	}
+}
+
+// TestReadinessCheck tests that a down required upstream makes the gateway not ready
+func TestReadinessCheck(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	testCases := []struct {
+		name           string
+		checker        *health.Checker
+		expectedCode   int
+		expectedStatus string
+	}{
+		{"no checker", nil, http.StatusOK, health.StatusReady},
+		{"optional upstream down", health.NewChecker(health.Upstream{Name: "cortex", URL: failingServer.URL}), http.StatusOK, health.StatusReady},
+		{"required upstream down", health.NewChecker(health.Upstream{Name: "data", URL: failingServer.URL, Required: true}), http.StatusServiceUnavailable, health.StatusNotReady},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&MockServiceProxy{}, nil, testCase.checker, MatchCountLimit{})
+			responseRecorder := httptest.NewRecorder()
+			handler.ReadinessCheck(responseRecorder, httptest.NewRequest("GET", "/readyz", nil))
+
+			if responseRecorder.Code != testCase.expectedCode {
+				t.Errorf("Expected status code %d, got %d", testCase.expectedCode, responseRecorder.Code)
+			}
+
+			var response readinessResponse
+			if err := json.NewDecoder(responseRecorder.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Status != testCase.expectedStatus {
+				t.Errorf("Expected status '%s', got '%s'", testCase.expectedStatus, response.Status)
+			}
+		})
+	}
+}
+
 // TestGetSummoner_Success tests successful summoner lookup
 func TestGetSummoner_Success(t *testing.T) {
 	expectedSummoner := &models.Summoner{
@@ -131,7 +209,7 @@ func TestGetSummoner_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
@@ -153,20 +231,49 @@ func TestGetSummoner_Success(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
 	}
 
-	var response models.Summoner
+	var response map[string]interface{}
 	err = json.NewDecoder(responseRecorder.Body).Decode(&response)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	if response.PUUID != expectedSummoner.PUUID {
-		t.Errorf("Expected PUUID '%s', got '%s'", expectedSummoner.PUUID, response.PUUID)
+	if response["id"] != expectedSummoner.ID {
+		t.Errorf("Expected ID '%s', got '%v'", expectedSummoner.ID, response["id"])
+	}
+
+	if _, exists := response["puuid"]; exists {
+		t.Error("Expected PUUID to be excluded from the response")
+	}
+}
+
+// TestGetSummoner_TenantDefaultRegion tests that requests on a tenant host may omit the region
+func TestGetSummoner_TenantDefaultRegion(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetSummonerByRiotIDFunc: func(region, gameName, tagLine string) (*models.Summoner, error) {
+			if region != "euw" {
+				t.Errorf("Expected the tenant's region 'euw', got '%s'", region)
+			}
+			return &models.Summoner{Name: "TestPlayer"}, nil
+		},
+	}
+
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
+
+	bodyBytes, _ := json.Marshal(map[string]string{"gameName": "TestPlayer", "tagLine": "NA1"})
+	request := httptest.NewRequest("POST", "/api/v1/summoner", bytes.NewBuffer(bodyBytes))
+	request = request.WithContext(tenants.WithTenant(request.Context(), &tenants.Tenant{ID: "acme", DefaultRegion: "euw"}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.GetSummoner(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
 	}
 }
 
 // TestGetSummoner_InvalidJSON tests invalid JSON request body
 func TestGetSummoner_InvalidJSON(t *testing.T) {
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	request, err := http.NewRequest("POST", "/api/v1/summoner", bytes.NewBufferString("invalid json"))
 	if err != nil {
@@ -195,7 +302,7 @@ func TestGetSummoner_MissingFields(t *testing.T) {
 		{"empty tagLine", map[string]string{"region": "na", "gameName": "Test", "tagLine": ""}},
 	}
 
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -221,7 +328,7 @@ func TestGetSummoner_ServiceError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
@@ -241,6 +348,43 @@ func TestGetSummoner_ServiceError(t *testing.T) {
 	}
 }
 
+// TestGetSummoner_PlayerNotFound tests that a wrapped PLAYER_NOT_FOUND error reaches the client as a 404
+func TestGetSummoner_PlayerNotFound(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetSummonerByRiotIDFunc: func(region, gameName, tagLine string) (*models.Summoner, error) {
+			return nil, fmt.Errorf("lookup failed: %w", apierrors.PlayerNotFound(gameName, tagLine))
+		},
+	}
+
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
+
+	requestBody := map[string]string{
+		"region":   "na",
+		"gameName": "TestPlayer",
+		"tagLine":  "NA1",
+	}
+	bodyBytes, _ := json.Marshal(requestBody)
+
+	request, _ := http.NewRequest("POST", "/api/v1/summoner", bytes.NewBuffer(bodyBytes))
+	request.Header.Set("Content-Type", "application/json")
+
+	responseRecorder := httptest.NewRecorder()
+	handler.GetSummoner(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, responseRecorder.Code)
+	}
+
+	var errorResponse apierrors.ErrorResponse
+	if err := json.NewDecoder(responseRecorder.Body).Decode(&errorResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if errorResponse.Error.Code != apierrors.ErrCodePlayerNotFound {
+		t.Errorf("Expected code '%s', got '%s'", apierrors.ErrCodePlayerNotFound, errorResponse.Error.Code)
+	}
+}
+
 // TestGetMatches_Success tests successful match history lookup
 func TestGetMatches_Success(t *testing.T) {
 	expectedMatches := []models.Match{
@@ -257,7 +401,7 @@ func TestGetMatches_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]interface{}{
 		"region":   "na",
@@ -288,6 +432,38 @@ func TestGetMatches_Success(t *testing.T) {
 	}
 }
 
+// TestGetMatches_HidesParticipantPUUIDs tests that participant PUUIDs are excluded from the response
+func TestGetMatches_HidesParticipantPUUIDs(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetMatchesByRiotIDFunc: func(region, gameName, tagLine string, count int) ([]models.Match, error) {
+			return []models.Match{
+				{MatchID: "NA1_123", Participants: []models.Participant{{PUUID: "secret-puuid", ChampionName: "Ahri"}}},
+			}, nil
+		},
+	}
+
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
+
+	requestBody := map[string]interface{}{
+		"region":   "na",
+		"gameName": "TestPlayer",
+		"tagLine":  "NA1",
+	}
+	bodyBytes, _ := json.Marshal(requestBody)
+
+	request, _ := http.NewRequest("POST", "/api/v1/matches", bytes.NewBuffer(bodyBytes))
+	responseRecorder := httptest.NewRecorder()
+	handler.GetMatches(responseRecorder, request)
+
+	if strings.Contains(responseRecorder.Body.String(), "puuid") {
+		t.Errorf("Expected no PUUID in response, got %s", responseRecorder.Body.String())
+	}
+
+	if !strings.Contains(responseRecorder.Body.String(), "Ahri") {
+		t.Errorf("Expected participant data in response, got %s", responseRecorder.Body.String())
+	}
+}
+
 // TestGetMatches_DefaultCount tests default count when not provided
 func TestGetMatches_DefaultCount(t *testing.T) {
 	var capturedCount int
@@ -299,7 +475,7 @@ func TestGetMatches_DefaultCount(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]interface{}{
 		"region":   "na",
@@ -319,9 +495,76 @@ func TestGetMatches_DefaultCount(t *testing.T) {
 	}
 }
 
+// TestGetMatches_CountLimitReject tests that counts above the configured maximum are rejected
+func TestGetMatches_CountLimitReject(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetMatchesByRiotIDFunc: func(region, gameName, tagLine string, count int) ([]models.Match, error) {
+			t.Error("Expected data service not to be called")
+			return nil, nil
+		},
+	}
+
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{Max: 50})
+
+	requestBody := map[string]interface{}{
+		"region":   "na",
+		"gameName": "TestPlayer",
+		"tagLine":  "NA1",
+		"count":    51,
+	}
+	bodyBytes, _ := json.Marshal(requestBody)
+
+	request, _ := http.NewRequest("POST", "/api/v1/matches", bytes.NewBuffer(bodyBytes))
+	responseRecorder := httptest.NewRecorder()
+	handler.GetMatches(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, responseRecorder.Code)
+	}
+
+	var errorResponse apierrors.ErrorResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&errorResponse)
+	if errorResponse.Error.Code != apierrors.ErrCodeMatchCountExceeded {
+		t.Errorf("Expected code '%s', got '%s'", apierrors.ErrCodeMatchCountExceeded, errorResponse.Error.Code)
+	}
+}
+
+// TestGetMatches_CountLimitClamp tests that clamp mode lowers counts to the configured maximum
+func TestGetMatches_CountLimitClamp(t *testing.T) {
+	var forwardedCount int
+	mockProxy := &MockServiceProxy{
+		GetMatchesByRiotIDFunc: func(region, gameName, tagLine string, count int) ([]models.Match, error) {
+			forwardedCount = count
+			return []models.Match{}, nil
+		},
+	}
+
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{Max: 50, Clamp: true})
+
+	requestBody := map[string]interface{}{
+		"region":   "na",
+		"gameName": "TestPlayer",
+		"tagLine":  "NA1",
+		"count":    500,
+	}
+	bodyBytes, _ := json.Marshal(requestBody)
+
+	request, _ := http.NewRequest("POST", "/api/v1/matches", bytes.NewBuffer(bodyBytes))
+	responseRecorder := httptest.NewRecorder()
+	handler.GetMatches(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+
+	if forwardedCount != 50 {
+		t.Errorf("Expected count 50 to be forwarded, got %d", forwardedCount)
+	}
+}
+
 // TestGetMatches_InvalidJSON tests invalid JSON request body
 func TestGetMatches_InvalidJSON(t *testing.T) {
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	request, _ := http.NewRequest("POST", "/api/v1/matches", bytes.NewBufferString("invalid json"))
 
@@ -344,7 +587,7 @@ func TestGetMatches_MissingFields(t *testing.T) {
 		{"missing tagLine", map[string]interface{}{"region": "na", "gameName": "Test"}},
 	}
 
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -370,7 +613,7 @@ func TestGetMatches_ServiceError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]interface{}{
 		"region":   "na",
@@ -420,7 +663,7 @@ func TestAnalyzePlayer_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
@@ -440,9 +683,78 @@ func TestAnalyzePlayer_Success(t *testing.T) {
 	}
 }
 
+// recordingPublisher is an events.Publisher that stores published events for assertions
+type recordingPublisher struct {
+	mutex     sync.Mutex
+	published []*events.Event
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, event *events.Event) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.published = append(publisher.published, event)
+	return nil
+}
+
+func (publisher *recordingPublisher) Close() error {
+	return nil
+}
+
+// TestAnalyzePlayer_PublishesAnalysisCompleted tests that a successful analysis emits an event without the payload
+func TestAnalyzePlayer_PublishesAnalysisCompleted(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetSummonerByRiotIDFunc: func(region, gameName, tagLine string) (*models.Summoner, error) {
+			return &models.Summoner{ID: "summoner-id", PUUID: "test-puuid"}, nil
+		},
+		GetMatchesByPUUIDFunc: func(region, puuid string, count int) ([]models.Match, error) {
+			return []models.Match{{MatchID: "NA1_123"}, {MatchID: "NA1_124"}}, nil
+		},
+		AnalyzePlayerFunc: func(summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error) {
+			return &models.AnalysisResult{PlayerStats: map[string]interface{}{"avgKills": 5.5}}, nil
+		},
+	}
+
+	publisher := &recordingPublisher{}
+	eventBus := events.NewBus(10, publisher)
+	handler := NewHandler(mockProxy, eventBus, nil, MatchCountLimit{})
+
+	bodyBytes, _ := json.Marshal(map[string]string{
+		"region":   "NA",
+		"gameName": "TestPlayer",
+		"tagLine":  "NA1",
+	})
+	request, _ := http.NewRequest("POST", "/api/v1/analyze", bytes.NewBuffer(bodyBytes))
+	responseRecorder := httptest.NewRecorder()
+	handler.AnalyzePlayer(responseRecorder, request)
+
+	// Close drains the queue so the event is guaranteed to be delivered
+	eventBus.Close()
+
+	if len(publisher.published) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(publisher.published))
+	}
+
+	event := publisher.published[0]
+	if event.Type != events.TypeAnalysisCompleted {
+		t.Errorf("Expected event type '%s', got '%s'", events.TypeAnalysisCompleted, event.Type)
+	}
+
+	if event.Data["region"] != "na" {
+		t.Errorf("Expected region 'na', got '%v'", event.Data["region"])
+	}
+
+	if event.Data["matchCount"] != 2 {
+		t.Errorf("Expected matchCount 2, got '%v'", event.Data["matchCount"])
+	}
+
+	if _, hasStats := event.Data["playerStats"]; hasStats {
+		t.Error("Expected event to exclude the analysis payload")
+	}
+}
+
 // TestAnalyzePlayer_InvalidJSON tests invalid JSON request body
 func TestAnalyzePlayer_InvalidJSON(t *testing.T) {
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	request, _ := http.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString("invalid json"))
 
@@ -465,7 +777,7 @@ func TestAnalyzePlayer_MissingFields(t *testing.T) {
 		{"missing tagLine", map[string]string{"region": "na", "gameName": "Test"}},
 	}
 
-	handler := NewHandler(&MockServiceProxy{})
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -491,7 +803,7 @@ func TestAnalyzePlayer_SummonerError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
@@ -522,7 +834,7 @@ func TestAnalyzePlayer_MatchHistoryError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
@@ -556,7 +868,7 @@ func TestAnalyzePlayer_AnalysisError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 
 	requestBody := map[string]string{
 		"region":   "na",
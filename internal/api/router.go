@@ -1,7 +1,21 @@
 package api
 
 import (
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+	"github.com/OPGLOL/opgl-gateway-service/internal/anomaly"
+	"github.com/OPGLOL/opgl-gateway-service/internal/docs"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/filters"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
 	"github.com/OPGLOL/opgl-gateway-service/internal/middleware"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+	"github.com/OPGLOL/opgl-gateway-service/internal/slo"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
 	"github.com/gorilla/mux"
 )
 
@@ -9,31 +23,289 @@ import (
 type RouterConfig struct {
 	Handler         *Handler
 	RateLimitClient *middleware.RateLimitServiceClient
+	EventBus        *events.Bus
+	// LookupTimeout bounds summoner and match lookups (zero disables the deadline)
+	LookupTimeout time.Duration
+	// AnalyzeTimeout bounds the orchestrated analysis (zero disables the deadline)
+	AnalyzeTimeout time.Duration
+	// LookupRateLimitCost and AnalyzeRateLimitCost are the requests each call counts as against the rate limit (zero counts as one)
+	LookupRateLimitCost  int
+	AnalyzeRateLimitCost int
+	// SignatureVerifier enables HMAC request-signature checks on API routes when set
+	SignatureVerifier *middleware.SignatureVerifier
+	// IdempotencyStore enables Idempotency-Key support on /analyze when set
+	IdempotencyStore *middleware.IdempotencyStore
+	// MetricsRegistry exposes GET /metrics in the Prometheus text format when set
+	MetricsRegistry *metrics.Registry
+	// Docs exposes GET /docs (API explorer) and its OpenAPI document when set
+	Docs *docs.Docs
+	// SLOTracker counts good and bad API requests per SLO when set
+	SLOTracker *slo.Tracker
+	// Routes are additional proxied endpoints from the route file, served through RouteForwarder
+	Routes         []routes.Route
+	RouteForwarder routes.Forwarder
+	// MiddlewareChains replaces the default middleware chain of API routes, keyed by path
+	MiddlewareChains map[string][]string
+	// Filters are custom middleware that MiddlewareChains can refer to by name
+	Filters map[string]filters.Filter
+	// Experiments assigns API key holders to A/B variants when set
+	Experiments *experiments.Assigner
+	// BotDetector slows or rejects likely scrapers on public declared routes when set
+	BotDetector *middleware.BotDetector
+	// AnomalyDetector watches per-key traffic for anomalies and enforces automatic suspensions when set
+	AnomalyDetector *anomaly.Detector
+	// CookieSessions enables CSRF protection for cookie-authenticated requests and GET /api/v1/auth/csrf
+	CookieSessions bool
+	// SessionHandler serves cookie session login, refresh and logout under /api/v1/auth when set
+	SessionHandler *middleware.SessionHandler
+	// AuthClient validates the access tokens (Bearer or session cookie) of routes whose chain has auth or optional-auth
+	AuthClient *middleware.AuthServiceClient
+	// ConcurrencyLimiter caps each API key's requests in flight when set
+	ConcurrencyLimiter *middleware.ConcurrencyLimiter
+	// IPRateLimiter limits requests without an API key per client IP on optional-key routes when set
+	IPRateLimiter *middleware.IPRateLimiter
+	// TrustedProxies are the load balancers whose X-Forwarded-For is believed when checking API key IP allowlists
+	TrustedProxies []netip.Prefix
+	// CacheWarmer collects the warm-up requests of cached declared routes when set
+	CacheWarmer *middleware.CacheWarmer
+	// Announcements serves GET /api/v1/announcements and the X-OPGL-Announcement header when set
+	Announcements *announcements.Board
+	// Tenants serves GET /api/v1/branding for tenant hosts when set
+	Tenants *tenants.Directory
+}
+
+// Default middleware chains of the API routes, outermost first
+// SLO events are recorded first so rate-limit and auth-service failures count too, and anomaly
+// detection, IP allowlists, scopes, concurrency limits, signatures and experiment assignment come after the API key has been accepted.
+var (
+	lookupChain = []string{
+		routes.MiddlewareSLO, routes.MiddlewareRateLimit, routes.MiddlewareIPAllowlist, routes.MiddlewareScope, routes.MiddlewareAnomaly, routes.MiddlewareConcurrency, routes.MiddlewareSignature, routes.MiddlewareExperiments, routes.MiddlewareTimeout,
+	}
+	analyzeChain = []string{
+		routes.MiddlewareSLO, routes.MiddlewareRateLimit, routes.MiddlewareIPAllowlist, routes.MiddlewareScope, routes.MiddlewareAnomaly, routes.MiddlewareConcurrency, routes.MiddlewareSignature, routes.MiddlewareExperiments, routes.MiddlewareIdempotency, routes.MiddlewareTimeout,
+	}
+)
+
+// routeSettings are the per-route inputs of a middleware chain
+type routeSettings struct {
+	path           string
+	timeout        time.Duration
+	rateLimitClass string
+	rateLimitCost  int
+	scope          string
+	cacheTTL       time.Duration
+	warm           []middleware.WarmRequest
+	defaultChain   []string
 }
 
 // SetupRouter configures all routes for the gateway
 func SetupRouter(config *RouterConfig) *mux.Router {
 	router := mux.NewRouter()
 
+	// Cookie sessions are sent by the browser automatically, so state-changing requests need a CSRF token
+	if config.CookieSessions {
+		router.Use(middleware.CSRFMiddleware)
+		router.HandleFunc("/api/v1/auth/csrf", middleware.CSRFTokenHandler).Methods("GET")
+	}
+	if config.SessionHandler != nil {
+		router.HandleFunc("/api/v1/auth/login", config.SessionHandler.Login).Methods("POST")
+		router.HandleFunc("/api/v1/auth/refresh", config.SessionHandler.Refresh).Methods("POST")
+		router.HandleFunc("/api/v1/auth/logout", config.SessionHandler.Logout).Methods("POST")
+	}
+
+	// Announcements - GET so clients and CDNs can cache them, no rate limiting
+	if config.Announcements != nil {
+		if config.Announcements.HasHeaders() {
+			router.Use(middleware.AnnouncementMiddleware(config.Announcements))
+		}
+		router.Handle("/api/v1/announcements", config.Announcements.Handler()).Methods("GET")
+	}
+
+	// Branding - GET and cacheable per host, no rate limiting
+	if config.Tenants != nil {
+		router.Handle("/api/v1/branding", config.Tenants.Handler()).Methods("GET")
+	}
+
+	// Mark responses while an upstream is down so clients can back off early
+	if config.Handler.healthChecker != nil {
+		router.Use(middleware.DegradedMiddleware(config.Handler.healthChecker))
+	}
+
 	// Health check endpoint - no rate limiting
 	router.HandleFunc("/health", config.Handler.HealthCheck).Methods("POST")
 
+	// Readiness probe - GET for load balancers and orchestrators, no rate limiting
+	router.HandleFunc("/readyz", config.Handler.ReadinessCheck).Methods("GET")
+
+	// Metrics endpoint - GET so Prometheus can scrape it, no rate limiting
+	if config.MetricsRegistry != nil {
+		router.Handle("/metrics", config.MetricsRegistry.Handler()).Methods("GET")
+	}
+
+	// API explorer - GET for browsers, no rate limiting (requests made from it are limited as usual)
+	if config.Docs != nil {
+		router.Handle("/docs", config.Docs.UIHandler()).Methods("GET")
+		router.Handle(docs.SpecPath, config.Docs.SpecHandler()).Methods("GET")
+	}
+
+	// Declared routes come before the /api/v1 subrouter so they aren't shadowed by its prefix
+	if config.RouteForwarder != nil {
+		for _, route := range config.Routes {
+			// Public routes are the free tier, so their anonymous callers also get bot mitigation
+			access := []string{routes.MiddlewareRateLimitOptional, routes.MiddlewareBot}
+			if route.AuthRequired {
+				access = []string{routes.MiddlewareRateLimit}
+			}
+			defaultChain := append(append([]string{routes.MiddlewareSLO}, access...),
+				routes.MiddlewareIPAllowlist, routes.MiddlewareScope, routes.MiddlewareAnomaly, routes.MiddlewareConcurrency, routes.MiddlewareSignature, routes.MiddlewareExperiments, routes.MiddlewareTimeout, routes.MiddlewareCache)
+
+			handler := config.chain(routeSettings{
+				path:           route.Path,
+				timeout:        config.LookupTimeout,
+				rateLimitClass: route.RateLimitClass,
+				rateLimitCost:  route.RateLimitCost,
+				scope:          route.Scope,
+				cacheTTL:       route.CacheTTL,
+				warm:           warmRequests(route),
+				defaultChain:   defaultChain,
+			}, config.RouteForwarder.Forward(route))
+			router.Handle(route.Path, handler).Methods(route.Method)
+		}
+	}
+
 	// API routes subrouter
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
 
-	// Apply rate limiting middleware if configured
-	if config.RateLimitClient != nil {
-		apiRouter.Use(middleware.RateLimitMiddleware(config.RateLimitClient))
+	// Proxied data endpoints: lookups get the short deadline
+	apiRouter.Handle("/summoner", config.chain(routeSettings{
+		path:          "/api/v1/summoner",
+		timeout:       config.LookupTimeout,
+		rateLimitCost: config.LookupRateLimitCost,
+		scope:         middleware.ScopeSummoner,
+		defaultChain:  lookupChain,
+	}, http.HandlerFunc(config.Handler.GetSummoner))).Methods("POST")
+	apiRouter.Handle("/matches", config.chain(routeSettings{
+		path:          "/api/v1/matches",
+		timeout:       config.LookupTimeout,
+		rateLimitCost: config.LookupRateLimitCost,
+		scope:         middleware.ScopeMatches,
+		defaultChain:  lookupChain,
+	}, http.HandlerFunc(config.Handler.GetMatches))).Methods("POST")
+
+	// Orchestrated analysis endpoint (long deadline, optionally idempotent)
+	apiRouter.Handle("/analyze", config.chain(routeSettings{
+		path:          "/api/v1/analyze",
+		timeout:       config.AnalyzeTimeout,
+		rateLimitCost: config.AnalyzeRateLimitCost,
+		scope:         middleware.ScopeAnalyze,
+		defaultChain:  analyzeChain,
+	}, http.HandlerFunc(config.Handler.AnalyzePlayer))).Methods("POST")
+
+	return router
+}
+
+// warmRequests converts a declared route's warm-up requests to gateway requests
+func warmRequests(route routes.Route) []middleware.WarmRequest {
+	requests := make([]middleware.WarmRequest, len(route.Warm))
+	for i, warmRequest := range route.Warm {
+		uri := route.Path
+		if warmRequest.Query != "" {
+			uri += "?" + warmRequest.Query
+		}
+		requests[i] = middleware.WarmRequest{Method: route.Method, URI: uri, Body: warmRequest.Body}
 	}
+	return requests
+}
 
-	// Proxied data endpoints (rate limited)
-	apiRouter.HandleFunc("/summoner", config.Handler.GetSummoner).Methods("POST")
-	apiRouter.HandleFunc("/matches", config.Handler.GetMatches).Methods("POST")
+// chain wraps handler in the route's middleware chain
+// A configured chain for the path replaces the default one. Middleware whose
+// dependency isn't configured (e.g. no rate limit client) is skipped.
+func (config *RouterConfig) chain(settings routeSettings, handler http.Handler) http.Handler {
+	names := settings.defaultChain
+	if configuredChain, exists := config.MiddlewareChains[settings.path]; exists {
+		names = configuredChain
+	}
 
-	// Orchestrated analysis endpoint (rate limited)
-	apiRouter.HandleFunc("/analyze", config.Handler.AnalyzePlayer).Methods("POST")
+	// Wrap innermost first so the first name ends up outermost
+	for i := len(names) - 1; i >= 0; i-- {
+		if wrap := config.middleware(names[i], settings); wrap != nil {
+			handler = wrap(handler)
+		}
+	}
+	return handler
+}
 
-	return router
+// middleware returns the named middleware for a route, or nil when it isn't configured
+func (config *RouterConfig) middleware(name string, settings routeSettings) func(http.Handler) http.Handler {
+	switch name {
+	case routes.MiddlewareSLO:
+		if config.SLOTracker != nil {
+			return middleware.SLOMiddleware(config.SLOTracker)
+		}
+	case routes.MiddlewareRateLimit:
+		if config.RateLimitClient != nil {
+			return middleware.RateLimitClassMiddleware(config.RateLimitClient, config.EventBus, settings.rateLimitClass, settings.rateLimitCost)
+		}
+	case routes.MiddlewareRateLimitOptional:
+		if config.RateLimitClient != nil {
+			return middleware.OptionalRateLimitClassMiddleware(config.RateLimitClient, config.EventBus, settings.rateLimitClass, settings.rateLimitCost, config.IPRateLimiter)
+		}
+	case routes.MiddlewareAuth:
+		if config.AuthClient != nil {
+			return middleware.AuthMiddleware(config.AuthClient)
+		}
+	case routes.MiddlewareAuthOptional:
+		if config.AuthClient != nil {
+			return middleware.OptionalAuthMiddleware(config.AuthClient)
+		}
+	case routes.MiddlewareSignature:
+		if config.SignatureVerifier != nil {
+			return middleware.SignatureMiddleware(config.SignatureVerifier)
+		}
+	case routes.MiddlewareIdempotency:
+		if config.IdempotencyStore != nil {
+			return middleware.IdempotencyMiddleware(config.IdempotencyStore)
+		}
+	case routes.MiddlewareTimeout:
+		return middleware.TimeoutMiddleware(settings.timeout)
+	case routes.MiddlewareCache:
+		if settings.cacheTTL > 0 {
+			cache := middleware.NewResponseCache(settings.cacheTTL)
+			if config.CacheWarmer != nil && len(settings.warm) > 0 {
+				config.CacheWarmer.Add(cache, settings.warm)
+			}
+			return middleware.CacheMiddleware(cache)
+		}
+	case routes.MiddlewareCompress:
+		return middleware.CompressMiddleware
+	case routes.MiddlewareBot:
+		if config.BotDetector != nil {
+			return middleware.BotMitigationMiddleware(config.BotDetector, config.TrustedProxies)
+		}
+	case routes.MiddlewareAnomaly:
+		if config.AnomalyDetector != nil {
+			return middleware.AnomalyMiddleware(config.AnomalyDetector)
+		}
+	case routes.MiddlewareIPAllowlist:
+		return middleware.IPAllowlistMiddleware(config.TrustedProxies)
+	case routes.MiddlewareScope:
+		if settings.scope != "" {
+			return middleware.ScopeMiddleware(settings.scope)
+		}
+	case routes.MiddlewareConcurrency:
+		if config.ConcurrencyLimiter != nil {
+			return middleware.ConcurrencyMiddleware(config.ConcurrencyLimiter)
+		}
+	case routes.MiddlewareExperiments:
+		if config.Experiments != nil {
+			return middleware.ExperimentMiddleware(config.Experiments, config.EventBus, settings.path)
+		}
+	default:
+		if filter, exists := config.Filters[name]; exists {
+			return filter
+		}
+	}
+	return nil
 }
 
 // SetupRouterSimple configures routes with minimal dependencies (for testing)
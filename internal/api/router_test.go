@@ -2,17 +2,24 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+	"github.com/OPGLOL/opgl-gateway-service/internal/health"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/OPGLOL/opgl-gateway-service/internal/middleware"
 	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
 )
 
 // TestSetupRouter tests that all routes are registered correctly
 func TestSetupRouter(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	if router == nil {
@@ -23,7 +30,7 @@ func TestSetupRouter(t *testing.T) {
 // TestRouterHealthEndpoint tests that the health endpoint is registered
 func TestRouterHealthEndpoint(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	request, _ := http.NewRequest("POST", "/health", nil)
@@ -39,7 +46,7 @@ func TestRouterHealthEndpoint(t *testing.T) {
 // TestRouterHealthEndpointMethodNotAllowed tests that GET is not allowed for health
 func TestRouterHealthEndpointMethodNotAllowed(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	request, _ := http.NewRequest("GET", "/health", nil)
@@ -59,7 +66,7 @@ func TestRouterSummonerEndpoint(t *testing.T) {
 			return &models.Summoner{PUUID: "test"}, nil
 		},
 	}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	// Send invalid JSON body to trigger BadRequest (proves endpoint is registered)
@@ -77,7 +84,7 @@ func TestRouterSummonerEndpoint(t *testing.T) {
 // TestRouterMatchesEndpoint tests that the matches endpoint is registered
 func TestRouterMatchesEndpoint(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	// Send invalid JSON body to test endpoint is registered
@@ -95,7 +102,7 @@ func TestRouterMatchesEndpoint(t *testing.T) {
 // TestRouterAnalyzeEndpoint tests that the analyze endpoint is registered
 func TestRouterAnalyzeEndpoint(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	// Send invalid JSON body to test endpoint is registered
@@ -113,7 +120,7 @@ func TestRouterAnalyzeEndpoint(t *testing.T) {
 // TestRouterNonExistentEndpoint tests that non-existent endpoints return 404
 func TestRouterNonExistentEndpoint(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	request, _ := http.NewRequest("POST", "/api/v1/nonexistent", nil)
@@ -129,7 +136,7 @@ func TestRouterNonExistentEndpoint(t *testing.T) {
 // TestRouterAllEndpointsUsePOST verifies all endpoints use POST method
 func TestRouterAllEndpointsUsePOST(t *testing.T) {
 	mockProxy := &MockServiceProxy{}
-	handler := NewHandler(mockProxy)
+	handler := NewHandler(mockProxy, nil, nil, MatchCountLimit{})
 	router := SetupRouterSimple(handler, nil)
 
 	// Test health endpoint returns 405 for GET
@@ -144,3 +151,159 @@ func TestRouterAllEndpointsUsePOST(t *testing.T) {
 	// Note: Subrouter endpoints return 404 for wrong methods due to gorilla/mux behavior
 	// This is acceptable as the endpoints are not exposed for wrong methods
 }
+
+// stubForwarder answers every declared route with its upstream and path
+type stubForwarder struct{}
+
+// Forward returns a handler echoing the route target
+func (stubForwarder) Forward(route routes.Route) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(route.Upstream + route.UpstreamPath))
+	})
+}
+
+// TestRouterDeclaredRoutes tests that routes from the route file are registered with their method
+func TestRouterDeclaredRoutes(t *testing.T) {
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
+	router := SetupRouter(&RouterConfig{
+		Handler:        handler,
+		Routes:         []routes.Route{{Path: "/api/v1/ranked", Method: "GET", Upstream: "data", UpstreamPath: "/api/v1/ranked"}},
+		RouteForwarder: stubForwarder{},
+	})
+
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("GET", "/api/v1/ranked", nil))
+	if responseRecorder.Code != http.StatusOK || responseRecorder.Body.String() != "data/api/v1/ranked" {
+		t.Errorf("Expected declared route to be forwarded, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("POST", "/api/v1/summoner", bytes.NewBufferString("invalid")))
+	if responseRecorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected built-in routes to keep working, got %d", responseRecorder.Code)
+	}
+}
+
+// TestRouterMiddlewareChains tests that a configured chain replaces the default one for its path only
+func TestRouterMiddlewareChains(t *testing.T) {
+	mockProxy := &MockServiceProxy{
+		GetMatchesByPUUIDFunc: func(region, puuid string, count int) ([]models.Match, error) {
+			return []models.Match{{MatchID: "NA1_1"}}, nil
+		},
+	}
+	router := SetupRouter(&RouterConfig{
+		Handler:          NewHandler(mockProxy, nil, nil, MatchCountLimit{}),
+		MiddlewareChains: map[string][]string{"/api/v1/matches": {routes.MiddlewareCompress}},
+	})
+
+	send := func(path string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		request.Header.Set("Accept-Encoding", "gzip")
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	matchesResponse := send("/api/v1/matches", `{"region":"na","puuid":"abc-123"}`)
+	if matchesResponse.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected /matches to be compressed, got status %d and encoding '%s'", matchesResponse.Code, matchesResponse.Header().Get("Content-Encoding"))
+	}
+
+	summonerResponse := send("/api/v1/summoner", "invalid")
+	if summonerResponse.Header().Get("Content-Encoding") != "" {
+		t.Error("Expected /summoner to keep its default chain without compression")
+	}
+}
+
+// userForwarder answers every declared route with the authenticated user's ID
+type userForwarder struct{}
+
+// Forward returns a handler echoing the user ID in the request context
+func (userForwarder) Forward(route routes.Route) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		userID, _ := identity.UserID(request.Context())
+		writer.Write([]byte(userID.String()))
+	})
+}
+
+// TestRouterAuthChain tests that a route with the auth middleware accepts the session cookie set at login
+func TestRouterAuthChain(t *testing.T) {
+	userID := "6f1c2a3e-8d4b-4c5f-9a7e-1b2c3d4e5f60"
+	authServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var validateRequest struct {
+			Token string `json:"token"`
+		}
+		json.NewDecoder(request.Body).Decode(&validateRequest)
+		json.NewEncoder(writer).Encode(map[string]interface{}{"valid": validateRequest.Token == "session-token", "userId": userID})
+	}))
+	defer authServer.Close()
+
+	router := SetupRouter(&RouterConfig{
+		Handler:          NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{}),
+		Routes:           []routes.Route{{Path: "/api/v1/me/favorites", Method: "GET", Upstream: "data", UpstreamPath: "/api/v1/me/favorites"}},
+		RouteForwarder:   userForwarder{},
+		MiddlewareChains: map[string][]string{"/api/v1/me/favorites": {routes.MiddlewareAuth}},
+		CookieSessions:   true,
+		AuthClient:       middleware.NewAuthServiceClient(authServer.URL, nil),
+	})
+
+	request := httptest.NewRequest("GET", "/api/v1/me/favorites", nil)
+	request.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session-token"})
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusOK || responseRecorder.Body.String() != userID {
+		t.Errorf("Expected the cookie-authenticated request to reach the handler as %s, got %d '%s'", userID, responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("GET", "/api/v1/me/favorites", nil))
+	if responseRecorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, responseRecorder.Code)
+	}
+}
+
+// TestRouterAnnouncements tests the announcements endpoint and header
+func TestRouterAnnouncements(t *testing.T) {
+	handler := NewHandler(&MockServiceProxy{}, nil, nil, MatchCountLimit{})
+	router := SetupRouter(&RouterConfig{
+		Handler: handler,
+		Announcements: announcements.NewBoard([]announcements.Announcement{
+			{ID: "maintenance", Message: "Maintenance tonight", Severity: announcements.SeverityWarning, Header: true},
+		}),
+	})
+
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("GET", "/api/v1/announcements", nil))
+	if responseRecorder.Code != http.StatusOK || !strings.Contains(responseRecorder.Body.String(), `"id":"maintenance"`) {
+		t.Errorf("Expected the announcement to be listed, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("POST", "/health", nil))
+	if headerValue := responseRecorder.Header().Get(announcements.Header); headerValue != `severity=warning; id=maintenance; message="Maintenance tonight"` {
+		t.Errorf("Expected the announcement header on other responses, got '%s'", headerValue)
+	}
+}
+
+// TestRouterDegradedHeader tests that responses are marked while an upstream is down
+func TestRouterDegradedHeader(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	handler := NewHandler(&MockServiceProxy{}, nil, health.NewChecker(health.Upstream{Name: "data", URL: failingServer.URL, Required: true}), MatchCountLimit{})
+	router := SetupRouter(&RouterConfig{Handler: handler})
+
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("GET", "/readyz", nil))
+	if responseRecorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, responseRecorder.Code)
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, httptest.NewRequest("POST", "/health", nil))
+	if responseRecorder.Header().Get(middleware.DegradedHeader) != "true" {
+		t.Errorf("Expected %s: true after a degraded check, got '%s'", middleware.DegradedHeader, responseRecorder.Header().Get(middleware.DegradedHeader))
+	}
+}
@@ -0,0 +1,609 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/egress"
+	"github.com/rs/zerolog"
+)
+
+// Profile names a deployment environment with its own defaults
+type Profile string
+
+const (
+	ProfileDev     Profile = "dev"
+	ProfileStaging Profile = "staging"
+	ProfileProd    Profile = "prod"
+)
+
+// Log output formats
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// profileDefaults holds the settings that differ between profiles
+type profileDefaults struct {
+	logFormat          string
+	logLevel           string
+	corsAllowedOrigins []string
+}
+
+// profiles bundles sensible defaults per environment
+// dev favors readability and permissive CORS; staging and prod emit JSON logs
+// and only allow explicitly configured browser origins.
+var profiles = map[Profile]profileDefaults{
+	ProfileDev: {
+		logFormat:          LogFormatConsole,
+		logLevel:           "debug",
+		corsAllowedOrigins: []string{"*"},
+	},
+	ProfileStaging: {
+		logFormat:          LogFormatJSON,
+		logLevel:           "debug",
+		corsAllowedOrigins: nil,
+	},
+	ProfileProd: {
+		logFormat:          LogFormatJSON,
+		logLevel:           "info",
+		corsAllowedOrigins: nil,
+	},
+}
+
+// defaultBotBlockedUserAgents are user-agent substrings of common scraping tools and HTTP libraries
+var defaultBotBlockedUserAgents = []string{
+	"scrapy", "python-requests", "python-urllib", "aiohttp", "go-http-client", "okhttp", "headlesschrome", "phantomjs",
+}
+
+// Config holds all gateway settings
+type Config struct {
+	Profile Profile
+
+	// Server
+	Port string
+
+	// Upstream services
+	DataServiceURL   string
+	CortexServiceURL string
+	AuthServiceURL   string
+
+	// Upstream endpoint paths (so upstreams can be fronted under other prefixes or versions)
+	DataSummonerPath  string
+	DataMatchesPath   string
+	CortexAnalyzePath string
+
+	// Minimum request body size gzip-compressed on upstream calls (0 disables compression)
+	UpstreamGzipMinBytes int
+
+	// Largest upstream response body read or forwarded (0 disables the limit)
+	UpstreamMaxResponseBytes int64
+
+	// How long upstream DNS lookups are cached before a background refresh (0 disables caching)
+	UpstreamDNSCacheTTL time.Duration
+
+	// Service-to-service credentials for data and cortex (mode none, bearer or jwt)
+	UpstreamAuthMode  string
+	UpstreamAuthToken string
+	UpstreamJWTSecret string
+	UpstreamJWTTTL    time.Duration
+
+	// mTLS identity presented to https upstreams, and an extra CA trusted for them (PEM files)
+	UpstreamTLSCert string
+	UpstreamTLSKey  string
+	UpstreamTLSCA   string
+
+	// Outbound connection allow and deny lists (IPs or CIDRs) for upstreams and webhooks
+	EgressAllow []string
+	EgressDeny  []string
+
+	// YAML file declaring additional proxied routes (empty disables declared routes)
+	RoutesFile string
+
+	// Shadow cortex receiving a percentage of analysis requests for comparison (empty disables mirroring)
+	MirrorCortexURL string
+	MirrorPercent   float64
+
+	// Logging
+	LogFormat string
+	LogLevel  zerolog.Level
+
+	// CORS origins allowed to call the gateway from a browser ("*" allows any)
+	CORSAllowedOrigins []string
+
+	// Match count limit for /matches
+	MatchCountMax   int
+	MatchCountClamp bool
+
+	// Request deadlines
+	LookupTimeout  time.Duration
+	AnalyzeTimeout time.Duration
+
+	// Requests counted against an API key's rate limit per lookup (/summoner, /matches) and per /analyze
+	LookupRateLimitCost  int
+	AnalyzeRateLimitCost int
+
+	// Idempotency-Key replay window
+	IdempotencyTTL time.Duration
+
+	// Request signing
+	SignatureRequiredKeyHashes []string
+	SignatureMaxSkew           time.Duration
+
+	// Service level objectives
+	SLOAvailabilityObjective float64
+	SLOLatencyObjective      float64
+	SLOLookupLatency         time.Duration
+	SLOAnalyzeLatency        time.Duration
+
+	// Per-IP limit for requests without an API key on public routes (a zero limit disables it)
+	IPRateLimit       int
+	IPRateLimitWindow time.Duration
+	// Load balancers whose X-Forwarded-For identifies the client IP
+	TrustedProxies []netip.Prefix
+
+	// Requests each API key may have in flight at once, unless the auth service sets its own (0 is unlimited)
+	KeyMaxConcurrency int
+
+	// What happens to API key requests while the auth service can't answer rate limit checks:
+	// a key's last answer is reused for RateLimitFallbackTTL, then "closed" rejects them and "stale" keeps
+	// reusing older answers; keys without an answer are always rejected
+	RateLimitFailurePolicy string
+	RateLimitFallbackTTL   time.Duration
+
+	// Upstreams that must be up for /readyz to report ready, and how often they are probed
+	ReadyRequiredUpstreams []string
+	HealthCheckInterval    time.Duration
+
+	// Bot mitigation for anonymous callers of public routes
+	BotBlockedUserAgents  []string
+	BotTarpit             time.Duration
+	BotChallengeVerifyURL string
+	BotChallengeSecret    string
+
+	// Serve the OpenAPI document and an interactive API explorer at /docs
+	DocsEnabled bool
+
+	// Cookie session mode: browser sessions in cookies, with double-submit CSRF protection
+	CookieSessions bool
+
+	// Per-key anomaly detection (a zero window disables it; a zero severity never suspends)
+	AnomalyWindow          time.Duration
+	AnomalySuspendSeverity int
+	AnomalySuspendDuration time.Duration
+
+	// Log export: every JSON log line is also batched to each configured sink
+	LogSinkLokiURL       string
+	LogSinkSyslogAddress string
+	LogSinkS3Bucket      string
+	LogSinkS3Region      string
+	LogSinkS3Prefix      string
+	LogSinkS3Endpoint    string
+	AWSAccessKeyID       string
+	AWSSecretAccessKey   string
+	AWSSessionToken      string
+	LogSinkBatchSize     int
+	LogSinkFlushInterval time.Duration
+
+	// Domain events
+	NATSURL              string
+	EventsSubjectPrefix  string
+	EventsWebhookURL     string
+	EventsWebhookSecrets []string
+}
+
+// Load reads configuration from the environment
+// APP_ENV selects the profile (dev by default); any explicitly set variable overrides the profile default.
+func Load() (*Config, error) {
+	profile := Profile(getString("APP_ENV", string(ProfileDev)))
+	defaults, known := profiles[profile]
+	if !known {
+		return nil, fmt.Errorf("unknown APP_ENV %q (expected dev, staging, or prod)", profile)
+	}
+
+	config := &Config{
+		Profile:                    profile,
+		Port:                       getString("PORT", "8080"),
+		DataServiceURL:             getString("OPGL_DATA_URL", "http://localhost:8081"),
+		CortexServiceURL:           getString("OPGL_CORTEX_URL", "http://localhost:8082"),
+		AuthServiceURL:             getString("OPGL_AUTH_URL", "http://localhost:8083"),
+		DataSummonerPath:           getString("OPGL_DATA_SUMMONER_PATH", "/api/v1/summoner"),
+		DataMatchesPath:            getString("OPGL_DATA_MATCHES_PATH", "/api/v1/matches"),
+		CortexAnalyzePath:          getString("OPGL_CORTEX_ANALYZE_PATH", "/api/v1/analyze"),
+		UpstreamAuthMode:           getString("OPGL_UPSTREAM_AUTH_MODE", "none"),
+		RateLimitFailurePolicy:     getString("OPGL_RATE_LIMIT_FAILURE_POLICY", "closed"),
+		UpstreamAuthToken:          os.Getenv("OPGL_UPSTREAM_AUTH_TOKEN"),
+		UpstreamJWTSecret:          os.Getenv("OPGL_UPSTREAM_JWT_SECRET"),
+		UpstreamTLSCert:            os.Getenv("OPGL_UPSTREAM_TLS_CERT"),
+		UpstreamTLSKey:             os.Getenv("OPGL_UPSTREAM_TLS_KEY"),
+		UpstreamTLSCA:              os.Getenv("OPGL_UPSTREAM_TLS_CA"),
+		EgressAllow:                getList("OPGL_EGRESS_ALLOW", nil),
+		EgressDeny:                 getList("OPGL_EGRESS_DENY", nil),
+		RoutesFile:                 os.Getenv("OPGL_ROUTES_FILE"),
+		MirrorCortexURL:            os.Getenv("OPGL_MIRROR_CORTEX_URL"),
+		LogFormat:                  getString("OPGL_LOG_FORMAT", defaults.logFormat),
+		CORSAllowedOrigins:         getList("OPGL_CORS_ALLOWED_ORIGINS", defaults.corsAllowedOrigins),
+		SignatureRequiredKeyHashes: getList("OPGL_SIGNATURE_REQUIRED_KEYS", nil),
+		BotBlockedUserAgents:       getList("OPGL_BOT_BLOCKED_USER_AGENTS", defaultBotBlockedUserAgents),
+		ReadyRequiredUpstreams:     getList("OPGL_READY_REQUIRED_UPSTREAMS", []string{"data", "cortex", "auth"}),
+		BotChallengeVerifyURL:      os.Getenv("OPGL_BOT_CHALLENGE_VERIFY_URL"),
+		BotChallengeSecret:         os.Getenv("OPGL_BOT_CHALLENGE_SECRET"),
+		NATSURL:                    os.Getenv("OPGL_NATS_URL"),
+		EventsSubjectPrefix:        getString("OPGL_EVENTS_SUBJECT_PREFIX", "opgl.gateway"),
+		EventsWebhookURL:           os.Getenv("OPGL_EVENTS_WEBHOOK_URL"),
+		LogSinkLokiURL:             os.Getenv("OPGL_LOG_SINK_LOKI_URL"),
+		LogSinkSyslogAddress:       os.Getenv("OPGL_LOG_SINK_SYSLOG_ADDRESS"),
+		LogSinkS3Bucket:            os.Getenv("OPGL_LOG_SINK_S3_BUCKET"),
+		LogSinkS3Region:            getString("OPGL_LOG_SINK_S3_REGION", "us-east-1"),
+		LogSinkS3Prefix:            os.Getenv("OPGL_LOG_SINK_S3_PREFIX"),
+		LogSinkS3Endpoint:          os.Getenv("OPGL_LOG_SINK_S3_ENDPOINT"),
+		AWSAccessKeyID:             os.Getenv("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey:         os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:            os.Getenv("AWS_SESSION_TOKEN"),
+		EventsWebhookSecrets:       getList("OPGL_EVENTS_WEBHOOK_SECRETS", nil),
+	}
+
+	upstreamPaths := map[string]string{
+		"OPGL_DATA_SUMMONER_PATH":  config.DataSummonerPath,
+		"OPGL_DATA_MATCHES_PATH":   config.DataMatchesPath,
+		"OPGL_CORTEX_ANALYZE_PATH": config.CortexAnalyzePath,
+	}
+	for key, path := range upstreamPaths {
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid %s %q (must start with /)", key, path)
+		}
+	}
+
+	if config.LogFormat != LogFormatConsole && config.LogFormat != LogFormatJSON {
+		return nil, fmt.Errorf("invalid OPGL_LOG_FORMAT %q (expected console or json)", config.LogFormat)
+	}
+
+	switch config.UpstreamAuthMode {
+	case "none":
+	case "bearer":
+		if config.UpstreamAuthToken == "" {
+			return nil, fmt.Errorf("OPGL_UPSTREAM_AUTH_TOKEN is required when OPGL_UPSTREAM_AUTH_MODE is bearer")
+		}
+	case "jwt":
+		if config.UpstreamJWTSecret == "" {
+			return nil, fmt.Errorf("OPGL_UPSTREAM_JWT_SECRET is required when OPGL_UPSTREAM_AUTH_MODE is jwt")
+		}
+	default:
+		return nil, fmt.Errorf("invalid OPGL_UPSTREAM_AUTH_MODE %q (expected none, bearer or jwt)", config.UpstreamAuthMode)
+	}
+
+	if config.RateLimitFailurePolicy != "closed" && config.RateLimitFailurePolicy != "stale" {
+		return nil, fmt.Errorf("invalid OPGL_RATE_LIMIT_FAILURE_POLICY %q (expected closed or stale)", config.RateLimitFailurePolicy)
+	}
+
+	if config.LogSinkLokiURL != "" && !strings.HasPrefix(config.LogSinkLokiURL, "http://") && !strings.HasPrefix(config.LogSinkLokiURL, "https://") {
+		return nil, fmt.Errorf("invalid OPGL_LOG_SINK_LOKI_URL %q (expected an http or https URL)", config.LogSinkLokiURL)
+	}
+	if config.LogSinkSyslogAddress != "" && !strings.HasPrefix(config.LogSinkSyslogAddress, "udp://") && !strings.HasPrefix(config.LogSinkSyslogAddress, "tcp://") {
+		return nil, fmt.Errorf("invalid OPGL_LOG_SINK_SYSLOG_ADDRESS %q (expected udp://host:port or tcp://host:port)", config.LogSinkSyslogAddress)
+	}
+	if config.LogSinkS3Bucket != "" && (config.AWSAccessKeyID == "" || config.AWSSecretAccessKey == "") {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when OPGL_LOG_SINK_S3_BUCKET is set")
+	}
+
+	if (config.UpstreamTLSCert == "") != (config.UpstreamTLSKey == "") {
+		return nil, fmt.Errorf("OPGL_UPSTREAM_TLS_CERT and OPGL_UPSTREAM_TLS_KEY must be set together")
+	}
+
+	if _, err := egress.NewGuard(config.EgressAllow, nil); err != nil {
+		return nil, fmt.Errorf("invalid OPGL_EGRESS_ALLOW: %w", err)
+	}
+	if _, err := egress.NewGuard(nil, config.EgressDeny); err != nil {
+		return nil, fmt.Errorf("invalid OPGL_EGRESS_DENY: %w", err)
+	}
+
+	upstreamGzipMinBytes, err := getInt("OPGL_UPSTREAM_GZIP_MIN_BYTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	config.UpstreamGzipMinBytes = upstreamGzipMinBytes
+
+	upstreamMaxResponseBytes, err := getInt("OPGL_UPSTREAM_MAX_RESPONSE_BYTES", 10<<20)
+	if err != nil {
+		return nil, err
+	}
+	if upstreamMaxResponseBytes < 0 {
+		return nil, fmt.Errorf("invalid OPGL_UPSTREAM_MAX_RESPONSE_BYTES %d (expected 0 or more)", upstreamMaxResponseBytes)
+	}
+	config.UpstreamMaxResponseBytes = int64(upstreamMaxResponseBytes)
+
+	mirrorPercent, err := getPercent("OPGL_MIRROR_PERCENT", 0)
+	if err != nil {
+		return nil, err
+	}
+	config.MirrorPercent = mirrorPercent
+
+	if (config.BotChallengeVerifyURL == "") != (config.BotChallengeSecret == "") {
+		return nil, fmt.Errorf("OPGL_BOT_CHALLENGE_VERIFY_URL and OPGL_BOT_CHALLENGE_SECRET must be set together")
+	}
+
+	cookieSessions, err := getBool("OPGL_COOKIE_SESSIONS", false)
+	if err != nil {
+		return nil, err
+	}
+	config.CookieSessions = cookieSessions
+
+	docsEnabled, err := getBool("OPGL_DOCS_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	config.DocsEnabled = docsEnabled
+
+	ipRateLimit, err := getInt("OPGL_IP_RATE_LIMIT", 60)
+	if err != nil {
+		return nil, err
+	}
+	if ipRateLimit < 0 {
+		return nil, fmt.Errorf("invalid OPGL_IP_RATE_LIMIT %d (expected 0 or more)", ipRateLimit)
+	}
+	config.IPRateLimit = ipRateLimit
+
+	logSinkBatchSize, err := getInt("OPGL_LOG_SINK_BATCH_SIZE", 500)
+	if err != nil {
+		return nil, err
+	}
+	if logSinkBatchSize < 1 {
+		return nil, fmt.Errorf("invalid OPGL_LOG_SINK_BATCH_SIZE %d (expected 1 or more)", logSinkBatchSize)
+	}
+	config.LogSinkBatchSize = logSinkBatchSize
+
+	keyMaxConcurrency, err := getInt("OPGL_KEY_MAX_CONCURRENCY", 10)
+	if err != nil {
+		return nil, err
+	}
+	if keyMaxConcurrency < 0 {
+		return nil, fmt.Errorf("invalid OPGL_KEY_MAX_CONCURRENCY %d (expected 0 or more)", keyMaxConcurrency)
+	}
+	config.KeyMaxConcurrency = keyMaxConcurrency
+
+	trustedProxies, err := getPrefixes("OPGL_TRUSTED_PROXIES")
+	if err != nil {
+		return nil, err
+	}
+	config.TrustedProxies = trustedProxies
+
+	for _, upstream := range config.ReadyRequiredUpstreams {
+		if upstream != "data" && upstream != "cortex" && upstream != "auth" {
+			return nil, fmt.Errorf("invalid OPGL_READY_REQUIRED_UPSTREAMS entry %q (expected data, cortex, or auth)", upstream)
+		}
+	}
+
+	anomalySuspendSeverity, err := getInt("OPGL_ANOMALY_SUSPEND_SEVERITY", 0)
+	if err != nil {
+		return nil, err
+	}
+	if anomalySuspendSeverity < 0 || anomalySuspendSeverity > 3 {
+		return nil, fmt.Errorf("invalid OPGL_ANOMALY_SUSPEND_SEVERITY %d (expected 0-3)", anomalySuspendSeverity)
+	}
+	config.AnomalySuspendSeverity = anomalySuspendSeverity
+
+	matchCountMax, err := getInt("OPGL_MATCH_COUNT_MAX", 100)
+	if err != nil {
+		return nil, err
+	}
+	if matchCountMax < 1 || matchCountMax > 100 {
+		return nil, fmt.Errorf("invalid OPGL_MATCH_COUNT_MAX %d (expected 1-100)", matchCountMax)
+	}
+	config.MatchCountMax = matchCountMax
+
+	rateLimitCosts := []struct {
+		key    string
+		target *int
+	}{
+		{"OPGL_RATE_LIMIT_COST_LOOKUP", &config.LookupRateLimitCost},
+		{"OPGL_RATE_LIMIT_COST_ANALYZE", &config.AnalyzeRateLimitCost},
+	}
+	for _, rateLimitCost := range rateLimitCosts {
+		cost, err := getInt(rateLimitCost.key, 1)
+		if err != nil {
+			return nil, err
+		}
+		if cost < 1 {
+			return nil, fmt.Errorf("invalid %s %d (expected 1 or more)", rateLimitCost.key, cost)
+		}
+		*rateLimitCost.target = cost
+	}
+
+	switch matchCountMode := getString("OPGL_MATCH_COUNT_MODE", "reject"); matchCountMode {
+	case "reject":
+		config.MatchCountClamp = false
+	case "clamp":
+		config.MatchCountClamp = true
+	default:
+		return nil, fmt.Errorf("invalid OPGL_MATCH_COUNT_MODE %q (expected reject or clamp)", matchCountMode)
+	}
+
+	logLevel, err := zerolog.ParseLevel(getString("OPGL_LOG_LEVEL", defaults.logLevel))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPGL_LOG_LEVEL: %w", err)
+	}
+	config.LogLevel = logLevel
+
+	durations := []struct {
+		key          string
+		defaultValue time.Duration
+		target       *time.Duration
+	}{
+		{"OPGL_LOOKUP_TIMEOUT", 10 * time.Second, &config.LookupTimeout},
+		{"OPGL_ANALYZE_TIMEOUT", 60 * time.Second, &config.AnalyzeTimeout},
+		{"OPGL_IDEMPOTENCY_TTL", 24 * time.Hour, &config.IdempotencyTTL},
+		{"OPGL_SIGNATURE_MAX_SKEW", 5 * time.Minute, &config.SignatureMaxSkew},
+		{"OPGL_SLO_LOOKUP_LATENCY", time.Second, &config.SLOLookupLatency},
+		{"OPGL_SLO_ANALYZE_LATENCY", 10 * time.Second, &config.SLOAnalyzeLatency},
+		{"OPGL_UPSTREAM_DNS_CACHE_TTL", 30 * time.Second, &config.UpstreamDNSCacheTTL},
+		{"OPGL_UPSTREAM_JWT_TTL", time.Minute, &config.UpstreamJWTTTL},
+		{"OPGL_IP_RATE_LIMIT_WINDOW", time.Minute, &config.IPRateLimitWindow},
+		{"OPGL_HEALTH_CHECK_INTERVAL", 10 * time.Second, &config.HealthCheckInterval},
+		{"OPGL_RATE_LIMIT_FALLBACK_TTL", 30 * time.Second, &config.RateLimitFallbackTTL},
+		{"OPGL_LOG_SINK_FLUSH_INTERVAL", 5 * time.Second, &config.LogSinkFlushInterval},
+		{"OPGL_BOT_TARPIT", 2 * time.Second, &config.BotTarpit},
+		{"OPGL_ANOMALY_WINDOW", time.Minute, &config.AnomalyWindow},
+		{"OPGL_ANOMALY_SUSPEND_DURATION", 15 * time.Minute, &config.AnomalySuspendDuration},
+	}
+	for _, duration := range durations {
+		value, err := getDuration(duration.key, duration.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*duration.target = value
+	}
+	if config.IPRateLimit > 0 && config.IPRateLimitWindow <= 0 {
+		return nil, fmt.Errorf("invalid OPGL_IP_RATE_LIMIT_WINDOW %s (expected a positive duration)", config.IPRateLimitWindow)
+	}
+
+	if config.LogSinkFlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid OPGL_LOG_SINK_FLUSH_INTERVAL %s (expected a positive duration)", config.LogSinkFlushInterval)
+	}
+	if config.HealthCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid OPGL_HEALTH_CHECK_INTERVAL %s (expected a positive duration)", config.HealthCheckInterval)
+	}
+	if config.RateLimitFallbackTTL < 0 {
+		return nil, fmt.Errorf("invalid OPGL_RATE_LIMIT_FALLBACK_TTL %s (expected 0 or a positive duration)", config.RateLimitFallbackTTL)
+	}
+
+	objectives := []struct {
+		key          string
+		defaultValue float64
+		target       *float64
+	}{
+		{"OPGL_SLO_AVAILABILITY_OBJECTIVE", 0.995, &config.SLOAvailabilityObjective},
+		{"OPGL_SLO_LATENCY_OBJECTIVE", 0.99, &config.SLOLatencyObjective},
+	}
+	for _, objective := range objectives {
+		value, err := getObjective(objective.key, objective.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*objective.target = value
+	}
+
+	return config, nil
+}
+
+// getString reads a string from the environment, falling back to defaultValue
+func getString(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// getList reads a comma-separated list from the environment, dropping empty entries
+func getList(key string, defaultValue []string) []string {
+	value, isSet := os.LookupEnv(key)
+	if !isSet {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getInt reads an integer from the environment, falling back to defaultValue
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return number, nil
+}
+
+// getPrefixes reads a comma-separated list of IP addresses or CIDR prefixes from the environment
+// A bare address is a single-host prefix.
+func getPrefixes(key string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range getList(key, nil) {
+		if !strings.Contains(entry, "/") {
+			address, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s entry %q (expected an IP or CIDR)", key, entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(address.Unmap(), address.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q (expected an IP or CIDR)", key, entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// getBool reads a boolean (e.g. "true" or "0") from the environment, falling back to defaultValue
+func getBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return enabled, nil
+}
+
+// getDuration reads a duration (e.g. "30s") from the environment, falling back to defaultValue
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+// getObjective reads an SLO objective ratio between 0 and 1 (exclusive) from the environment
+func getObjective(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	objective, err := strconv.ParseFloat(value, 64)
+	if err != nil || objective <= 0 || objective >= 1 {
+		return 0, fmt.Errorf("invalid %s %q (expected a ratio between 0 and 1, e.g. 0.995)", key, value)
+	}
+	return objective, nil
+}
+
+// getPercent reads a percentage between 0 and 100 (inclusive) from the environment
+func getPercent(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid %s %q (expected a percentage between 0 and 100, e.g. 5)", key, value)
+	}
+	return percent, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// TestLoad_DevDefaults tests that dev is the default profile
+func TestLoad_DevDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Profile != ProfileDev {
+		t.Errorf("Expected profile '%s', got '%s'", ProfileDev, config.Profile)
+	}
+
+	if config.LogFormat != LogFormatConsole {
+		t.Errorf("Expected log format '%s', got '%s'", LogFormatConsole, config.LogFormat)
+	}
+
+	if config.LogLevel != zerolog.DebugLevel {
+		t.Errorf("Expected log level debug, got %s", config.LogLevel)
+	}
+
+	if len(config.CORSAllowedOrigins) != 1 || config.CORSAllowedOrigins[0] != "*" {
+		t.Errorf("Expected CORS origins [*], got %v", config.CORSAllowedOrigins)
+	}
+
+	if config.LookupTimeout != 10*time.Second {
+		t.Errorf("Expected lookup timeout 10s, got %s", config.LookupTimeout)
+	}
+}
+
+// TestLoad_ProdDefaults tests that prod logs JSON at info level with no CORS origins
+func TestLoad_ProdDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.LogFormat != LogFormatJSON {
+		t.Errorf("Expected log format '%s', got '%s'", LogFormatJSON, config.LogFormat)
+	}
+
+	if config.LogLevel != zerolog.InfoLevel {
+		t.Errorf("Expected log level info, got %s", config.LogLevel)
+	}
+
+	if len(config.CORSAllowedOrigins) != 0 {
+		t.Errorf("Expected no CORS origins, got %v", config.CORSAllowedOrigins)
+	}
+}
+
+// TestLoad_Overrides tests that explicit variables override profile defaults
+func TestLoad_Overrides(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("OPGL_LOG_LEVEL", "warn")
+	t.Setenv("OPGL_CORS_ALLOWED_ORIGINS", "https://opgl.gg, https://www.opgl.gg")
+	t.Setenv("OPGL_ANALYZE_TIMEOUT", "90s")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.LogLevel != zerolog.WarnLevel {
+		t.Errorf("Expected log level warn, got %s", config.LogLevel)
+	}
+
+	if len(config.CORSAllowedOrigins) != 2 || config.CORSAllowedOrigins[1] != "https://www.opgl.gg" {
+		t.Errorf("Expected two CORS origins, got %v", config.CORSAllowedOrigins)
+	}
+
+	if config.AnalyzeTimeout != 90*time.Second {
+		t.Errorf("Expected analyze timeout 90s, got %s", config.AnalyzeTimeout)
+	}
+}
+
+// TestLoad_EmptyListOverridesDefault tests that a set-but-empty list clears the profile default
+func TestLoad_EmptyListOverridesDefault(t *testing.T) {
+	t.Setenv("APP_ENV", "dev")
+	t.Setenv("OPGL_CORS_ALLOWED_ORIGINS", "")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(config.CORSAllowedOrigins) != 0 {
+		t.Errorf("Expected no CORS origins, got %v", config.CORSAllowedOrigins)
+	}
+}
+
+// TestLoad_InvalidValues tests that invalid settings are rejected
+func TestLoad_InvalidValues(t *testing.T) {
+	testCases := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"unknown profile", "APP_ENV", "qa"},
+		{"unknown log format", "OPGL_LOG_FORMAT", "xml"},
+		{"unknown log level", "OPGL_LOG_LEVEL", "loud"},
+		{"invalid duration", "OPGL_LOOKUP_TIMEOUT", "ten seconds"},
+		{"relative upstream path", "OPGL_DATA_MATCHES_PATH", "api/v2/matches"},
+		{"match count above Riot maximum", "OPGL_MATCH_COUNT_MAX", "500"},
+		{"zero lookup rate limit cost", "OPGL_RATE_LIMIT_COST_LOOKUP", "0"},
+		{"non-numeric analyze rate limit cost", "OPGL_RATE_LIMIT_COST_ANALYZE", "ten"},
+		{"unknown match count mode", "OPGL_MATCH_COUNT_MODE", "truncate"},
+		{"objective out of range", "OPGL_SLO_AVAILABILITY_OBJECTIVE", "99.5"},
+		{"mirror percent out of range", "OPGL_MIRROR_PERCENT", "150"},
+		{"anomaly severity out of range", "OPGL_ANOMALY_SUSPEND_SEVERITY", "4"},
+		{"negative response size limit", "OPGL_UPSTREAM_MAX_RESPONSE_BYTES", "-1"},
+		{"invalid egress prefix", "OPGL_EGRESS_DENY", "10.0.0.0/33"},
+		{"negative IP rate limit", "OPGL_IP_RATE_LIMIT", "-5"},
+		{"zero IP rate limit window", "OPGL_IP_RATE_LIMIT_WINDOW", "0s"},
+		{"log sink loki url without scheme", "OPGL_LOG_SINK_LOKI_URL", "loki:3100"},
+		{"log sink syslog address without scheme", "OPGL_LOG_SINK_SYSLOG_ADDRESS", "syslog:514"},
+		{"log sink s3 bucket without credentials", "OPGL_LOG_SINK_S3_BUCKET", "opgl-audit"},
+		{"zero log sink batch size", "OPGL_LOG_SINK_BATCH_SIZE", "0"},
+		{"zero log sink flush interval", "OPGL_LOG_SINK_FLUSH_INTERVAL", "0s"},
+		{"negative key concurrency", "OPGL_KEY_MAX_CONCURRENCY", "-1"},
+		{"unknown rate limit failure policy", "OPGL_RATE_LIMIT_FAILURE_POLICY", "retry"},
+		{"removed open failure policy", "OPGL_RATE_LIMIT_FAILURE_POLICY", "open"},
+		{"negative rate limit fallback TTL", "OPGL_RATE_LIMIT_FALLBACK_TTL", "-1s"},
+		{"unknown ready upstream", "OPGL_READY_REQUIRED_UPSTREAMS", "data,riot"},
+		{"zero health check interval", "OPGL_HEALTH_CHECK_INTERVAL", "0s"},
+		{"invalid trusted proxy", "OPGL_TRUSTED_PROXIES", "load-balancer"},
+		{"unknown upstream auth mode", "OPGL_UPSTREAM_AUTH_MODE", "basic"},
+		{"bearer mode without token", "OPGL_UPSTREAM_AUTH_MODE", "bearer"},
+		{"client certificate without key", "OPGL_UPSTREAM_TLS_CERT", "/etc/opgl/gateway.pem"},
+		{"invalid docs flag", "OPGL_DOCS_ENABLED", "maybe"},
+		{"invalid cookie sessions flag", "OPGL_COOKIE_SESSIONS", "sometimes"},
+		{"challenge URL without secret", "OPGL_BOT_CHALLENGE_VERIFY_URL", "https://challenges.cloudflare.com/turnstile/v0/siteverify"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv(testCase.key, testCase.value)
+
+			if _, err := Load(); err == nil {
+				t.Errorf("Expected error for %s=%q", testCase.key, testCase.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// SpecPath is where the OpenAPI document is served; the explorer page loads it from there
+const SpecPath = "/docs/openapi.json"
+
+// swaggerUIVersion pins the Swagger UI release loaded by the explorer page
+const swaggerUIVersion = "5.17.14"
+
+// explorerPage loads Swagger UI from a CDN so the gateway binary doesn't carry its assets
+// persistAuthorization keeps an entered API key across page reloads.
+const explorerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>OPGL Gateway API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "` + SpecPath + `", dom_id: "#swagger-ui", persistAuthorization: true});
+  </script>
+</body>
+</html>
+`
+
+// explorerPolicy only lets the page load Swagger UI from the CDN and call the gateway itself
+const explorerPolicy = "default-src 'none'; script-src https://cdn.jsdelivr.net 'unsafe-inline'; " +
+	"style-src https://cdn.jsdelivr.net 'unsafe-inline'; img-src 'self' data: https://cdn.jsdelivr.net; connect-src 'self'"
+
+// Docs serves the gateway's OpenAPI document and an interactive explorer for it
+type Docs struct {
+	spec []byte
+}
+
+// New generates the OpenAPI document for the built-in endpoints and the declared routes
+func New(version string, declaredRoutes []routes.Route) (*Docs, error) {
+	spec, err := json.MarshalIndent(buildSpec(version, declaredRoutes), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Docs{spec: spec}, nil
+}
+
+// SpecHandler serves the OpenAPI document
+func (docs *Docs) SpecHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write(docs.spec)
+	})
+}
+
+// UIHandler serves the Swagger UI explorer page
+func (docs *Docs) UIHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Header().Set("Content-Security-Policy", explorerPolicy)
+		writer.Write([]byte(explorerPage))
+	})
+}
+
+// buildSpec assembles the OpenAPI 3.0 document
+// No servers are listed, so the explorer sends requests to the gateway serving it (e.g. staging).
+func buildSpec(version string, declaredRoutes []routes.Route) map[string]interface{} {
+	paths := map[string]interface{}{
+		"/health": map[string]interface{}{
+			"post": operation("Gateway and upstream health", nil, "HealthResponse", false),
+		},
+		"/api/v1/summoner": map[string]interface{}{
+			"post": operation("Look up a summoner by Riot ID", schemaRef("RiotID"), "", true),
+		},
+		"/api/v1/matches": map[string]interface{}{
+			"post": operation("Fetch a summoner's recent matches", schemaRef("MatchRequest"), "", true),
+		},
+		"/api/v1/analyze": map[string]interface{}{
+			"post": analyzeOperation(),
+		},
+	}
+
+	// Declared routes are pass-through, so their bodies are documented as free-form JSON
+	sortedRoutes := append([]routes.Route(nil), declaredRoutes...)
+	sort.Slice(sortedRoutes, func(i, j int) bool { return sortedRoutes[i].Path < sortedRoutes[j].Path })
+	for _, route := range sortedRoutes {
+		method := strings.ToLower(route.Method)
+		if method == "" {
+			method = "post"
+		}
+		var requestBody map[string]interface{}
+		if method != "get" && method != "head" && method != "delete" {
+			requestBody = map[string]interface{}{"type": "object"}
+		}
+
+		declaredOperation := operation("Proxied to the "+upstreamName(route)+" upstream", requestBody, "", route.AuthRequired)
+		if !route.AuthRequired {
+			// An API key is optional on public routes; it lifts anonymous limits
+			declaredOperation["security"] = []interface{}{
+				map[string]interface{}{"apiKey": []string{}},
+				map[string]interface{}{},
+			}
+		}
+
+		pathItem, exists := paths[route.Path].(map[string]interface{})
+		if !exists {
+			pathItem = map[string]interface{}{}
+			paths[route.Path] = pathItem
+		}
+		pathItem[method] = declaredOperation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "OPGL Gateway API",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+			"schemas": schemas(),
+		},
+	}
+}
+
+// operation describes one endpoint
+// A nil requestBody means the operation takes no body; an empty responseSchema documents a free-form JSON object.
+func operation(summary string, requestBody map[string]interface{}, responseSchema string, authRequired bool) map[string]interface{} {
+	successSchema := map[string]interface{}{"type": "object"}
+	if responseSchema != "" {
+		successSchema = schemaRef(responseSchema)
+	}
+
+	documented := map[string]interface{}{
+		"summary": summary,
+		"responses": map[string]interface{}{
+			"200":     jsonResponse("Success", successSchema),
+			"default": jsonResponse("Error", schemaRef("ErrorResponse")),
+		},
+	}
+	if requestBody != nil {
+		documented["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": requestBody},
+			},
+		}
+	}
+	if authRequired {
+		documented["security"] = []interface{}{map[string]interface{}{"apiKey": []string{}}}
+	} else {
+		documented["security"] = []interface{}{}
+	}
+	return documented
+}
+
+// analyzeOperation describes /analyze, which also accepts an Idempotency-Key
+func analyzeOperation() map[string]interface{} {
+	analyze := operation("Analyze a summoner's recent matches", schemaRef("RiotID"), "", true)
+	analyze["parameters"] = []interface{}{
+		map[string]interface{}{
+			"name":        "Idempotency-Key",
+			"in":          "header",
+			"required":    false,
+			"description": "Replays the stored response when the same key and body are sent again",
+			"schema":      map[string]interface{}{"type": "string"},
+		},
+	}
+	return analyze
+}
+
+// jsonResponse describes a JSON response with the given schema
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// schemaRef refers to a schema in components
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// upstreamName names a declared route's upstream for its summary
+func upstreamName(route routes.Route) string {
+	if route.Upstream == routes.UpstreamData || route.Upstream == routes.UpstreamCortex {
+		return route.Upstream
+	}
+	return "external"
+}
+
+// schemas are the request and response bodies of the built-in endpoints
+func schemas() map[string]interface{} {
+	riotIDProperties := map[string]interface{}{
+		"region":   map[string]interface{}{"type": "string", "example": "na"},
+		"gameName": map[string]interface{}{"type": "string", "minLength": 3, "maxLength": 16, "example": "Newyenn"},
+		"tagLine":  map[string]interface{}{"type": "string", "minLength": 3, "maxLength": 5, "example": "GGEZ"},
+	}
+	matchProperties := map[string]interface{}{
+		"count": map[string]interface{}{"type": "integer", "minimum": 1, "default": 20},
+	}
+	for name, property := range riotIDProperties {
+		matchProperties[name] = property
+	}
+
+	return map[string]interface{}{
+		"RiotID": map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"region", "gameName", "tagLine"},
+			"properties":           riotIDProperties,
+			"additionalProperties": false,
+		},
+		"MatchRequest": map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"region", "gameName", "tagLine"},
+			"properties":           matchProperties,
+			"additionalProperties": false,
+		},
+		"HealthResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"status": map[string]interface{}{"type": "string", "example": "healthy"},
+			},
+		},
+		"ErrorResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"error": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code":      map[string]interface{}{"type": "string", "example": "PLAYER_NOT_FOUND"},
+						"message":   map[string]interface{}{"type": "string"},
+						"requestId": map[string]interface{}{"type": "string"},
+						"details":   map[string]interface{}{"type": "object"},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// TestSpecPath_Reserved tests that a route file can't shadow the OpenAPI document
+func TestSpecPath_Reserved(t *testing.T) {
+	if _, err := routes.Parse([]byte("routes:\n  - path: " + SpecPath + "\n    method: GET\n    upstream: data\n")); err == nil {
+		t.Errorf("Expected declaring GET %s to be rejected", SpecPath)
+	}
+}
+
+// TestSpecHandler tests that the document lists built-in and declared routes with their security
+func TestSpecHandler(t *testing.T) {
+	apiDocs, err := New("1.2.3", []routes.Route{
+		{Path: "/api/v1/champions", Method: "GET", Upstream: routes.UpstreamData},
+		{Path: "/api/v1/ranked", Method: "POST", Upstream: routes.UpstreamData, AuthRequired: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	apiDocs.SpecHandler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Security    []map[string][]string `json:"security"`
+			RequestBody interface{}           `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Expected a JSON document, got %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" || spec.Info.Version != "1.2.3" {
+		t.Errorf("Expected OpenAPI 3.0.3 for version 1.2.3, got %s %s", spec.OpenAPI, spec.Info.Version)
+	}
+
+	for _, path := range []string{"/health", "/api/v1/summoner", "/api/v1/matches", "/api/v1/analyze"} {
+		if _, exists := spec.Paths[path]["post"]; !exists {
+			t.Errorf("Expected POST %s to be documented", path)
+		}
+	}
+	if len(spec.Paths["/api/v1/analyze"]["post"].Security) != 1 {
+		t.Errorf("Expected /analyze to require an API key, got %v", spec.Paths["/api/v1/analyze"]["post"].Security)
+	}
+
+	champions, exists := spec.Paths["/api/v1/champions"]["get"]
+	if !exists || champions.RequestBody != nil || len(champions.Security) != 2 {
+		t.Errorf("Expected bodiless GET /api/v1/champions with an optional API key, got %+v", champions)
+	}
+	if ranked := spec.Paths["/api/v1/ranked"]["post"]; len(ranked.Security) != 1 || ranked.RequestBody == nil {
+		t.Errorf("Expected POST /api/v1/ranked to require an API key and take a body, got %+v", ranked)
+	}
+}
+
+// TestUIHandler tests that the explorer page loads the spec under a restrictive CSP
+func TestUIHandler(t *testing.T) {
+	apiDocs, err := New("dev", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	apiDocs.UIHandler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if !strings.Contains(responseRecorder.Body.String(), `url: "`+SpecPath+`"`) {
+		t.Errorf("Expected the page to load %s", SpecPath)
+	}
+	if policy := responseRecorder.Header().Get("Content-Security-Policy"); !strings.Contains(policy, "connect-src 'self'") {
+		t.Errorf("Expected a Content-Security-Policy limiting requests to the gateway, got '%s'", policy)
+	}
+}
@@ -0,0 +1,130 @@
+package egress
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes are never dialed: link-local ranges (including the 169.254.169.254 cloud
+// metadata endpoint), other cloud metadata addresses, unspecified and multicast addresses
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fd00:ec2::254/128"),
+	netip.MustParsePrefix("100.100.100.200/32"),
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// Guard decides which IP addresses outbound connections may reach
+// The check runs on the resolved address of every connection, so a hostname that
+// later resolves somewhere else (DNS rebinding) is still caught.
+type Guard struct {
+	// allowed restricts destinations to these prefixes when non-empty
+	allowed []netip.Prefix
+	// denied are rejected in addition to blockedPrefixes
+	denied []netip.Prefix
+}
+
+// NewGuard creates a Guard from allow and deny lists of IP addresses or CIDR prefixes
+// An empty allow list permits every address that isn't denied.
+func NewGuard(allow []string, deny []string) (*Guard, error) {
+	allowed, err := parsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+	denied, err := parsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Guard{allowed: allowed, denied: denied}, nil
+}
+
+// parsePrefixes parses IP addresses and CIDR prefixes; a bare address is a single-host prefix
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			address, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid egress address %q (expected an IP or CIDR)", entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(address, address.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress prefix %q (expected an IP or CIDR)", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Check returns an error when address may not be connected to
+func (guard *Guard) Check(address netip.Addr) error {
+	address = address.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(address) {
+			return fmt.Errorf("egress to %s is blocked (link-local, metadata, unspecified or multicast address)", address)
+		}
+	}
+	for _, prefix := range guard.denied {
+		if prefix.Contains(address) {
+			return fmt.Errorf("egress to %s is denied by the egress deny list", address)
+		}
+	}
+	if len(guard.allowed) == 0 {
+		return nil
+	}
+	for _, prefix := range guard.allowed {
+		if prefix.Contains(address) {
+			return nil
+		}
+	}
+	return fmt.Errorf("egress to %s is not in the egress allow list", address)
+}
+
+// Control checks the address of a connection about to be opened, for use as net.Dialer.Control
+func (guard *Guard) Control(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("egress to unresolved address %q", address)
+	}
+	return guard.Check(ip)
+}
+
+// Dialer returns a net.Dialer that refuses connections the guard doesn't allow
+// A nil guard returns a plain dialer.
+func (guard *Guard) Dialer() *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if guard != nil {
+		dialer.Control = guard.Control
+	}
+	return dialer
+}
+
+// Transport returns a clone of http.DefaultTransport whose connections are checked by the guard
+// HTTP_PROXY and HTTPS_PROXY are ignored: through a proxy the guard would only see the proxy's
+// address, never the destination's.
+func (guard *Guard) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = guard.Dialer().DialContext
+	return transport
+}
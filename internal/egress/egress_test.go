@@ -0,0 +1,88 @@
+package egress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// TestGuard_Check tests built-in blocks, the deny list and the allow list
+func TestGuard_Check(t *testing.T) {
+	guard, err := NewGuard([]string{"10.0.0.0/8", "127.0.0.1"}, []string{"10.9.0.0/16"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	testCases := []struct {
+		address string
+		allowed bool
+	}{
+		{"10.1.2.3", true},
+		{"127.0.0.1", true},
+		{"10.9.0.1", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"::ffff:169.254.169.254", false},
+		{"fd00:ec2::254", false},
+		{"0.0.0.0", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.address, func(t *testing.T) {
+			err := guard.Check(netip.MustParseAddr(testCase.address))
+			if (err == nil) != testCase.allowed {
+				t.Errorf("Expected allowed=%t for %s, got error %v", testCase.allowed, testCase.address, err)
+			}
+		})
+	}
+
+	// Without an allow list only blocked and denied addresses are refused
+	openGuard, _ := NewGuard(nil, nil)
+	if err := openGuard.Check(netip.MustParseAddr("192.168.1.10")); err != nil {
+		t.Errorf("Expected private addresses to be allowed without an allow list, got %v", err)
+	}
+	if err := openGuard.Check(netip.MustParseAddr("169.254.169.254")); err == nil {
+		t.Error("Expected the metadata address to be blocked without any lists")
+	}
+}
+
+// TestNewGuard_InvalidEntries tests that malformed entries are rejected
+func TestNewGuard_InvalidEntries(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "metadata.internal", ""} {
+		if _, err := NewGuard([]string{entry}, nil); err == nil {
+			t.Errorf("Expected error for %q", entry)
+		}
+	}
+}
+
+// TestGuard_Transport tests that the transport refuses connections the guard doesn't allow
+func TestGuard_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	allowingGuard, _ := NewGuard([]string{"127.0.0.0/8"}, nil)
+	response, err := (&http.Client{Transport: allowingGuard.Transport()}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected loopback to be allowed, got %v", err)
+	}
+	response.Body.Close()
+
+	denyingGuard, _ := NewGuard(nil, []string{"127.0.0.0/8"})
+	_, err = (&http.Client{Transport: denyingGuard.Transport()}).Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Expected the connection to be denied, got %v", err)
+	}
+}
+
+// TestGuard_TransportIgnoresProxy tests that the transport connects directly rather than through
+// a proxy the guard can't see past
+func TestGuard_TransportIgnoresProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy.invalid:3128")
+
+	guard, _ := NewGuard(nil, nil)
+	if guard.Transport().Proxy != nil {
+		t.Errorf("Expected no proxy on the guarded transport")
+	}
+}
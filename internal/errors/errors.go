@@ -2,7 +2,10 @@ package errors
 
 import (
 	"encoding/json"
+	goerrors "errors"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // ErrorCode represents a unique error code for client handling
@@ -19,6 +22,13 @@ const (
 	ErrCodeMissingAPIKey      ErrorCode = "MISSING_API_KEY"
 	ErrCodeInvalidAPIKey      ErrorCode = "INVALID_API_KEY"
 	ErrCodeRateLimitExceeded  ErrorCode = "RATE_LIMIT_EXCEEDED"
+	ErrCodeTooManyInFlight    ErrorCode = "TOO_MANY_CONCURRENT_REQUESTS"
+	ErrCodeInsufficientScope  ErrorCode = "INSUFFICIENT_SCOPE"
+	ErrCodeIPNotAllowed       ErrorCode = "IP_NOT_ALLOWED"
+	ErrCodeRequestCancelled   ErrorCode = "REQUEST_CANCELLED"
+	ErrCodeIdempotencyInUse   ErrorCode = "IDEMPOTENCY_KEY_IN_USE"
+	ErrCodeIdempotencyReused  ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeMatchCountExceeded ErrorCode = "MATCH_COUNT_EXCEEDED"
 
 	// Auth errors
 	ErrCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
@@ -26,10 +36,20 @@ const (
 	ErrCodeInvalidToken       ErrorCode = "INVALID_TOKEN"
 	ErrCodeEmailAlreadyExists ErrorCode = "EMAIL_ALREADY_EXISTS"
 	ErrCodeUserNotFound       ErrorCode = "USER_NOT_FOUND"
+	ErrCodeSignatureRequired  ErrorCode = "SIGNATURE_REQUIRED"
+	ErrCodeInvalidSignature   ErrorCode = "INVALID_SIGNATURE"
+	ErrCodeReplayedRequest    ErrorCode = "REPLAYED_REQUEST"
+	ErrCodeKeySuspended       ErrorCode = "KEY_SUSPENDED"
+	ErrCodeBotDetected        ErrorCode = "BOT_DETECTED"
+	ErrCodeChallengeRequired  ErrorCode = "CHALLENGE_REQUIRED"
+	ErrCodeCSRFTokenInvalid   ErrorCode = "CSRF_TOKEN_INVALID"
 
 	// Server errors (5xx)
 	ErrCodeDataServiceError   ErrorCode = "DATA_SERVICE_ERROR"
 	ErrCodeCortexServiceError ErrorCode = "CORTEX_SERVICE_ERROR"
+	ErrCodeUpstreamError      ErrorCode = "UPSTREAM_ERROR"
+	ErrCodeResponseTooLarge   ErrorCode = "UPSTREAM_RESPONSE_TOO_LARGE"
+	ErrCodeGatewayTimeout     ErrorCode = "GATEWAY_TIMEOUT"
 	ErrCodeInternalError      ErrorCode = "INTERNAL_ERROR"
 )
 
@@ -38,6 +58,8 @@ type APIError struct {
 	Code    ErrorCode `json:"code"`
 	Message string    `json:"message"`
 	Status  int       `json:"-"`
+	// Details holds machine-readable fields for clients, e.g. RateLimitDetails
+	Details interface{} `json:"details,omitempty"`
 }
 
 // Error implements the error interface
@@ -52,10 +74,32 @@ type ErrorResponse struct {
 
 // ErrorDetail contains the error information
 type ErrorDetail struct {
-	Code    ErrorCode `json:"code"`
-	Message string    `json:"message"`
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"requestId,omitempty"`
+	// Details are the error's machine-readable fields, when it has any
+	Details interface{} `json:"details,omitempty"`
+}
+
+// RateLimitDetails are the machine-readable fields of a RATE_LIMIT_EXCEEDED error
+type RateLimitDetails struct {
+	// Limit is the number of requests allowed per window
+	Limit int `json:"limit"`
+	// Window is the window length in seconds (omitted when the auth service doesn't report it)
+	Window int64 `json:"window,omitempty"`
+	// Remaining is the number of requests left in the current window
+	Remaining int `json:"remaining"`
+	// ResetAt is when the current rate-limit window ends
+	ResetAt time.Time `json:"resetAt"`
+	// Policy names the rate-limit policy that was exceeded
+	Policy string `json:"policy"`
 }
 
+// RequestIDHeader is the response header holding the request ID
+// The request-ID middleware sets it before any handler runs, so WriteError can
+// include the same ID in the error body for users to quote in support tickets.
+const RequestIDHeader = "X-Request-ID"
+
 // NewAPIError creates a new APIError
 func NewAPIError(code ErrorCode, message string, status int) *APIError {
 	return &APIError{
@@ -90,6 +134,18 @@ func CortexServiceError(message string) *APIError {
 	return NewAPIError(ErrCodeCortexServiceError, message, http.StatusBadGateway)
 }
 
+func UpstreamError(message string) *APIError {
+	return NewAPIError(ErrCodeUpstreamError, message, http.StatusBadGateway)
+}
+
+func UpstreamResponseTooLarge(limitBytes int64) *APIError {
+	return NewAPIError(ErrCodeResponseTooLarge, "Upstream response exceeded the "+strconv.FormatInt(limitBytes, 10)+" byte limit", http.StatusBadGateway)
+}
+
+func GatewayTimeout(message string) *APIError {
+	return NewAPIError(ErrCodeGatewayTimeout, message, http.StatusGatewayTimeout)
+}
+
 func InternalError(message string) *APIError {
 	return NewAPIError(ErrCodeInternalError, message, http.StatusInternalServerError)
 }
@@ -98,6 +154,82 @@ func ValidationFailed(message string) *APIError {
 	return NewAPIError(ErrCodeValidationFailed, message, http.StatusBadRequest)
 }
 
+func MatchCountExceeded(maxCount int) *APIError {
+	return NewAPIError(ErrCodeMatchCountExceeded, "count cannot exceed "+strconv.Itoa(maxCount), http.StatusBadRequest)
+}
+
+// StatusClientClosedRequest is the non-standard status used when the client disconnects before a response
+const StatusClientClosedRequest = 499
+
+func RequestCancelled() *APIError {
+	return NewAPIError(ErrCodeRequestCancelled, "Request was cancelled by the client", StatusClientClosedRequest)
+}
+
+func IdempotencyKeyInUse() *APIError {
+	return NewAPIError(ErrCodeIdempotencyInUse, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+}
+
+func IdempotencyKeyReused() *APIError {
+	return NewAPIError(ErrCodeIdempotencyReused, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+}
+
+func SignatureRequired() *APIError {
+	return NewAPIError(ErrCodeSignatureRequired, "This API key must sign requests. Include X-OPGL-Signature and X-OPGL-Timestamp headers.", http.StatusUnauthorized)
+}
+
+func InvalidSignature(message string) *APIError {
+	return NewAPIError(ErrCodeInvalidSignature, message, http.StatusUnauthorized)
+}
+
+func RateLimitExceeded(message string, details RateLimitDetails) *APIError {
+	apiError := NewAPIError(ErrCodeRateLimitExceeded, message, http.StatusTooManyRequests)
+	apiError.Details = details
+	return apiError
+}
+
+func TooManyInFlight(limit int) *APIError {
+	return NewAPIError(ErrCodeTooManyInFlight, "This API key already has "+strconv.Itoa(limit)+" requests in flight. Wait for one to finish before sending another.", http.StatusTooManyRequests)
+}
+
+func InsufficientScope(scope string) *APIError {
+	return NewAPIError(ErrCodeInsufficientScope, "This API key is not allowed to use this endpoint (scope \""+scope+"\").", http.StatusForbidden)
+}
+
+func IPNotAllowed() *APIError {
+	return NewAPIError(ErrCodeIPNotAllowed, "This API key can't be used from this IP address.", http.StatusForbidden)
+}
+
+func KeySuspended(suspendedUntil time.Time) *APIError {
+	return NewAPIError(ErrCodeKeySuspended, "This API key is temporarily suspended after unusual traffic until "+suspendedUntil.UTC().Format(time.RFC3339), http.StatusForbidden)
+}
+
+func BotDetected() *APIError {
+	return NewAPIError(ErrCodeBotDetected, "Automated clients must use an API key. Include X-API-Key header in your request.", http.StatusForbidden)
+}
+
+func ChallengeRequired(message string) *APIError {
+	return NewAPIError(ErrCodeChallengeRequired, message, http.StatusForbidden)
+}
+
+func CSRFTokenInvalid(message string) *APIError {
+	return NewAPIError(ErrCodeCSRFTokenInvalid, message, http.StatusForbidden)
+}
+
+func ReplayedRequest() *APIError {
+	return NewAPIError(ErrCodeReplayedRequest, "This request nonce has already been used", http.StatusUnauthorized)
+}
+
+// FromError classifies err as an APIError
+// Wrapped APIErrors (e.g. fmt.Errorf("...: %w", apiErr)) are unwrapped so their code and
+// status reach the client; any other error becomes a generic INTERNAL_ERROR.
+func FromError(err error) *APIError {
+	var apiError *APIError
+	if goerrors.As(err, &apiError) {
+		return apiError
+	}
+	return InternalError("An unexpected error occurred")
+}
+
 // WriteError writes a JSON error response to the http.ResponseWriter
 func WriteError(writer http.ResponseWriter, apiError *APIError) {
 	writer.Header().Set("Content-Type", "application/json")
@@ -105,8 +237,10 @@ func WriteError(writer http.ResponseWriter, apiError *APIError) {
 
 	errorResponse := ErrorResponse{
 		Error: ErrorDetail{
-			Code:    apiError.Code,
-			Message: apiError.Message,
+			Code:      apiError.Code,
+			Message:   apiError.Message,
+			RequestID: writer.Header().Get(RequestIDHeader),
+			Details:   apiError.Details,
 		},
 	}
 
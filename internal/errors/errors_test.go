@@ -2,6 +2,7 @@ package errors
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -118,6 +119,32 @@ func TestCortexServiceError(t *testing.T) {
 	}
 }
 
+// TestGatewayTimeout tests the GatewayTimeout constructor
+func TestGatewayTimeout(t *testing.T) {
+	apiError := GatewayTimeout("Request exceeded the 10s deadline")
+
+	if apiError.Code != ErrCodeGatewayTimeout {
+		t.Errorf("Expected code '%s', got '%s'", ErrCodeGatewayTimeout, apiError.Code)
+	}
+
+	if apiError.Status != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, apiError.Status)
+	}
+}
+
+// TestRequestCancelled tests the RequestCancelled constructor
+func TestRequestCancelled(t *testing.T) {
+	apiError := RequestCancelled()
+
+	if apiError.Code != ErrCodeRequestCancelled {
+		t.Errorf("Expected code '%s', got '%s'", ErrCodeRequestCancelled, apiError.Code)
+	}
+
+	if apiError.Status != StatusClientClosedRequest {
+		t.Errorf("Expected status %d, got %d", StatusClientClosedRequest, apiError.Status)
+	}
+}
+
 // TestInternalError tests the InternalError constructor
 func TestInternalError(t *testing.T) {
 	apiError := InternalError("Unexpected error")
@@ -190,3 +217,22 @@ func TestWriteError_DifferentStatusCodes(t *testing.T) {
 		})
 	}
 }
+
+// TestFromError tests classification of plain and wrapped errors
+func TestFromError(t *testing.T) {
+	wrappedError := fmt.Errorf("lookup failed: %w", PlayerNotFound("TestPlayer", "NA1"))
+
+	apiError := FromError(wrappedError)
+	if apiError.Code != ErrCodePlayerNotFound {
+		t.Errorf("Expected code '%s', got '%s'", ErrCodePlayerNotFound, apiError.Code)
+	}
+
+	if apiError.Status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, apiError.Status)
+	}
+
+	unknownError := FromError(fmt.Errorf("boom"))
+	if unknownError.Code != ErrCodeInternalError {
+		t.Errorf("Expected code '%s', got '%s'", ErrCodeInternalError, unknownError.Code)
+	}
+}
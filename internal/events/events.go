@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/jobs"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Domain event types published by the gateway
+const (
+	TypeRateLimitExceeded  = "ratelimit.exceeded"
+	TypeUsageThreshold     = "usage.threshold"
+	TypeAnalysisCompleted  = "analysis.completed"
+	TypeExperimentExposure = "experiment.exposure"
+	TypeMirrorDiff         = "mirror.diff"
+	TypeAnomalyDetected    = "anomaly.detected"
+	TypeAPIKeySuspended    = "apikey.suspended"
+	TypeGatewayDegraded    = "gateway.degraded"
+	TypeGatewayRecovered   = "gateway.recovered"
+)
+
+// eventSource identifies the gateway as the producer of an event
+const eventSource = "opgl-gateway"
+
+// Event represents a single domain event emitted by the gateway
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Source     string                 `json:"source"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Publisher delivers events to an external message broker
+type Publisher interface {
+	// Publish sends a single event to the broker
+	Publish(ctx context.Context, event *Event) error
+	// Close releases any broker connections held by the publisher
+	Close() error
+}
+
+// Bus queues events and publishes them asynchronously so request handling
+// never blocks on the message broker. Each publisher has its own job pool, so a slow
+// or retrying webhook never delays NATS, and failed publishes are retried by that pool.
+type Bus struct {
+	publishers     []Publisher
+	pools          []*jobs.Pool
+	publishTimeout time.Duration
+	drainTimeout   time.Duration
+	closeOnce      sync.Once
+}
+
+// NewBus creates a new Bus that delivers every event to each of the given publishers
+// Each publisher gets its own delivery job, so a failing webhook never causes duplicate NATS messages
+func NewBus(bufferSize int, publishers ...Publisher) *Bus {
+	poolConfig := jobs.DefaultConfig()
+	poolConfig.Workers = 1
+	poolConfig.QueueSize = bufferSize
+
+	// One worker per publisher keeps each publisher's events in order
+	pools := make([]*jobs.Pool, len(publishers))
+	for index := range publishers {
+		pools[index] = jobs.NewPool(poolConfig)
+	}
+
+	return &Bus{
+		publishers:     publishers,
+		pools:          pools,
+		publishTimeout: 5 * time.Second,
+		drainTimeout:   10 * time.Second,
+	}
+}
+
+// Publish enqueues a new event of the given type
+// A nil Bus is valid and discards all events, so callers don't need to check configuration
+func (bus *Bus) Publish(eventType string, data map[string]interface{}) {
+	if bus == nil {
+		return
+	}
+
+	event := &Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Source:     eventSource,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+
+	for index, publisher := range bus.publishers {
+		target := publisher
+
+		// Drop the event rather than block the request if the broker is falling behind
+		err := bus.pools[index].Submit("publish:"+eventType, func(ctx context.Context) error {
+			publishContext, cancelPublish := context.WithTimeout(ctx, bus.publishTimeout)
+			defer cancelPublish()
+			return target.Publish(publishContext, event)
+		})
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("event_type", eventType).
+				Msg("Dropping event")
+		}
+	}
+}
+
+// Close stops accepting events, drains pending publishes, and closes the publishers
+func (bus *Bus) Close() error {
+	if bus == nil {
+		return nil
+	}
+
+	var closeErr error
+	bus.closeOnce.Do(func() {
+		drainContext, cancelDrain := context.WithTimeout(context.Background(), bus.drainTimeout)
+		defer cancelDrain()
+
+		// Drain the pools together so a slow publisher doesn't use up the others' time
+		var drainGroup sync.WaitGroup
+		for _, pool := range bus.pools {
+			drainGroup.Add(1)
+			go func() {
+				defer drainGroup.Done()
+				if err := pool.Drain(drainContext); err != nil {
+					log.Warn().Err(err).Msg("Event bus closed before all events were published")
+				}
+			}()
+		}
+		drainGroup.Wait()
+
+		for _, publisher := range bus.publishers {
+			if err := publisher.Close(); err != nil && closeErr == nil {
+				closeErr = err
+			}
+		}
+	})
+
+	return closeErr
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingPublisher is a Publisher that stores published events for assertions
+type recordingPublisher struct {
+	mutex     sync.Mutex
+	published []*Event
+	closed    bool
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, event *Event) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.published = append(publisher.published, event)
+	return nil
+}
+
+func (publisher *recordingPublisher) Close() error {
+	publisher.closed = true
+	return nil
+}
+
+// TestBus_PublishDeliversEvents tests that queued events reach the publisher after Close
+func TestBus_PublishDeliversEvents(t *testing.T) {
+	publisher := &recordingPublisher{}
+	bus := NewBus(10, publisher)
+
+	bus.Publish(TypeRateLimitExceeded, map[string]interface{}{"path": "/api/v1/summoner"})
+	bus.Publish(TypeAnalysisCompleted, nil)
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(publisher.published) != 2 {
+		t.Fatalf("Expected 2 published events, got %d", len(publisher.published))
+	}
+
+	firstEvent := publisher.published[0]
+	if firstEvent.Type != TypeRateLimitExceeded {
+		t.Errorf("Expected type '%s', got '%s'", TypeRateLimitExceeded, firstEvent.Type)
+	}
+
+	if firstEvent.ID == "" {
+		t.Error("Expected event ID to be set")
+	}
+
+	if firstEvent.Source != "opgl-gateway" {
+		t.Errorf("Expected source 'opgl-gateway', got '%s'", firstEvent.Source)
+	}
+
+	if !publisher.closed {
+		t.Error("Expected publisher to be closed")
+	}
+}
+
+// blockingPublisher is a Publisher whose publishes wait until release is closed
+type blockingPublisher struct {
+	release chan struct{}
+}
+
+func (publisher *blockingPublisher) Publish(ctx context.Context, event *Event) error {
+	select {
+	case <-publisher.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (publisher *blockingPublisher) Close() error {
+	return nil
+}
+
+// TestBus_SlowPublisherDoesNotDelayOthers tests that each publisher is delivered to independently
+func TestBus_SlowPublisherDoesNotDelayOthers(t *testing.T) {
+	slowPublisher := &blockingPublisher{release: make(chan struct{})}
+	fastPublisher := &recordingPublisher{}
+	bus := NewBus(10, slowPublisher, fastPublisher)
+
+	bus.Publish(TypeRateLimitExceeded, nil)
+	bus.Publish(TypeAnalysisCompleted, nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		fastPublisher.mutex.Lock()
+		delivered := len(fastPublisher.published)
+		fastPublisher.mutex.Unlock()
+		if delivered == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 events delivered while the other publisher was blocked, got %d", delivered)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(slowPublisher.release)
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// TestBus_NilBusIsNoOp tests that a nil Bus can be used without configuration
+func TestBus_NilBusIsNoOp(t *testing.T) {
+	var bus *Bus
+
+	bus.Publish(TypeAnalysisCompleted, nil)
+
+	if err := bus.Close(); err != nil {
+		t.Errorf("Expected nil error from nil bus, got %v", err)
+	}
+}
+
+// TestBus_CloseIsIdempotent tests that Close can be called more than once
+func TestBus_CloseIsIdempotent(t *testing.T) {
+	bus := NewBus(1, &recordingPublisher{})
+
+	bus.Close()
+	if err := bus.Close(); err != nil {
+		t.Errorf("Expected second Close to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+)
+
+// LogPublisher writes events to the application log (used when no broker is configured)
+type LogPublisher struct{}
+
+// NewLogPublisher creates a new LogPublisher
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish logs the event at debug level
+func (publisher *LogPublisher) Publish(ctx context.Context, event *Event) error {
+	log.Debug().
+		Str("event_id", event.ID).
+		Str("event_type", event.Type).
+		Interface("data", event.Data).
+		Msg("Event published")
+	return nil
+}
+
+// Close is a no-op for LogPublisher
+func (publisher *LogPublisher) Close() error {
+	return nil
+}
+
+// NATSPublisher publishes events to a NATS server
+// Each event is published on "<subjectPrefix>.<event type>"
+type NATSPublisher struct {
+	connection    *nats.Conn
+	subjectPrefix string
+}
+
+// NewNATSPublisher connects to the NATS server at the given URL
+func NewNATSPublisher(natsURL string, subjectPrefix string) (*NATSPublisher, error) {
+	connection, err := nats.Connect(natsURL,
+		nats.Name("opgl-gateway"),
+		nats.Timeout(5*time.Second),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSPublisher{
+		connection:    connection,
+		subjectPrefix: subjectPrefix,
+	}, nil
+}
+
+// Publish sends the event as JSON to NATS
+func (publisher *NATSPublisher) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return publisher.connection.Publish(publisher.subjectPrefix+"."+event.Type, payload)
+}
+
+// Close flushes pending messages and closes the NATS connection
+func (publisher *NATSPublisher) Close() error {
+	return publisher.connection.Drain()
+}
+
+// WebhookPublisher delivers events as signed HTTP POST requests
+// Each delivery carries X-OPGL-Signature with one signature per active secret,
+// so receivers keep verifying while secrets are rotated.
+type WebhookPublisher struct {
+	webhookURL string
+	secrets    []string
+	httpClient *http.Client
+}
+
+// NewWebhookPublisher creates a new WebhookPublisher
+// secrets are ordered newest first; all of them sign every delivery
+// transport may be nil to use http.DefaultTransport (e.g. pass an egress-guarded transport).
+func NewWebhookPublisher(webhookURL string, secrets []string, transport http.RoundTripper) *WebhookPublisher {
+	return &WebhookPublisher{
+		webhookURL: webhookURL,
+		secrets:    secrets,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+	}
+}
+
+// Publish posts the event as JSON to the webhook URL
+// Non-2xx responses are returned as errors so the job pool retries the delivery
+func (publisher *WebhookPublisher) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, publisher.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	signatures := make([]string, 0, len(publisher.secrets))
+	for _, secret := range publisher.secrets {
+		signatures = append(signatures, signing.Sign(secret, timestamp, payload))
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("X-OPGL-Event", event.Type)
+	httpRequest.Header.Set("X-OPGL-Delivery", event.ID)
+	httpRequest.Header.Set("X-OPGL-Timestamp", strconv.FormatInt(timestamp, 10))
+	if len(signatures) > 0 {
+		httpRequest.Header.Set("X-OPGL-Signature", strings.Join(signatures, ","))
+	}
+
+	response, err := publisher.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Close is a no-op for WebhookPublisher
+func (publisher *WebhookPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+)
+
+// TestWebhookPublisher_SignsDeliveries tests that receivers can verify deliveries with any active secret
+func TestWebhookPublisher_SignsDeliveries(t *testing.T) {
+	var receivedBody []byte
+	var receivedHeaders http.Header
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedBody, _ = io.ReadAll(request.Body)
+		receivedHeaders = request.Header.Clone()
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+
+	publisher := NewWebhookPublisher(mockServer.URL, []string{"new-secret", "old-secret"}, nil)
+	event := &Event{ID: "event-id", Type: TypeAnalysisCompleted, OccurredAt: time.Now()}
+
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if receivedHeaders.Get("X-OPGL-Event") != TypeAnalysisCompleted {
+		t.Errorf("Expected X-OPGL-Event '%s', got '%s'", TypeAnalysisCompleted, receivedHeaders.Get("X-OPGL-Event"))
+	}
+
+	if receivedHeaders.Get("X-OPGL-Delivery") != "event-id" {
+		t.Errorf("Expected X-OPGL-Delivery 'event-id', got '%s'", receivedHeaders.Get("X-OPGL-Delivery"))
+	}
+
+	timestamp, err := strconv.ParseInt(receivedHeaders.Get("X-OPGL-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("Expected numeric X-OPGL-Timestamp, got '%s'", receivedHeaders.Get("X-OPGL-Timestamp"))
+	}
+
+	signatureHeader := receivedHeaders.Get("X-OPGL-Signature")
+	for _, secret := range []string{"new-secret", "old-secret"} {
+		if !signing.Verify(secret, timestamp, receivedBody, signatureHeader) {
+			t.Errorf("Expected delivery to verify with '%s'", secret)
+		}
+	}
+
+	if signing.Verify("unknown-secret", timestamp, receivedBody, signatureHeader) {
+		t.Error("Expected delivery not to verify with an unknown secret")
+	}
+}
+
+// TestWebhookPublisher_NonSuccessStatus tests that failed deliveries return an error for retry
+func TestWebhookPublisher_NonSuccessStatus(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	publisher := NewWebhookPublisher(mockServer.URL, []string{"secret"}, nil)
+
+	if err := publisher.Publish(context.Background(), &Event{ID: "event-id"}); err == nil {
+		t.Error("Expected error for non-2xx webhook response")
+	}
+}
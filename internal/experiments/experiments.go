@@ -0,0 +1,158 @@
+package experiments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Header carries a request's assignments to the client and to proxied upstreams
+const Header = "X-OPGL-Experiments"
+
+// Variant is one arm of an experiment
+type Variant struct {
+	Name string `yaml:"name"`
+	// Weight is the variant's share of traffic relative to the other variants
+	Weight int `yaml:"weight"`
+}
+
+// Experiment splits the callers of a set of API routes between variants
+type Experiment struct {
+	// Name identifies the experiment in headers, upstream payloads and exposure events
+	Name string `yaml:"name"`
+	// Paths are the API routes the experiment runs on
+	Paths    []string  `yaml:"paths"`
+	Variants []Variant `yaml:"variants"`
+}
+
+// Assignment is the variant a caller was assigned in one experiment
+type Assignment struct {
+	Experiment string
+	Variant    string
+}
+
+// Assigner deterministically assigns callers to experiment variants
+type Assigner struct {
+	experiments []Experiment
+}
+
+// NewAssigner creates an Assigner for the given experiments
+func NewAssigner(experiments []Experiment) *Assigner {
+	return &Assigner{experiments: experiments}
+}
+
+// Validate checks experiment names and variant weights
+func Validate(experiments []Experiment) error {
+	names := make(map[string]bool, len(experiments))
+	for i, experiment := range experiments {
+		if experiment.Name == "" || strings.ContainsAny(experiment.Name, "=,; ") {
+			return fmt.Errorf("experiment %d: name must be non-empty and contain no '=', ',', ';' or spaces", i+1)
+		}
+		if names[experiment.Name] {
+			return fmt.Errorf("experiment %s: declared more than once", experiment.Name)
+		}
+		names[experiment.Name] = true
+
+		if len(experiment.Paths) == 0 {
+			return fmt.Errorf("experiment %s: at least one path is required", experiment.Name)
+		}
+		if len(experiment.Variants) < 2 {
+			return fmt.Errorf("experiment %s: at least two variants are required", experiment.Name)
+		}
+
+		variantNames := make(map[string]bool, len(experiment.Variants))
+		for _, variant := range experiment.Variants {
+			if variant.Name == "" || strings.ContainsAny(variant.Name, "=,; ") {
+				return fmt.Errorf("experiment %s: variant names must be non-empty and contain no '=', ',', ';' or spaces", experiment.Name)
+			}
+			if variantNames[variant.Name] {
+				return fmt.Errorf("experiment %s: variant %s declared more than once", experiment.Name, variant.Name)
+			}
+			variantNames[variant.Name] = true
+			if variant.Weight < 1 {
+				return fmt.Errorf("experiment %s: variant %s must have a positive weight", experiment.Name, variant.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Assign returns the subject's variant in every experiment running on path
+// The same subject always lands in the same variant, so callers see consistent
+// behaviour without the gateway storing assignments.
+func (assigner *Assigner) Assign(path string, subject string) []Assignment {
+	var assignments []Assignment
+	for _, experiment := range assigner.experiments {
+		if !runsOn(experiment, path) {
+			continue
+		}
+		assignments = append(assignments, Assignment{
+			Experiment: experiment.Name,
+			Variant:    pickVariant(experiment, subject),
+		})
+	}
+	return assignments
+}
+
+// runsOn reports whether the experiment covers path
+func runsOn(experiment Experiment, path string) bool {
+	for _, experimentPath := range experiment.Paths {
+		if experimentPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// pickVariant hashes the subject into the experiment's weighted variants
+// The experiment name is part of the hash so assignments across experiments are independent.
+func pickVariant(experiment Experiment, subject string) string {
+	totalWeight := 0
+	for _, variant := range experiment.Variants {
+		totalWeight += variant.Weight
+	}
+
+	digest := sha256.Sum256([]byte(experiment.Name + ":" + subject))
+	bucket := int(binary.BigEndian.Uint64(digest[:8]) % uint64(totalWeight))
+	for _, variant := range experiment.Variants {
+		if bucket < variant.Weight {
+			return variant.Name
+		}
+		bucket -= variant.Weight
+	}
+	return experiment.Variants[len(experiment.Variants)-1].Name
+}
+
+// FormatHeader renders assignments as "experiment=variant" pairs for the Header
+func FormatHeader(assignments []Assignment) string {
+	pairs := make([]string, len(assignments))
+	for i, assignment := range assignments {
+		pairs[i] = assignment.Experiment + "=" + assignment.Variant
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Variants maps experiment names to the assigned variant, the form sent in upstream payloads
+func Variants(assignments []Assignment) map[string]string {
+	variants := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		variants[assignment.Experiment] = assignment.Variant
+	}
+	return variants
+}
+
+// assignmentsContextKey is the context key under which assignments are stored
+type assignmentsContextKey struct{}
+
+// WithAssignments returns a copy of ctx carrying the request's assignments
+func WithAssignments(ctx context.Context, assignments []Assignment) context.Context {
+	return context.WithValue(ctx, assignmentsContextKey{}, assignments)
+}
+
+// FromContext returns the request's assignments, or nil when it isn't in any experiment
+func FromContext(ctx context.Context) []Assignment {
+	assignments, _ := ctx.Value(assignmentsContextKey{}).([]Assignment)
+	return assignments
+}
@@ -0,0 +1,87 @@
+package experiments
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// analysisModel is a 90/10 experiment on /analyze used across tests
+var analysisModel = Experiment{
+	Name:     "analysis-model",
+	Paths:    []string{"/api/v1/analyze"},
+	Variants: []Variant{{Name: "control", Weight: 90}, {Name: "cortex-v2", Weight: 10}},
+}
+
+// TestAssigner_Assign tests that assignment is deterministic, weighted and limited to the experiment's paths
+func TestAssigner_Assign(t *testing.T) {
+	assigner := NewAssigner([]Experiment{analysisModel})
+
+	first := assigner.Assign("/api/v1/analyze", "key-a")
+	if len(first) != 1 || first[0].Experiment != "analysis-model" {
+		t.Fatalf("Expected one analysis-model assignment, got %v", first)
+	}
+	for i := 0; i < 10; i++ {
+		if again := assigner.Assign("/api/v1/analyze", "key-a"); again[0] != first[0] {
+			t.Errorf("Expected a stable assignment %v, got %v", first[0], again[0])
+		}
+	}
+
+	if assignments := assigner.Assign("/api/v1/summoner", "key-a"); len(assignments) != 0 {
+		t.Errorf("Expected no assignments outside the experiment's paths, got %v", assignments)
+	}
+
+	treatment := 0
+	for i := 0; i < 10000; i++ {
+		if assigner.Assign("/api/v1/analyze", "key-"+strconv.Itoa(i))[0].Variant == "cortex-v2" {
+			treatment++
+		}
+	}
+	if treatment < 800 || treatment > 1200 {
+		t.Errorf("Expected about 10%% of subjects in cortex-v2, got %d of 10000", treatment)
+	}
+}
+
+// TestValidate tests that malformed experiments are rejected
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		experiment    Experiment
+		expectedError string
+	}{
+		{"missing name", Experiment{Paths: analysisModel.Paths, Variants: analysisModel.Variants}, "name must be non-empty"},
+		{"no paths", Experiment{Name: "x", Variants: analysisModel.Variants}, "at least one path"},
+		{"single variant", Experiment{Name: "x", Paths: analysisModel.Paths, Variants: analysisModel.Variants[:1]}, "at least two variants"},
+		{"zero weight", Experiment{Name: "x", Paths: analysisModel.Paths, Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b"}}}, "positive weight"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := Validate([]Experiment{testCase.experiment})
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("Expected error containing '%s', got '%v'", testCase.expectedError, err)
+			}
+		})
+	}
+
+	if err := Validate([]Experiment{analysisModel, analysisModel}); err == nil || !strings.Contains(err.Error(), "declared more than once") {
+		t.Errorf("Expected duplicate experiment error, got '%v'", err)
+	}
+}
+
+// TestContextAndHeader tests carrying assignments through the context and formatting them
+func TestContextAndHeader(t *testing.T) {
+	assignments := []Assignment{{Experiment: "analysis-model", Variant: "cortex-v2"}, {Experiment: "tips", Variant: "on"}}
+	ctx := WithAssignments(context.Background(), assignments)
+
+	if len(FromContext(ctx)) != 2 || FromContext(context.Background()) != nil {
+		t.Errorf("Expected assignments only in the derived context, got %v", FromContext(ctx))
+	}
+	if header := FormatHeader(assignments); header != "analysis-model=cortex-v2,tips=on" {
+		t.Errorf("Expected 'analysis-model=cortex-v2,tips=on', got '%s'", header)
+	}
+	if variants := Variants(assignments); variants["analysis-model"] != "cortex-v2" || variants["tips"] != "on" {
+		t.Errorf("Expected variants by experiment, got %v", variants)
+	}
+}
@@ -0,0 +1,181 @@
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Filter is request/response middleware applied to a route's chain
+type Filter func(http.Handler) http.Handler
+
+// Factory builds a Filter from the options given in the route file
+type Factory func(options map[string]string) (Filter, error)
+
+// PluginSymbol is the function a Go plugin must export to provide a filter
+// Its signature uses standard library types only so plugins don't have to be
+// built against this module:
+//
+//	func NewFilter(options map[string]string) (func(http.Handler) http.Handler, error)
+const PluginSymbol = "NewFilter"
+
+// Spec declares a filter instance in the route file
+type Spec struct {
+	// Name is how middleware chains refer to the filter
+	Name string `yaml:"name"`
+	// Type selects a compiled-in filter registered with Register
+	Type string `yaml:"type"`
+	// Plugin is the path of a Go plugin (.so) exporting NewFilter; used instead of Type
+	Plugin string `yaml:"plugin"`
+	// Options are passed to the filter's factory
+	Options map[string]string `yaml:"options"`
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{
+		"headers": newHeadersFilter,
+	}
+)
+
+// Register makes a compiled-in filter type available to the route file
+// Forks and embedders call it from an init function; registering a type twice panics.
+func Register(filterType string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, exists := registry[filterType]; exists {
+		panic("filters: type " + filterType + " registered twice")
+	}
+	registry[filterType] = factory
+}
+
+// Types returns the registered filter types in sorted order
+func Types() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	filterTypes := make([]string, 0, len(registry))
+	for filterType := range registry {
+		filterTypes = append(filterTypes, filterType)
+	}
+	sort.Strings(filterTypes)
+	return filterTypes
+}
+
+// Load builds every declared filter, keyed by name
+func Load(specs []Spec) (map[string]Filter, error) {
+	loaded := make(map[string]Filter, len(specs))
+	for _, spec := range specs {
+		factory, err := factoryFor(spec)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", spec.Name, err)
+		}
+
+		filter, err := factory(spec.Options)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", spec.Name, err)
+		}
+		loaded[spec.Name] = filter
+	}
+	return loaded, nil
+}
+
+// factoryFor resolves the factory of a spec from the registry or a Go plugin
+func factoryFor(spec Spec) (Factory, error) {
+	if spec.Plugin == "" {
+		registryMutex.RLock()
+		factory, exists := registry[spec.Type]
+		registryMutex.RUnlock()
+		if !exists {
+			return nil, fmt.Errorf("unknown type %q (registered: %s)", spec.Type, strings.Join(Types(), ", "))
+		}
+		return factory, nil
+	}
+
+	goPlugin, err := plugin.Open(spec.Plugin)
+	if err != nil {
+		return nil, fmt.Errorf("open plugin: %w", err)
+	}
+	symbol, err := goPlugin.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", spec.Plugin, err)
+	}
+	newFilter, ok := symbol.(func(map[string]string) (func(http.Handler) http.Handler, error))
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: %s has the wrong signature", spec.Plugin, PluginSymbol)
+	}
+
+	return func(options map[string]string) (Filter, error) {
+		filter, err := newFilter(options)
+		return Filter(filter), err
+	}, nil
+}
+
+// newHeadersFilter builds the compiled-in "headers" filter
+// Options named request.<Header> set a request header before the route runs and
+// response.<Header> set a response header; an empty value removes the header.
+func newHeadersFilter(options map[string]string) (Filter, error) {
+	requestHeaders := make(map[string]string)
+	responseHeaders := make(map[string]string)
+	for key, value := range options {
+		target, header, found := strings.Cut(key, ".")
+		if !found || header == "" {
+			return nil, fmt.Errorf("option %q must be request.<Header> or response.<Header>", key)
+		}
+
+		switch target {
+		case "request":
+			requestHeaders[http.CanonicalHeaderKey(header)] = value
+		case "response":
+			responseHeaders[http.CanonicalHeaderKey(header)] = value
+		default:
+			return nil, fmt.Errorf("option %q must be request.<Header> or response.<Header>", key)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			applyHeaders(request.Header, requestHeaders)
+			next.ServeHTTP(&headerWriter{ResponseWriter: writer, headers: responseHeaders}, request)
+		})
+	}, nil
+}
+
+// headerWriter is a wrapper around http.ResponseWriter that rewrites headers just before they are sent
+type headerWriter struct {
+	http.ResponseWriter
+	headers     map[string]string
+	wroteHeader bool
+}
+
+// WriteHeader applies the header rewrites and calls the underlying WriteHeader
+func (writer *headerWriter) WriteHeader(statusCode int) {
+	if !writer.wroteHeader {
+		writer.wroteHeader = true
+		applyHeaders(writer.Header(), writer.headers)
+	}
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write sends the rewritten headers first if the handler didn't call WriteHeader
+func (writer *headerWriter) Write(data []byte) (int, error) {
+	if !writer.wroteHeader {
+		writer.WriteHeader(http.StatusOK)
+	}
+	return writer.ResponseWriter.Write(data)
+}
+
+// applyHeaders sets or removes headers in place
+func applyHeaders(header http.Header, values map[string]string) {
+	for name, value := range values {
+		if value == "" {
+			header.Del(name)
+		} else {
+			header.Set(name, value)
+		}
+	}
+}
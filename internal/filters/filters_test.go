@@ -0,0 +1,84 @@
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestLoad_HeadersFilter tests that the compiled-in headers filter rewrites request and response headers
+func TestLoad_HeadersFilter(t *testing.T) {
+	loaded, err := Load([]Spec{{
+		Name: "tenant",
+		Type: "headers",
+		Options: map[string]string{
+			"request.X-Tenant":  "eu-west",
+			"response.Server":   "",
+			"response.X-Filter": "tenant",
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var receivedTenant string
+	handler := loaded["tenant"](http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedTenant = request.Header.Get("X-Tenant")
+		writer.Header().Set("Server", "opgl-data")
+		writer.Write([]byte("ok"))
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil))
+
+	if receivedTenant != "eu-west" {
+		t.Errorf("Expected X-Tenant 'eu-west', got '%s'", receivedTenant)
+	}
+	if responseRecorder.Header().Get("Server") != "" {
+		t.Errorf("Expected Server header to be removed, got '%s'", responseRecorder.Header().Get("Server"))
+	}
+	if responseRecorder.Header().Get("X-Filter") != "tenant" {
+		t.Errorf("Expected X-Filter 'tenant', got '%s'", responseRecorder.Header().Get("X-Filter"))
+	}
+}
+
+// TestLoad_Errors tests that unknown types, bad options and missing plugins fail at startup
+func TestLoad_Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		spec          Spec
+		expectedError string
+	}{
+		{"unknown type", Spec{Name: "x", Type: "wasm"}, "unknown type"},
+		{"bad option", Spec{Name: "x", Type: "headers", Options: map[string]string{"X-Tenant": "a"}}, "must be request.<Header>"},
+		{"missing plugin", Spec{Name: "x", Plugin: "/nonexistent/filter.so"}, "open plugin"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Load([]Spec{testCase.spec})
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("Expected error containing '%s', got '%v'", testCase.expectedError, err)
+			}
+		})
+	}
+}
+
+// TestRegister tests that registered types can be loaded and duplicates panic
+func TestRegister(t *testing.T) {
+	Register("test-noop", func(options map[string]string) (Filter, error) {
+		return func(next http.Handler) http.Handler { return next }, nil
+	})
+
+	if _, err := Load([]Spec{{Name: "noop", Type: "test-noop"}}); err != nil {
+		t.Errorf("Expected registered type to load, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a type twice to panic")
+		}
+	}()
+	Register("test-noop", nil)
+}
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/unixsocket"
+)
+
+// Overall verdicts reported by the health endpoint
+const (
+	StatusHealthy  = "healthy"
+	StatusDegraded = "degraded"
+)
+
+// Per-upstream reachability states
+const (
+	UpstreamUp   = "up"
+	UpstreamDown = "down"
+)
+
+// Readiness verdicts reported by the readiness endpoint
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Upstream names a backend service probed by the Checker
+// URL may be unix:///path/to/socket for upstreams reached over a unix domain socket.
+type Upstream struct {
+	Name string
+	URL  string
+	// Required makes the gateway not ready while the upstream is down
+	Required bool
+}
+
+// UpstreamStatus describes the latest probe of a single upstream
+type UpstreamStatus struct {
+	Status      string     `json:"status"`
+	LatencyMs   int64      `json:"latencyMs"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Report is the health verdict for the gateway and its upstreams
+type Report struct {
+	Status    string                    `json:"status"`
+	Service   string                    `json:"service"`
+	Upstreams map[string]UpstreamStatus `json:"upstreams"`
+}
+
+// upstreamError remembers the most recent failure of an upstream
+type upstreamError struct {
+	message    string
+	occurredAt time.Time
+}
+
+// Checker probes upstream services and reports whether the gateway is degraded
+// Each upstream is probed with POST <url>/health. Reports are cached briefly so
+// frequent health checks don't turn into a burst of upstream traffic.
+type Checker struct {
+	upstreams    []Upstream
+	httpClient   *http.Client
+	probeTimeout time.Duration
+	cacheTTL     time.Duration
+
+	mutex        sync.Mutex
+	lastErrors   map[string]upstreamError
+	cachedReport *Report
+	cachedAt     time.Time
+}
+
+// NewChecker creates a new Checker for the given upstreams
+func NewChecker(upstreams ...Upstream) *Checker {
+	sockets := unixsocket.New()
+	probedUpstreams := make([]Upstream, len(upstreams))
+	for i, upstream := range upstreams {
+		probedUpstreams[i] = Upstream{Name: upstream.Name, URL: sockets.Register(upstream.Name, upstream.URL), Required: upstream.Required}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = sockets.DialContext(transport.DialContext)
+	transport.Proxy = sockets.Proxy(transport.Proxy)
+
+	return &Checker{
+		upstreams:    probedUpstreams,
+		httpClient:   &http.Client{Transport: transport},
+		probeTimeout: 2 * time.Second,
+		cacheTTL:     5 * time.Second,
+		lastErrors:   make(map[string]upstreamError),
+	}
+}
+
+// Check probes every upstream concurrently and returns the combined report
+// The gateway is healthy when all upstreams are up and degraded otherwise.
+func (checker *Checker) Check(ctx context.Context) *Report {
+	checker.mutex.Lock()
+	if checker.cachedReport != nil && time.Since(checker.cachedAt) < checker.cacheTTL {
+		cachedReport := checker.cachedReport
+		checker.mutex.Unlock()
+		return cachedReport
+	}
+	checker.mutex.Unlock()
+
+	type probeResult struct {
+		name    string
+		latency time.Duration
+		err     error
+	}
+
+	results := make(chan probeResult, len(checker.upstreams))
+	for _, upstream := range checker.upstreams {
+		go func(upstream Upstream) {
+			startTime := time.Now()
+			err := checker.probe(ctx, upstream.URL)
+			results <- probeResult{name: upstream.Name, latency: time.Since(startTime), err: err}
+		}(upstream)
+	}
+
+	report := &Report{
+		Status:    StatusHealthy,
+		Service:   "opgl-gateway",
+		Upstreams: make(map[string]UpstreamStatus, len(checker.upstreams)),
+	}
+
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	for range checker.upstreams {
+		result := <-results
+
+		upstreamStatus := UpstreamStatus{
+			Status:    UpstreamUp,
+			LatencyMs: result.latency.Milliseconds(),
+		}
+		if result.err != nil {
+			upstreamStatus.Status = UpstreamDown
+			report.Status = StatusDegraded
+			checker.lastErrors[result.name] = upstreamError{message: result.err.Error(), occurredAt: time.Now().UTC()}
+		}
+
+		// Keep reporting the last failure after recovery so flapping upstreams are visible
+		if lastError, exists := checker.lastErrors[result.name]; exists {
+			occurredAt := lastError.occurredAt
+			upstreamStatus.LastError = lastError.message
+			upstreamStatus.LastErrorAt = &occurredAt
+		}
+
+		report.Upstreams[result.name] = upstreamStatus
+	}
+
+	checker.cachedReport = report
+	checker.cachedAt = time.Now()
+	return report
+}
+
+// Degraded reports whether the most recent check found an upstream down
+// It never probes, so it is cheap enough to call on every request; it is false before the first check.
+func (checker *Checker) Degraded() bool {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+	return checker.cachedReport != nil && checker.cachedReport.Status == StatusDegraded
+}
+
+// Ready checks the upstreams and reports whether every required one is up
+func (checker *Checker) Ready(ctx context.Context) (bool, *Report) {
+	report := checker.Check(ctx)
+	for _, upstream := range checker.upstreams {
+		if upstream.Required && report.Upstreams[upstream.Name].Status != UpstreamUp {
+			return false, report
+		}
+	}
+	return true, report
+}
+
+// Watch checks the upstreams every interval until ctx is done
+// onChange is called with the report whenever the verdict flips between healthy and
+// degraded, so the gateway can signal trouble before clients see hard failures.
+func (checker *Checker) Watch(ctx context.Context, interval time.Duration, onChange func(report *Report)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastStatus := StatusHealthy
+	for {
+		report := checker.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if report.Status != lastStatus {
+			lastStatus = report.Status
+			onChange(report)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe calls the upstream health endpoint and returns an error unless it answers 2xx
+func (checker *Checker) probe(ctx context.Context, baseURL string) error {
+	probeContext, cancelProbe := context.WithTimeout(ctx, checker.probeTimeout)
+	defer cancelProbe()
+
+	httpRequest, err := http.NewRequestWithContext(probeContext, http.MethodPost, baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := checker.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("health check returned status %d", response.StatusCode)
+	}
+
+	return nil
+}
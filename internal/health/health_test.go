@@ -0,0 +1,193 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestChecker_AllUpstreamsUp tests that the verdict is healthy when every upstream answers
+func TestChecker_AllUpstreamsUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost || request.URL.Path != "/health" {
+			t.Errorf("Expected POST /health, got %s %s", request.Method, request.URL.Path)
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := NewChecker(Upstream{Name: "data", URL: server.URL}, Upstream{Name: "cortex", URL: server.URL})
+	report := checker.Check(context.Background())
+
+	if report.Status != StatusHealthy {
+		t.Errorf("Expected status '%s', got '%s'", StatusHealthy, report.Status)
+	}
+
+	if len(report.Upstreams) != 2 {
+		t.Fatalf("Expected 2 upstreams, got %d", len(report.Upstreams))
+	}
+
+	if report.Upstreams["data"].Status != UpstreamUp {
+		t.Errorf("Expected data upstream '%s', got '%s'", UpstreamUp, report.Upstreams["data"].Status)
+	}
+}
+
+// TestChecker_UpstreamDown tests that a failing upstream degrades the verdict and records the error
+func TestChecker_UpstreamDown(t *testing.T) {
+	healthyServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer healthyServer.Close()
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	checker := NewChecker(Upstream{Name: "data", URL: healthyServer.URL}, Upstream{Name: "cortex", URL: failingServer.URL})
+	report := checker.Check(context.Background())
+
+	if report.Status != StatusDegraded {
+		t.Errorf("Expected status '%s', got '%s'", StatusDegraded, report.Status)
+	}
+
+	cortexStatus := report.Upstreams["cortex"]
+	if cortexStatus.Status != UpstreamDown {
+		t.Errorf("Expected cortex upstream '%s', got '%s'", UpstreamDown, cortexStatus.Status)
+	}
+
+	if cortexStatus.LastError == "" || cortexStatus.LastErrorAt == nil {
+		t.Error("Expected last error to be recorded")
+	}
+
+	if report.Upstreams["data"].LastError != "" {
+		t.Errorf("Expected no error for data upstream, got '%s'", report.Upstreams["data"].LastError)
+	}
+}
+
+// TestChecker_KeepsLastErrorAfterRecovery tests that the last failure is still reported once the upstream recovers
+func TestChecker_KeepsLastErrorAfterRecovery(t *testing.T) {
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if failing {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := NewChecker(Upstream{Name: "auth", URL: server.URL})
+	checker.cacheTTL = 0
+	checker.Check(context.Background())
+
+	failing = false
+	report := checker.Check(context.Background())
+
+	if report.Status != StatusHealthy {
+		t.Errorf("Expected status '%s', got '%s'", StatusHealthy, report.Status)
+	}
+
+	if report.Upstreams["auth"].LastError == "" {
+		t.Error("Expected last error to be kept after recovery")
+	}
+}
+
+// TestChecker_CachesReport tests that reports are reused within the cache TTL
+func TestChecker_CachesReport(t *testing.T) {
+	probeCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		probeCount++
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := NewChecker(Upstream{Name: "data", URL: server.URL})
+	checker.Check(context.Background())
+	checker.Check(context.Background())
+
+	if probeCount != 1 {
+		t.Errorf("Expected 1 probe, got %d", probeCount)
+	}
+}
+
+// TestChecker_Ready tests that only required upstreams decide readiness
+func TestChecker_Ready(t *testing.T) {
+	healthyServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer healthyServer.Close()
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	checker := NewChecker(Upstream{Name: "data", URL: healthyServer.URL, Required: true}, Upstream{Name: "cortex", URL: failingServer.URL})
+	if checker.Degraded() {
+		t.Error("Expected no degraded verdict before the first check")
+	}
+	if ready, _ := checker.Ready(context.Background()); !ready {
+		t.Error("Expected ready while only an optional upstream is down")
+	}
+	if !checker.Degraded() {
+		t.Error("Expected the degraded verdict of the last check")
+	}
+
+	checker = NewChecker(Upstream{Name: "data", URL: healthyServer.URL, Required: true}, Upstream{Name: "cortex", URL: failingServer.URL, Required: true})
+	ready, report := checker.Ready(context.Background())
+	if ready {
+		t.Error("Expected not ready while a required upstream is down")
+	}
+	if report.Upstreams["cortex"].Status != UpstreamDown {
+		t.Errorf("Expected the report with the failing upstream, got %+v", report.Upstreams)
+	}
+}
+
+// TestChecker_Watch tests that Watch reports each flip between healthy and degraded once
+func TestChecker_Watch(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if failing.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := NewChecker(Upstream{Name: "data", URL: server.URL})
+	checker.cacheTTL = 0
+
+	changes := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failing.Store(true)
+	go checker.Watch(ctx, 10*time.Millisecond, func(report *Report) {
+		changes <- report.Status
+	})
+
+	waitForChange := func(expected string) {
+		select {
+		case status := <-changes:
+			if status != expected {
+				t.Fatalf("Expected change to '%s', got '%s'", expected, status)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected change to '%s'", expected)
+		}
+	}
+	waitForChange(StatusDegraded)
+	failing.Store(false)
+	waitForChange(StatusHealthy)
+
+	select {
+	case status := <-changes:
+		t.Errorf("Expected no further changes, got '%s'", status)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
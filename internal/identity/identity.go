@@ -0,0 +1,67 @@
+package identity
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Typed context keys, so values can't collide with keys set by other packages
+type (
+	userIDContextKey    struct{}
+	apiKeyIDContextKey  struct{}
+	rolesContextKey     struct{}
+	requestIDContextKey struct{}
+)
+
+// WithUserID returns a copy of ctx carrying the ID of the user whose access token was accepted
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserID returns the ID of the authenticated user, if the request carried a valid access token
+func UserID(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDContextKey{}).(uuid.UUID)
+	return userID, ok
+}
+
+// WithAPIKeyID returns a copy of ctx carrying the ID of the accepted API key
+// The ID is the key's fingerprint, never the key itself.
+func WithAPIKeyID(ctx context.Context, apiKeyID string) context.Context {
+	return context.WithValue(ctx, apiKeyIDContextKey{}, apiKeyID)
+}
+
+// APIKeyID returns the ID of the API key accepted by rate limiting, or "" if none
+// It is safe to log or pass to upstreams.
+func APIKeyID(ctx context.Context) string {
+	apiKeyID, _ := ctx.Value(apiKeyIDContextKey{}).(string)
+	return apiKeyID
+}
+
+// WithRoles returns a copy of ctx carrying the authenticated user's roles
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesContextKey{}, roles)
+}
+
+// Roles returns the authenticated user's roles, or nil if none
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesContextKey{}).([]string)
+	return roles
+}
+
+// HasRole reports whether the authenticated user has role
+func HasRole(ctx context.Context, role string) bool {
+	return slices.Contains(Roles(ctx), role)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestID returns the ID assigned to the request, or "" if none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,40 @@
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestIdentity tests that each value is read back and absent values are zero
+func TestIdentity(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := UserID(ctx); ok || APIKeyID(ctx) != "" || Roles(ctx) != nil || RequestID(ctx) != "" {
+		t.Error("Expected no identity in an empty context")
+	}
+
+	userID := uuid.New()
+	ctx = WithUserID(ctx, userID)
+	ctx = WithAPIKeyID(ctx, "3f2a9c1b7d4e")
+	ctx = WithRoles(ctx, []string{"admin"})
+	ctx = WithRequestID(ctx, "req-1")
+
+	if got, ok := UserID(ctx); !ok || got != userID {
+		t.Errorf("Expected user ID %s, got %s", userID, got)
+	}
+	if APIKeyID(ctx) != "3f2a9c1b7d4e" {
+		t.Errorf("Expected API key ID '3f2a9c1b7d4e', got '%s'", APIKeyID(ctx))
+	}
+	if !HasRole(ctx, "admin") || HasRole(ctx, "support") {
+		t.Errorf("Expected only the admin role, got %v", Roles(ctx))
+	}
+	if RequestID(ctx) != "req-1" {
+		t.Errorf("Expected request ID 'req-1', got '%s'", RequestID(ctx))
+	}
+
+	// A raw string key doesn't reach the typed value
+	if _, ok := UserID(context.WithValue(context.Background(), "userID", userID)); ok {
+		t.Error("Expected a string-keyed value to be ignored")
+	}
+}
@@ -0,0 +1,268 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrPoolClosed is returned when submitting to a pool that is draining or drained
+	ErrPoolClosed = errors.New("job pool is closed")
+	// ErrQueueFull is returned when the pool cannot accept more work
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// maxDeadLetters bounds how many failed jobs are retained for inspection
+const maxDeadLetters = 100
+
+// RunFunc is the unit of work executed by a job
+// The context is cancelled when the pool is forced to stop
+type RunFunc func(ctx context.Context) error
+
+// Config holds worker pool settings
+type Config struct {
+	// Workers is the number of goroutines executing jobs concurrently
+	Workers int
+	// QueueSize is the maximum number of jobs waiting to run
+	QueueSize int
+	// MaxAttempts is the total number of tries before a job is dead-lettered
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry (doubled on each retry)
+	InitialBackoff time.Duration
+	// MaxBackoff caps the retry delay
+	MaxBackoff time.Duration
+}
+
+// DefaultConfig returns sensible defaults for background work
+func DefaultConfig() Config {
+	return Config{
+		Workers:        4,
+		QueueSize:      1024,
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// job is a queued unit of work with its retry state
+type job struct {
+	id       string
+	name     string
+	attempts int
+	run      RunFunc
+}
+
+// DeadLetter records a job that exhausted its retries
+type DeadLetter struct {
+	JobID    string    `json:"jobId"`
+	Name     string    `json:"name"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// Pool runs submitted jobs on a fixed set of workers with retries and dead-lettering
+type Pool struct {
+	config        Config
+	queue         chan *job
+	baseContext   context.Context
+	cancelBase    context.CancelFunc
+	pendingJobs   sync.WaitGroup
+	workerGroup   sync.WaitGroup
+	mutex         sync.Mutex
+	closed        bool
+	deadLetters   []DeadLetter
+	drainOnce     sync.Once
+	drainComplete chan struct{}
+}
+
+// NewPool creates a Pool and starts its workers
+func NewPool(config Config) *Pool {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+
+	baseContext, cancelBase := context.WithCancel(context.Background())
+
+	pool := &Pool{
+		config:        config,
+		queue:         make(chan *job, config.QueueSize),
+		baseContext:   baseContext,
+		cancelBase:    cancelBase,
+		drainComplete: make(chan struct{}),
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		pool.workerGroup.Add(1)
+		go pool.work()
+	}
+
+	return pool
+}
+
+// Submit enqueues a named job without blocking
+func (pool *Pool) Submit(name string, run RunFunc) error {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.closed {
+		return ErrPoolClosed
+	}
+
+	newJob := &job{
+		id:   uuid.New().String(),
+		name: name,
+		run:  run,
+	}
+
+	pool.pendingJobs.Add(1)
+	select {
+	case pool.queue <- newJob:
+		return nil
+	default:
+		pool.pendingJobs.Done()
+		return ErrQueueFull
+	}
+}
+
+// Drain stops accepting jobs and waits for queued and retrying jobs to finish
+// If ctx expires first, running jobs are cancelled and ctx.Err() is returned
+func (pool *Pool) Drain(ctx context.Context) error {
+	pool.mutex.Lock()
+	pool.closed = true
+	pool.mutex.Unlock()
+
+	pool.drainOnce.Do(func() {
+		go func() {
+			pool.pendingJobs.Wait()
+			close(pool.drainComplete)
+		}()
+	})
+
+	var drainErr error
+	select {
+	case <-pool.drainComplete:
+	case <-ctx.Done():
+		drainErr = ctx.Err()
+	}
+
+	// Stop workers; any job still running sees a cancelled context
+	pool.cancelBase()
+	pool.workerGroup.Wait()
+
+	return drainErr
+}
+
+// DeadLetters returns the most recent jobs that exhausted their retries
+func (pool *Pool) DeadLetters() []DeadLetter {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	deadLetters := make([]DeadLetter, len(pool.deadLetters))
+	copy(deadLetters, pool.deadLetters)
+	return deadLetters
+}
+
+// work executes jobs until the pool is stopped
+func (pool *Pool) work() {
+	defer pool.workerGroup.Done()
+
+	for {
+		select {
+		case <-pool.baseContext.Done():
+			return
+		case queuedJob := <-pool.queue:
+			pool.execute(queuedJob)
+		}
+	}
+}
+
+// execute runs a single attempt of a job and schedules a retry on failure
+func (pool *Pool) execute(queuedJob *job) {
+	queuedJob.attempts++
+
+	err := queuedJob.run(pool.baseContext)
+	if err == nil {
+		pool.pendingJobs.Done()
+		return
+	}
+
+	// Don't retry once the pool has been forced to stop
+	if queuedJob.attempts >= pool.config.MaxAttempts || pool.baseContext.Err() != nil {
+		pool.deadLetter(queuedJob, err)
+		return
+	}
+
+	backoff := pool.backoff(queuedJob.attempts)
+	log.Warn().
+		Err(err).
+		Str("job_id", queuedJob.id).
+		Str("job_name", queuedJob.name).
+		Int("attempt", queuedJob.attempts).
+		Dur("retry_in", backoff).
+		Msg("Job failed, scheduling retry")
+
+	time.AfterFunc(backoff, func() {
+		select {
+		case pool.queue <- queuedJob:
+		default:
+			pool.deadLetter(queuedJob, ErrQueueFull)
+		}
+	})
+}
+
+// backoff returns the exponential delay before the given retry attempt
+func (pool *Pool) backoff(attempt int) time.Duration {
+	delay := pool.config.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= pool.config.MaxBackoff {
+			return pool.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// deadLetter records a permanently failed job and marks it finished
+func (pool *Pool) deadLetter(queuedJob *job, err error) {
+	defer pool.pendingJobs.Done()
+
+	log.Error().
+		Err(err).
+		Str("job_id", queuedJob.id).
+		Str("job_name", queuedJob.name).
+		Int("attempts", queuedJob.attempts).
+		Msg("Job moved to dead-letter list")
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.deadLetters = append(pool.deadLetters, DeadLetter{
+		JobID:    queuedJob.id,
+		Name:     queuedJob.name,
+		Attempts: queuedJob.attempts,
+		Error:    err.Error(),
+		FailedAt: time.Now().UTC(),
+	})
+	if len(pool.deadLetters) > maxDeadLetters {
+		pool.deadLetters = pool.deadLetters[len(pool.deadLetters)-maxDeadLetters:]
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig returns a small pool configuration with fast retries
+func testConfig() Config {
+	return Config{
+		Workers:        2,
+		QueueSize:      10,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+// TestPool_RunsSubmittedJobs tests that submitted jobs run before Drain returns
+func TestPool_RunsSubmittedJobs(t *testing.T) {
+	pool := NewPool(testConfig())
+
+	var completed int32
+	for i := 0; i < 5; i++ {
+		err := pool.Submit("increment", func(ctx context.Context) error {
+			atomic.AddInt32(&completed, 1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected submit error: %v", err)
+		}
+	}
+
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Fatalf("Unexpected drain error: %v", err)
+	}
+
+	if completed != 5 {
+		t.Errorf("Expected 5 completed jobs, got %d", completed)
+	}
+}
+
+// TestPool_RetriesFailedJobs tests that a job succeeding on a later attempt is not dead-lettered
+func TestPool_RetriesFailedJobs(t *testing.T) {
+	pool := NewPool(testConfig())
+
+	var attempts int32
+	pool.Submit("flaky", func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	pool.Drain(context.Background())
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	if len(pool.DeadLetters()) != 0 {
+		t.Errorf("Expected no dead letters, got %d", len(pool.DeadLetters()))
+	}
+}
+
+// TestPool_DeadLettersExhaustedJobs tests that a job failing every attempt is dead-lettered
+func TestPool_DeadLettersExhaustedJobs(t *testing.T) {
+	pool := NewPool(testConfig())
+
+	pool.Submit("always-fails", func(ctx context.Context) error {
+		return errors.New("permanent failure")
+	})
+
+	pool.Drain(context.Background())
+
+	deadLetters := pool.DeadLetters()
+	if len(deadLetters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(deadLetters))
+	}
+
+	if deadLetters[0].Name != "always-fails" {
+		t.Errorf("Expected name 'always-fails', got '%s'", deadLetters[0].Name)
+	}
+
+	if deadLetters[0].Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", deadLetters[0].Attempts)
+	}
+}
+
+// TestPool_SubmitAfterDrain tests that a drained pool rejects new jobs
+func TestPool_SubmitAfterDrain(t *testing.T) {
+	pool := NewPool(testConfig())
+	pool.Drain(context.Background())
+
+	err := pool.Submit("late", func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+}
+
+// TestPool_DrainTimeoutCancelsRunningJobs tests that an expired drain context cancels in-flight work
+func TestPool_DrainTimeoutCancelsRunningJobs(t *testing.T) {
+	pool := NewPool(testConfig())
+
+	pool.Submit("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	drainContext, cancelDrain := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelDrain()
+
+	if err := pool.Drain(drainContext); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestPool_Backoff tests exponential backoff with a cap
+func TestPool_Backoff(t *testing.T) {
+	pool := &Pool{config: Config{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+
+	testCases := map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+	}
+
+	for attempt, expected := range testCases {
+		if actual := pool.backoff(attempt); actual != expected {
+			t.Errorf("Attempt %d: expected backoff %v, got %v", attempt, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package logsink
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/jobs"
+)
+
+// Entry is one JSON log line and the time it was written
+type Entry struct {
+	Time time.Time
+	Line []byte
+}
+
+// Sink delivers batches of log lines to external storage
+type Sink interface {
+	// Name identifies the sink in job names and dead letters
+	Name() string
+	// Send delivers one batch; an error makes the job pool retry the whole batch
+	Send(ctx context.Context, batch []Entry) error
+	// Close releases any connections held by the sink
+	Close() error
+}
+
+// Config holds the batching settings of an Exporter
+type Config struct {
+	// BatchSize is the number of lines that triggers a flush before FlushInterval
+	BatchSize int
+	// FlushInterval is the longest a line waits before its batch is sent
+	FlushInterval time.Duration
+}
+
+// Exporter is an io.Writer for the gateway's JSON log that copies every line to the sinks
+// Lines are batched and each sink's batches are delivered and retried on a job pool, so
+// logging never blocks on a slow sink. When the pool falls behind, batches are dropped
+// and counted rather than buffered without bound.
+type Exporter struct {
+	sinks        []Sink
+	config       Config
+	pool         *jobs.Pool
+	drainTimeout time.Duration
+
+	mutex   sync.Mutex
+	pending []Entry
+	dropped atomic.Int64
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewExporter creates an Exporter for sinks and starts its flush loop
+func NewExporter(config Config, sinks ...Sink) *Exporter {
+	poolConfig := jobs.DefaultConfig()
+	poolConfig.Workers = len(sinks)
+	poolConfig.QueueSize = 64 * len(sinks)
+	poolConfig.MaxAttempts = 5
+
+	exporter := &Exporter{
+		sinks:        sinks,
+		config:       config,
+		pool:         jobs.NewPool(poolConfig),
+		drainTimeout: 10 * time.Second,
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	go exporter.flushLoop()
+	return exporter
+}
+
+// Write buffers one log line
+// zerolog reuses its buffer after Write returns, so the line is copied.
+func (exporter *Exporter) Write(line []byte) (int, error) {
+	entry := Entry{Time: time.Now(), Line: append([]byte(nil), line...)}
+
+	exporter.mutex.Lock()
+	exporter.pending = append(exporter.pending, entry)
+	full := len(exporter.pending) >= exporter.config.BatchSize
+	exporter.mutex.Unlock()
+
+	if full {
+		exporter.flush()
+	}
+	return len(line), nil
+}
+
+// Dropped returns the number of lines dropped because the job pool was full
+func (exporter *Exporter) Dropped() int64 {
+	return exporter.dropped.Load()
+}
+
+// flushLoop sends whatever is buffered every FlushInterval until Close
+func (exporter *Exporter) flushLoop() {
+	defer close(exporter.stopped)
+	ticker := time.NewTicker(exporter.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			exporter.flush()
+		case <-exporter.stop:
+			exporter.flush()
+			return
+		}
+	}
+}
+
+// flush hands the buffered lines to every sink as one batch
+func (exporter *Exporter) flush() {
+	exporter.mutex.Lock()
+	batch := exporter.pending
+	exporter.pending = nil
+	exporter.mutex.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	for _, sink := range exporter.sinks {
+		target := sink
+		err := exporter.pool.Submit("logsink:"+target.Name(), func(ctx context.Context) error {
+			return target.Send(ctx, batch)
+		})
+		if err != nil {
+			// Logging here would write back into the exporter, so the loss is only counted
+			exporter.dropped.Add(int64(len(batch)))
+		}
+	}
+}
+
+// Close flushes buffered lines, waits for pending deliveries, and closes the sinks
+func (exporter *Exporter) Close() error {
+	var closeErr error
+	exporter.closeOnce.Do(func() {
+		close(exporter.stop)
+		<-exporter.stopped
+
+		drainContext, cancelDrain := context.WithTimeout(context.Background(), exporter.drainTimeout)
+		defer cancelDrain()
+		closeErr = exporter.pool.Drain(drainContext)
+
+		for _, sink := range exporter.sinks {
+			if err := sink.Close(); err != nil && closeErr == nil {
+				closeErr = err
+			}
+		}
+	})
+	return closeErr
+}
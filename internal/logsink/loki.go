@@ -0,0 +1,93 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiPushPath is Loki's push API, relative to the configured base URL
+const lokiPushPath = "/loki/api/v1/push"
+
+// lokiPushRequest is the JSON body of a Loki push
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// lokiStream is a set of log lines sharing the same labels
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	// Values are [unix nanoseconds, line] pairs
+	Values [][2]string `json:"values"`
+}
+
+// LokiSink pushes log lines to Grafana Loki as a single stream labelled service=opgl-gateway
+type LokiSink struct {
+	pushURL    string
+	labels     map[string]string
+	httpClient *http.Client
+}
+
+// NewLokiSink creates a LokiSink for the Loki server at baseURL, e.g. http://loki:3100
+// labels are added to service=opgl-gateway; keep them low-cardinality (environment, region).
+func NewLokiSink(baseURL string, labels map[string]string, transport http.RoundTripper) *LokiSink {
+	streamLabels := map[string]string{"service": "opgl-gateway"}
+	for name, value := range labels {
+		streamLabels[name] = value
+	}
+
+	return &LokiSink{
+		pushURL: strings.TrimRight(baseURL, "/") + lokiPushPath,
+		labels:  streamLabels,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+	}
+}
+
+// Name identifies the sink
+func (sink *LokiSink) Name() string {
+	return "loki"
+}
+
+// Send pushes the batch as one stream
+// Non-2xx responses are returned as errors so the job pool retries the batch.
+func (sink *LokiSink) Send(ctx context.Context, batch []Entry) error {
+	stream := lokiStream{Stream: sink.labels, Values: make([][2]string, len(batch))}
+	for i, entry := range batch {
+		stream.Values[i] = [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), string(bytes.TrimRight(entry.Line, "\n"))}
+	}
+
+	payload, err := json.Marshal(lokiPushRequest{Streams: []lokiStream{stream}})
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.pushURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := sink.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		return fmt.Errorf("loki returned status %d", httpResponse.StatusCode)
+	}
+	return nil
+}
+
+// Close is a no-op for LokiSink
+func (sink *LokiSink) Close() error {
+	return nil
+}
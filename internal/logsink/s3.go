@@ -0,0 +1,169 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// S3Credentials are the static AWS credentials used to sign uploads
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+// S3Config locates the bucket that receives log batches
+type S3Config struct {
+	Bucket string
+	Region string
+	// Prefix is prepended to every object key, e.g. "gateway/access"
+	Prefix string
+	// Endpoint overrides https://s3.<region>.amazonaws.com for S3-compatible stores such as MinIO
+	Endpoint string
+}
+
+// S3Sink uploads each batch as a newline-delimited JSON object
+// Objects are named <prefix>/YYYY/MM/DD/<first line time>-<uuid>.jsonl and are addressed
+// path-style, so the bucket name needn't be a valid hostname. Requests are signed with AWS
+// Signature Version 4.
+type S3Sink struct {
+	config      S3Config
+	credentials S3Credentials
+	httpClient  *http.Client
+	now         func() time.Time
+}
+
+// NewS3Sink creates an S3Sink
+func NewS3Sink(config S3Config, credentials S3Credentials, transport http.RoundTripper) *S3Sink {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	return &S3Sink{
+		config:      config,
+		credentials: credentials,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		now: time.Now,
+	}
+}
+
+// Name identifies the sink
+func (sink *S3Sink) Name() string {
+	return "s3"
+}
+
+// Send uploads the batch as one object
+// The object key is chosen per attempt, so a retry after an ambiguous failure can at worst
+// store the batch twice; it never overwrites another batch.
+func (sink *S3Sink) Send(ctx context.Context, batch []Entry) error {
+	var body bytes.Buffer
+	for _, entry := range batch {
+		body.Write(bytes.TrimRight(entry.Line, "\n"))
+		body.WriteByte('\n')
+	}
+
+	firstLineTime := batch[0].Time.UTC()
+	objectKey := firstLineTime.Format("2006/01/02/20060102T150405Z") + "-" + uuid.New().String() + ".jsonl"
+	if sink.config.Prefix != "" {
+		objectKey = sink.config.Prefix + "/" + objectKey
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPut, sink.config.Endpoint+"/"+sink.config.Bucket+"/"+objectKey, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-ndjson")
+	sink.sign(httpRequest, body.Bytes())
+
+	httpResponse, err := sink.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		return fmt.Errorf("s3 returned status %d for %s", httpResponse.StatusCode, objectKey)
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers covering every header already set on the request
+func (sink *S3Sink) sign(request *http.Request, payload []byte) {
+	signedAt := sink.now().UTC()
+	amzDate := signedAt.Format("20060102T150405Z")
+	scopeDate := signedAt.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+	request.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	request.Header.Set("X-Amz-Date", amzDate)
+	if sink.credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", sink.credentials.SessionToken)
+	}
+
+	// Canonical headers: lowercase names, sorted, with host included
+	canonicalHeaders := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		canonicalHeaders[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	headerNames := make([]string, 0, len(canonicalHeaders))
+	for name := range canonicalHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaderLines strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaderLines.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaderLines.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	credentialScope := scopeDate + "/" + sink.config.Region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, credentialScope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+sink.credentials.SecretAccessKey), scopeDate)
+	signingKey = hmacSHA256(signingKey, sink.config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sink.credentials.AccessKeyID, credentialScope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Close is a no-op for S3Sink
+func (sink *S3Sink) Close() error {
+	return nil
+}
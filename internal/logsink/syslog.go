@@ -0,0 +1,128 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// syslogFacility is local0, the usual facility for application logs
+const syslogFacility = 16
+
+// syslogSeverities maps zerolog levels to syslog severities
+var syslogSeverities = map[string]int{
+	"panic": 0,
+	"fatal": 2,
+	"error": 3,
+	"warn":  4,
+	"info":  6,
+	"debug": 7,
+	"trace": 7,
+}
+
+// SyslogSink sends log lines to a syslog server as RFC 5424 messages
+// Over tcp messages are octet-counted (RFC 6587) on one kept-open connection that is
+// redialled after an error; over udp each line is one datagram.
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+	dialer   *net.Dialer
+
+	mutex      sync.Mutex
+	connection net.Conn
+}
+
+// NewSyslogSink creates a SyslogSink for an address like udp://syslog:514 or tcp://syslog:601
+func NewSyslogSink(address string, dialer *net.Dialer) (*SyslogSink, error) {
+	parsedAddress, err := url.Parse(address)
+	if err != nil || (parsedAddress.Scheme != "udp" && parsedAddress.Scheme != "tcp") || parsedAddress.Host == "" {
+		return nil, fmt.Errorf("syslog address %q must be udp://host:port or tcp://host:port", address)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		network:  parsedAddress.Scheme,
+		address:  parsedAddress.Host,
+		hostname: hostname,
+		dialer:   dialer,
+	}, nil
+}
+
+// Name identifies the sink
+func (sink *SyslogSink) Name() string {
+	return "syslog"
+}
+
+// Send writes every line of the batch, dropping the connection on error so the retry redials
+func (sink *SyslogSink) Send(ctx context.Context, batch []Entry) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.connection == nil {
+		connection, err := sink.dialer.DialContext(ctx, sink.network, sink.address)
+		if err != nil {
+			return err
+		}
+		sink.connection = connection
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	sink.connection.SetWriteDeadline(deadline)
+
+	for _, entry := range batch {
+		message := sink.format(entry)
+		if sink.network == "tcp" {
+			message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+		}
+		if _, err := sink.connection.Write(message); err != nil {
+			sink.connection.Close()
+			sink.connection = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// format renders an entry as an RFC 5424 message with the JSON line as its body
+func (sink *SyslogSink) format(entry Entry) []byte {
+	var logLine struct {
+		Level string `json:"level"`
+	}
+	json.Unmarshal(entry.Line, &logLine)
+
+	severity, known := syslogSeverities[logLine.Level]
+	if !known {
+		severity = syslogSeverities["info"]
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s opgl-gateway %d - - ", syslogFacility*8+severity, entry.Time.UTC().Format(time.RFC3339Nano), sink.hostname, os.Getpid())
+	return append([]byte(header), bytes.TrimRight(entry.Line, "\n")...)
+}
+
+// Close closes the connection, if one is open
+func (sink *SyslogSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.connection == nil {
+		return nil
+	}
+	err := sink.connection.Close()
+	sink.connection = nil
+	return err
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types in the Prometheus text exposition format
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+	typeSummary = "summary"
+)
+
+// Registry holds metric families and renders them in the Prometheus text format
+type Registry struct {
+	mutex    sync.Mutex
+	families []*family
+}
+
+// family is a named metric with one value per label combination
+type family struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+	series     map[string]*series
+}
+
+// series is a single labelled value of a metric family
+// For summaries value holds the sum of observations and count their number.
+type series struct {
+	labelValues []string
+	value       float64
+	count       uint64
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter is a monotonically increasing metric
+type Counter struct {
+	registry *Registry
+	family   *family
+}
+
+// Gauge is a metric that can be set to any value
+type Gauge struct {
+	registry *Registry
+	family   *family
+}
+
+// Summary tracks the sum and count of observations (e.g. durations in seconds)
+type Summary struct {
+	registry *Registry
+	family   *family
+}
+
+// NewCounter registers a counter with the given label names
+func (registry *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{registry: registry, family: registry.register(name, help, typeCounter, labelNames)}
+}
+
+// NewGauge registers a gauge with the given label names
+func (registry *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{registry: registry, family: registry.register(name, help, typeGauge, labelNames)}
+}
+
+// NewSummary registers a summary with the given label names
+func (registry *Registry) NewSummary(name string, help string, labelNames ...string) *Summary {
+	return &Summary{registry: registry, family: registry.register(name, help, typeSummary, labelNames)}
+}
+
+// Inc adds one to the counter for the given label values
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add adds delta to the counter for the given label values (negative deltas are ignored)
+func (counter *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+
+	counter.registry.mutex.Lock()
+	defer counter.registry.mutex.Unlock()
+
+	counter.family.seriesFor(labelValues).value += delta
+}
+
+// Set sets the gauge for the given label values
+func (gauge *Gauge) Set(value float64, labelValues ...string) {
+	gauge.registry.mutex.Lock()
+	defer gauge.registry.mutex.Unlock()
+
+	gauge.family.seriesFor(labelValues).value = value
+}
+
+// Add adds delta to the gauge for the given label values
+func (gauge *Gauge) Add(delta float64, labelValues ...string) {
+	gauge.registry.mutex.Lock()
+	defer gauge.registry.mutex.Unlock()
+
+	gauge.family.seriesFor(labelValues).value += delta
+}
+
+// Observe records a single observation for the given label values
+func (summary *Summary) Observe(value float64, labelValues ...string) {
+	summary.registry.mutex.Lock()
+	defer summary.registry.mutex.Unlock()
+
+	observedSeries := summary.family.seriesFor(labelValues)
+	observedSeries.value += value
+	observedSeries.count++
+}
+
+// register adds a new metric family to the registry
+func (registry *Registry) register(name string, help string, metricType string, labelNames []string) *family {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	newFamily := &family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+	registry.families = append(registry.families, newFamily)
+	return newFamily
+}
+
+// seriesFor returns the series for the label values, creating it on first use (caller must hold the lock)
+func (metricFamily *family) seriesFor(labelValues []string) *series {
+	seriesKey := strings.Join(labelValues, "\xff")
+	existingSeries, exists := metricFamily.series[seriesKey]
+	if !exists {
+		existingSeries = &series{labelValues: append([]string(nil), labelValues...)}
+		metricFamily.series[seriesKey] = existingSeries
+	}
+	return existingSeries
+}
+
+// Handler returns an http.Handler that serves all metrics in the Prometheus text format
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writer.Write([]byte(registry.render()))
+	})
+}
+
+// render formats every metric family in the Prometheus text format
+func (registry *Registry) render() string {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	var builder strings.Builder
+	for _, metricFamily := range registry.families {
+		builder.WriteString("# HELP " + metricFamily.name + " " + metricFamily.help + "\n")
+		builder.WriteString("# TYPE " + metricFamily.name + " " + metricFamily.metricType + "\n")
+
+		// Sort series so output is stable between scrapes
+		seriesKeys := make([]string, 0, len(metricFamily.series))
+		for seriesKey := range metricFamily.series {
+			seriesKeys = append(seriesKeys, seriesKey)
+		}
+		sort.Strings(seriesKeys)
+
+		for _, seriesKey := range seriesKeys {
+			metricSeries := metricFamily.series[seriesKey]
+			labels := formatLabels(metricFamily.labelNames, metricSeries.labelValues)
+
+			// Summaries are exposed as <name>_sum and <name>_count
+			if metricFamily.metricType == typeSummary {
+				builder.WriteString(metricFamily.name + "_sum" + labels + " " + strconv.FormatFloat(metricSeries.value, 'g', -1, 64) + "\n")
+				builder.WriteString(metricFamily.name + "_count" + labels + " " + strconv.FormatUint(metricSeries.count, 10) + "\n")
+				continue
+			}
+
+			builder.WriteString(metricFamily.name + labels + " " + strconv.FormatFloat(metricSeries.value, 'g', -1, 64) + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// formatLabels renders {name="value",...} with Prometheus escaping
+func formatLabels(labelNames []string, labelValues []string) string {
+	if len(labelNames) == 0 {
+		return ""
+	}
+
+	labelEscaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(labelNames))
+	for index, labelName := range labelNames {
+		labelValue := ""
+		if index < len(labelValues) {
+			labelValue = labelValues[index]
+		}
+		pairs = append(pairs, labelName+`="`+labelEscaper.Replace(labelValue)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegistry_RendersCountersAndGauges tests the Prometheus text output
+func TestRegistry_RendersCountersAndGauges(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("test_requests_total", "Requests served.", "route")
+	objective := registry.NewGauge("test_objective", "Objective.")
+
+	requests.Inc("/b")
+	requests.Inc("/a")
+	requests.Add(2, "/a")
+	objective.Set(0.995)
+
+	output := registry.render()
+
+	expectedLines := []string{
+		"# HELP test_requests_total Requests served.",
+		"# TYPE test_requests_total counter",
+		`test_requests_total{route="/a"} 3` + "\n" + `test_requests_total{route="/b"} 1`,
+		"# TYPE test_objective gauge",
+		"test_objective 0.995",
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(output, expectedLine) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expectedLine, output)
+		}
+	}
+}
+
+// TestCounter_IgnoresNegativeDelta tests that counters never decrease
+func TestCounter_IgnoresNegativeDelta(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_total", "Test.")
+
+	counter.Add(5)
+	counter.Add(-3)
+
+	if !strings.Contains(registry.render(), "test_total 5") {
+		t.Errorf("Expected counter to stay at 5, got:\n%s", registry.render())
+	}
+}
+
+// TestFormatLabels_EscapesValues tests that label values are escaped
+func TestFormatLabels_EscapesValues(t *testing.T) {
+	formatted := formatLabels([]string{"path"}, []string{"a\"b\\c\nd"})
+
+	expected := `{path="a\"b\\c\nd"}`
+	if formatted != expected {
+		t.Errorf("Expected %s, got %s", expected, formatted)
+	}
+}
+
+// TestRegistry_Handler tests the content type of the metrics endpoint
+func TestRegistry_Handler(t *testing.T) {
+	registry := NewRegistry()
+	recorder := httptest.NewRecorder()
+
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	contentType := recorder.Header().Get("Content-Type")
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Expected text/plain content type, got '%s'", contentType)
+	}
+}
+
+// TestSummary_RendersSumAndCount tests that summaries expose _sum and _count series
+func TestSummary_RendersSumAndCount(t *testing.T) {
+	registry := NewRegistry()
+	latency := registry.NewSummary("test_duration_seconds", "Duration.", "host")
+
+	latency.Observe(0.25, "data:8081")
+	latency.Observe(0.5, "data:8081")
+
+	output := registry.render()
+
+	expectedLines := []string{
+		"# TYPE test_duration_seconds summary",
+		`test_duration_seconds_sum{host="data:8081"} 0.75`,
+		`test_duration_seconds_count{host="data:8081"} 2`,
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(output, expectedLine) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expectedLine, output)
+		}
+	}
+}
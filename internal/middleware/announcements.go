@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+)
+
+// AnnouncementMiddleware creates middleware that adds the most severe active header
+// announcement to every response in the X-OPGL-Announcement header
+func AnnouncementMiddleware(board *announcements.Board) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if headerValue := board.HeaderValue(); headerValue != "" {
+				writer.Header().Set(announcements.Header, headerValue)
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/anomaly"
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/gorilla/mux"
+)
+
+// AnomalyMiddleware creates middleware that feeds accepted API key traffic to the anomaly detector
+// Keys the detector has automatically suspended are rejected with KEY_SUSPENDED until the
+// suspension ends. Requests without an accepted API key pass through, so the middleware
+// must run after rate limiting.
+func AnomalyMiddleware(detector *anomaly.Detector) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			consumer := identity.APIKeyID(request.Context())
+			if consumer == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if suspendedUntil, suspended := detector.Suspended(consumer); suspended {
+				retryAfter := int64(time.Until(suspendedUntil).Seconds()) + 1
+				writer.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				apierrors.WriteError(writer, apierrors.KeySuspended(suspendedUntil))
+				return
+			}
+
+			wrappedWriter := newResponseWriter(writer)
+			next.ServeHTTP(wrappedWriter, request)
+
+			route := request.URL.Path
+			if currentRoute := mux.CurrentRoute(request); currentRoute != nil {
+				if pathTemplate, err := currentRoute.GetPathTemplate(); err == nil {
+					route = pathTemplate
+				}
+			}
+			detector.Observe(consumer, route, wrappedWriter.statusCode)
+		})
+	}
+}
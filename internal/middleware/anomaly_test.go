@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/anomaly"
+)
+
+// TestAnomalyMiddleware tests that traffic passes through until the detector suspends the key
+func TestAnomalyMiddleware(t *testing.T) {
+	detector := anomaly.NewDetector(anomaly.Config{Window: time.Hour, SuspendSeverity: 1, SuspendDuration: time.Minute}, nil)
+	handler := AnomalyMiddleware(detector)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, withConsumer(httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil), "test-key"))
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", responseRecorder.Code)
+	}
+
+	// Anonymous requests are never observed or suspended
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil))
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected anonymous request to pass, got %d", responseRecorder.Code)
+	}
+	if _, suspended := detector.Suspended(""); suspended {
+		t.Error("Expected no state for anonymous requests")
+	}
+}
+
+// TestAnomalyMiddleware_Suspended tests the KEY_SUSPENDED response
+func TestAnomalyMiddleware_Suspended(t *testing.T) {
+	detector := anomaly.NewDetector(anomaly.Config{Window: 50 * time.Millisecond, SuspendSeverity: 1, SuspendDuration: time.Hour}, nil)
+	handler := AnomalyMiddleware(detector)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	// Warm up on successful lookups, then fail every request on a new endpoint until suspended
+	send := func(path string) *httptest.ResponseRecorder {
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, withConsumer(httptest.NewRequest(http.MethodPost, path, nil), "test-key"))
+		return responseRecorder
+	}
+	for window := 0; window < 10; window++ {
+		for i := 0; i < 20; i++ {
+			detector.Observe(apiKeyFingerprint("test-key"), "/api/v1/summoner", http.StatusOK)
+		}
+		time.Sleep(60 * time.Millisecond)
+	}
+	for i := 0; i < 200; i++ {
+		send("/api/v1/analyze")
+	}
+	time.Sleep(60 * time.Millisecond)
+	send("/api/v1/analyze")
+
+	responseRecorder := send("/api/v1/analyze")
+	if responseRecorder.Code != http.StatusForbidden || !strings.Contains(responseRecorder.Body.String(), "KEY_SUSPENDED") {
+		t.Fatalf("Expected 403 KEY_SUSPENDED, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+	if responseRecorder.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
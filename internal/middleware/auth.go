@@ -9,6 +9,7 @@ import (
 	"time"
 
 	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
 	"github.com/google/uuid"
 )
 
@@ -18,12 +19,14 @@ type AuthServiceClient struct {
 	httpClient *http.Client
 }
 
-// NewAuthServiceClient creates a new auth service client
-func NewAuthServiceClient(baseURL string) *AuthServiceClient {
+// NewAuthServiceClient creates a new auth service client sending requests through transport
+// (nil uses http.DefaultTransport)
+func NewAuthServiceClient(baseURL string, transport http.RoundTripper) *AuthServiceClient {
 	return &AuthServiceClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
+			Transport: transport,
+			Timeout:   5 * time.Second,
 		},
 	}
 }
@@ -35,13 +38,14 @@ type validateTokenRequest struct {
 
 // validateTokenResponse represents the response from token validation
 type validateTokenResponse struct {
-	Valid  bool   `json:"valid"`
-	UserID string `json:"userId,omitempty"`
-	Email  string `json:"email,omitempty"`
+	Valid  bool     `json:"valid"`
+	UserID string   `json:"userId,omitempty"`
+	Email  string   `json:"email,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
 }
 
 // ValidateToken calls the auth service to validate a token
-func (client *AuthServiceClient) ValidateToken(token string) (*validateTokenResponse, error) {
+func (client *AuthServiceClient) ValidateToken(ctx context.Context, token string) (*validateTokenResponse, error) {
 	requestBody := validateTokenRequest{Token: token}
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
@@ -49,7 +53,13 @@ func (client *AuthServiceClient) ValidateToken(token string) (*validateTokenResp
 	}
 
 	url := client.baseURL + "/api/v1/auth/validate"
-	resp, err := client.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.httpClient.Do(httpRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -64,36 +74,38 @@ func (client *AuthServiceClient) ValidateToken(token string) (*validateTokenResp
 }
 
 // AuthMiddleware creates middleware that validates JWT access tokens via auth service
+// The token comes from a Bearer Authorization header or, without one, the session cookie.
 func AuthMiddleware(authClient *AuthServiceClient) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-			// Extract Authorization header
+			// Extract the token from the Authorization header, or the session cookie without one
 			authHeader := request.Header.Get("Authorization")
+			tokenString := sessionCookieToken(request)
 
-			if authHeader == "" {
+			if authHeader == "" && tokenString == "" {
 				apierrors.WriteError(responseWriter, apierrors.NewAPIError(
 					apierrors.ErrCodeUnauthorized,
-					"Authorization header is required",
+					"Authorization header or session cookie is required",
 					http.StatusUnauthorized,
 				))
 				return
 			}
 
-			// Check Bearer token format
-			if !strings.HasPrefix(authHeader, "Bearer ") {
-				apierrors.WriteError(responseWriter, apierrors.NewAPIError(
-					apierrors.ErrCodeUnauthorized,
-					"Invalid authorization format. Use: Bearer <token>",
-					http.StatusUnauthorized,
-				))
-				return
+			if authHeader != "" {
+				// Check Bearer token format
+				if !strings.HasPrefix(authHeader, "Bearer ") {
+					apierrors.WriteError(responseWriter, apierrors.NewAPIError(
+						apierrors.ErrCodeUnauthorized,
+						"Invalid authorization format. Use: Bearer <token>",
+						http.StatusUnauthorized,
+					))
+					return
+				}
+				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
 			}
 
-			// Extract token
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
 			// Validate token via auth service
-			validationResult, err := authClient.ValidateToken(tokenString)
+			validationResult, err := authClient.ValidateToken(request.Context(), tokenString)
 			if err != nil {
 				apierrors.WriteError(responseWriter, apierrors.InternalError("Failed to validate token"))
 				return
@@ -115,8 +127,8 @@ func AuthMiddleware(authClient *AuthServiceClient) func(http.Handler) http.Handl
 				return
 			}
 
-			// Add user ID to request context
-			ctx := context.WithValue(request.Context(), "userID", userID)
+			// Add the user's identity to the request context
+			ctx := identity.WithRoles(identity.WithUserID(request.Context(), userID), validationResult.Roles)
 			request = request.WithContext(ctx)
 
 			// Proceed to next handler
@@ -130,24 +142,24 @@ func AuthMiddleware(authClient *AuthServiceClient) func(http.Handler) http.Handl
 func OptionalAuthMiddleware(authClient *AuthServiceClient) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-			// Extract Authorization header
+			// Extract the token from the Authorization header, or the session cookie without one
 			authHeader := request.Header.Get("Authorization")
-
-			// If no auth header, proceed without user context
-			if authHeader == "" {
-				next.ServeHTTP(responseWriter, request)
-				return
+			tokenString := sessionCookieToken(request)
+			if authHeader != "" {
+				tokenString = ""
+				if strings.HasPrefix(authHeader, "Bearer ") {
+					tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+				}
 			}
 
-			// Check Bearer token format
-			if !strings.HasPrefix(authHeader, "Bearer ") {
+			// If no usable token, proceed without user context
+			if tokenString == "" {
 				next.ServeHTTP(responseWriter, request)
 				return
 			}
 
-			// Extract and validate token
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-			validationResult, err := authClient.ValidateToken(tokenString)
+			// Validate token
+			validationResult, err := authClient.ValidateToken(request.Context(), tokenString)
 			if err != nil || !validationResult.Valid {
 				// Token invalid, proceed without user context
 				next.ServeHTTP(responseWriter, request)
@@ -161,11 +173,20 @@ func OptionalAuthMiddleware(authClient *AuthServiceClient) func(http.Handler) ht
 				return
 			}
 
-			// Add user ID to request context
-			ctx := context.WithValue(request.Context(), "userID", userID)
+			// Add the user's identity to the request context
+			ctx := identity.WithRoles(identity.WithUserID(request.Context(), userID), validationResult.Roles)
 			request = request.WithContext(ctx)
 
 			next.ServeHTTP(responseWriter, request)
 		})
 	}
 }
+
+// sessionCookieToken returns the access token from the session cookie, or "" without one
+func sessionCookieToken(request *http.Request) string {
+	cookie, err := request.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
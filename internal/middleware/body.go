@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// maxBufferedBodyBytes bounds the request bodies middleware reads into memory
+// It matches the proxy's limit for transformed declared-route bodies; built-in handlers
+// apply their own, smaller limit afterwards.
+const maxBufferedBodyBytes = 1 << 20
+
+// bufferRequestBody reads the request body and restores it for the next handler
+// Unreadable and oversized bodies are rejected with INVALID_REQUEST_BODY (400) and false is returned.
+func bufferRequestBody(writer http.ResponseWriter, request *http.Request) ([]byte, bool) {
+	requestBody, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxBufferedBodyBytes))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			apierrors.WriteError(writer, apierrors.InvalidRequestBody(fmt.Sprintf("Request body must be at most %d bytes", maxBufferedBodyBytes)))
+			return nil, false
+		}
+		apierrors.WriteError(writer, apierrors.InvalidRequestBody("Unable to read request body"))
+		return nil, false
+	}
+	request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	return requestBody, true
+}
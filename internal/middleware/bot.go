@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// ChallengeHeader carries a challenge token (e.g. a Turnstile or hCaptcha response) from the client
+const ChallengeHeader = "X-OPGL-Challenge"
+
+// suspiciousScore is the header-fingerprint score at which an anonymous request counts as automated
+const suspiciousScore = 3
+
+// ChallengeVerifier checks challenge tokens with a CAPTCHA-style provider
+type ChallengeVerifier interface {
+	Verify(ctx context.Context, token string, remoteIP string) (bool, error)
+}
+
+// BotDetector holds bot and scraper mitigation policy for anonymous requests
+type BotDetector struct {
+	// blockedUserAgents are lowercase user-agent substrings that are always rejected
+	blockedUserAgents []string
+	// tarpit delays suspicious requests when no challenge verifier is configured
+	tarpit time.Duration
+	// challenge lets suspicious clients prove they are human instead of being slowed down (optional)
+	challenge ChallengeVerifier
+}
+
+// NewBotDetector creates a new BotDetector
+// challenge may be nil, in which case suspicious requests are delayed by tarpit instead.
+func NewBotDetector(blockedUserAgents []string, tarpit time.Duration, challenge ChallengeVerifier) *BotDetector {
+	detector := &BotDetector{tarpit: tarpit, challenge: challenge}
+	for _, userAgent := range blockedUserAgents {
+		userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+		if userAgent != "" {
+			detector.blockedUserAgents = append(detector.blockedUserAgents, userAgent)
+		}
+	}
+	return detector
+}
+
+// blocked reports whether the user agent matches a blocked substring
+func (detector *BotDetector) blocked(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, blockedUserAgent := range detector.blockedUserAgents {
+		if strings.Contains(userAgent, blockedUserAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprintScore scores headers that browsers always send but simple scripts usually omit
+func fingerprintScore(header http.Header) int {
+	score := 0
+	if header.Get("User-Agent") == "" {
+		score += 2
+	}
+	if header.Get("Accept") == "" {
+		score++
+	}
+	if header.Get("Accept-Language") == "" {
+		score++
+	}
+	if header.Get("Accept-Encoding") == "" {
+		score++
+	}
+	return score
+}
+
+// BotMitigationMiddleware creates middleware that slows or rejects likely scrapers on public routes
+// Only anonymous requests are inspected; requests with an accepted API key are already
+// accountable to their key's rate limit. Blocked user agents get BOT_DETECTED (403).
+// Requests whose header fingerprint looks automated must pass a challenge when a verifier is
+// configured (CHALLENGE_REQUIRED, 403) and are otherwise delayed by the tarpit. The client IP
+// sent to the challenge provider is resolved like the per-IP limit's, trusting X-Forwarded-For
+// only from trustedProxies.
+func BotMitigationMiddleware(detector *BotDetector, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if identity.APIKeyID(request.Context()) != "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if detector.blocked(request.Header.Get("User-Agent")) {
+				apierrors.WriteError(writer, apierrors.BotDetected())
+				return
+			}
+
+			if fingerprintScore(request.Header) < suspiciousScore {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if detector.challenge != nil {
+				token := request.Header.Get(ChallengeHeader)
+				if token == "" {
+					apierrors.WriteError(writer, apierrors.ChallengeRequired("Complete the challenge and send its token in the X-OPGL-Challenge header, or use an API key"))
+					return
+				}
+				remoteIP := clientIP(request)
+				if address, ok := forwardedClientAddress(request, trustedProxies); ok {
+					remoteIP = address.String()
+				}
+				passed, err := detector.challenge.Verify(request.Context(), token, remoteIP)
+				if err != nil {
+					apierrors.WriteError(writer, apierrors.InternalError("Challenge verification failed"))
+					return
+				}
+				if !passed {
+					apierrors.WriteError(writer, apierrors.ChallengeRequired("Challenge token is invalid or expired"))
+					return
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			// Without a challenge provider, slow the client down instead of serving it at full speed
+			select {
+			case <-time.After(detector.tarpit):
+			case <-request.Context().Done():
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// SiteverifyChallenge verifies tokens against a siteverify endpoint
+// Cloudflare Turnstile, hCaptcha and reCAPTCHA all accept a form-encoded secret, response
+// and remoteip and answer with {"success": true|false}.
+type SiteverifyChallenge struct {
+	verifyURL  string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewSiteverifyChallenge creates a new SiteverifyChallenge sending requests through transport
+// (nil uses http.DefaultTransport)
+func NewSiteverifyChallenge(verifyURL string, secret string, transport http.RoundTripper) *SiteverifyChallenge {
+	return &SiteverifyChallenge{
+		verifyURL: verifyURL,
+		secret:    secret,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   5 * time.Second,
+		},
+	}
+}
+
+// siteverifyResponse is the part of the siteverify response the gateway reads
+type siteverifyResponse struct {
+	Success bool `json:"success"`
+}
+
+// Verify checks a challenge token with the provider
+func (challenge *SiteverifyChallenge) Verify(ctx context.Context, token string, remoteIP string) (bool, error) {
+	form := url.Values{
+		"secret":   {challenge.secret},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, challenge.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := challenge.httpClient.Do(httpRequest)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var response siteverifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return false, err
+	}
+	return response.Success, nil
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubChallenge accepts a single valid token and records the client IP it was sent
+type stubChallenge struct {
+	validToken string
+	remoteIP   string
+}
+
+func (challenge *stubChallenge) Verify(ctx context.Context, token string, remoteIP string) (bool, error) {
+	challenge.remoteIP = remoteIP
+	return token == challenge.validToken, nil
+}
+
+// browserRequest builds an anonymous request with the headers a browser sends
+func browserRequest() *http.Request {
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/champions", nil)
+	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Accept-Language", "en-US")
+	request.Header.Set("Accept-Encoding", "gzip")
+	return request
+}
+
+// TestBotMitigationMiddleware tests user-agent blocking, challenges and API key exemption
+func TestBotMitigationMiddleware(t *testing.T) {
+	detector := NewBotDetector([]string{"Scrapy"}, time.Second, &stubChallenge{validToken: "passed"})
+	handler := BotMitigationMiddleware(detector, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	scriptRequest := func() *http.Request {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/champions", nil)
+		request.Header.Set("User-Agent", "curl/8.0")
+		return request
+	}
+	scrapyRequest := browserRequest()
+	scrapyRequest.Header.Set("User-Agent", "Scrapy/2.11 (+https://scrapy.org)")
+	challengedRequest := scriptRequest()
+	challengedRequest.Header.Set(ChallengeHeader, "passed")
+	wrongTokenRequest := scriptRequest()
+	wrongTokenRequest.Header.Set(ChallengeHeader, "forged")
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"browser", browserRequest(), http.StatusOK, ""},
+		{"blocked user agent", scrapyRequest, http.StatusForbidden, "BOT_DETECTED"},
+		{"script without challenge", scriptRequest(), http.StatusForbidden, "CHALLENGE_REQUIRED"},
+		{"script with valid challenge", challengedRequest, http.StatusOK, ""},
+		{"script with invalid challenge", wrongTokenRequest, http.StatusForbidden, "CHALLENGE_REQUIRED"},
+		{"script with API key", withConsumer(scriptRequest(), "test-key"), http.StatusOK, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, testCase.request)
+
+			if responseRecorder.Code != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got %d", testCase.expectedStatus, responseRecorder.Code)
+			}
+			if !strings.Contains(responseRecorder.Body.String(), testCase.expectedCode) {
+				t.Errorf("Expected code %s, got '%s'", testCase.expectedCode, responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+// TestBotMitigationMiddleware_ChallengeRemoteIP tests that the provider gets the client IP forwarded by a trusted proxy
+func TestBotMitigationMiddleware_ChallengeRemoteIP(t *testing.T) {
+	challenge := &stubChallenge{validToken: "passed"}
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	handler := BotMitigationMiddleware(NewBotDetector(nil, time.Second, challenge), trustedProxies)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/champions", nil)
+	request.RemoteAddr = "10.0.0.5:4000"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	request.Header.Set(ChallengeHeader, "passed")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if challenge.remoteIP != "203.0.113.7" {
+		t.Errorf("Expected remote IP 203.0.113.7, got '%s'", challenge.remoteIP)
+	}
+}
+
+// TestBotMitigationMiddleware_Tarpit tests that suspicious requests are delayed without a challenge provider
+func TestBotMitigationMiddleware_Tarpit(t *testing.T) {
+	detector := NewBotDetector(nil, 50*time.Millisecond, nil)
+	handler := BotMitigationMiddleware(detector, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	startTime := time.Now()
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/api/v1/champions", nil))
+	if responseRecorder.Code != http.StatusOK || time.Since(startTime) < 50*time.Millisecond {
+		t.Errorf("Expected a delayed 200, got %d after %s", responseRecorder.Code, time.Since(startTime))
+	}
+
+	startTime = time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), browserRequest())
+	if time.Since(startTime) >= 50*time.Millisecond {
+		t.Errorf("Expected browser requests not to be delayed, took %s", time.Since(startTime))
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+)
+
+// maxResponseCacheEntries bounds the memory a single response cache can hold
+const maxResponseCacheEntries = 10000
+
+// cachedResponse is a stored successful response
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// ResponseCache keeps successful responses of a route for a TTL
+type ResponseCache struct {
+	ttl       time.Duration
+	mutex     sync.Mutex
+	entries   map[string]*cachedResponse
+	lastSweep time.Time
+	// origin serves cache misses; set when the cache is wrapped around a handler
+	origin http.Handler
+}
+
+// NewResponseCache creates a new in-memory response cache
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:       ttl,
+		entries:   make(map[string]*cachedResponse),
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the unexpired response stored under cacheKey
+func (cache *ResponseCache) get(cacheKey string) (*cachedResponse, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, exists := cache.entries[cacheKey]
+	if !exists || !time.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// put stores a response, skipping it when the cache is full of unexpired entries
+func (cache *ResponseCache) put(cacheKey string, contentType string, body []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(cache.lastSweep) >= time.Minute || len(cache.entries) >= maxResponseCacheEntries {
+		cache.lastSweep = now
+		for key, entry := range cache.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(cache.entries, key)
+			}
+		}
+	}
+	if len(cache.entries) >= maxResponseCacheEntries {
+		return
+	}
+
+	cache.entries[cacheKey] = &cachedResponse{
+		contentType: contentType,
+		body:        body,
+		expiresAt:   now.Add(cache.ttl),
+	}
+}
+
+// responseCacheKey identifies a request by method, path, query and body, and by what else can change
+// the response: the tenant owning the request's host (its default region applies) and the request's
+// experiment assignments (sent upstream). All hosts without a tenant are the gateway's own and share entries.
+func responseCacheKey(request *http.Request, requestBody []byte) string {
+	bodyHash := sha256.Sum256(requestBody)
+	cacheKey := request.Method + " " + request.URL.RequestURI() + " " + hex.EncodeToString(bodyHash[:])
+	if tenant := tenants.FromContext(request.Context()); tenant != nil {
+		cacheKey += " tenant=" + tenant.ID
+	}
+	if assignments := experiments.FromContext(request.Context()); len(assignments) > 0 {
+		cacheKey += " experiments=" + experiments.FormatHeader(assignments)
+	}
+	return cacheKey
+}
+
+// CacheMiddleware creates middleware that serves repeated requests from cache
+// Requests are keyed by method, path, query, body, tenant and experiment assignments; only 200 responses are stored.
+// Responses carry X-Cache: HIT or MISS. Place it after authentication so only
+// accepted requests can read cached data.
+func CacheMiddleware(cache *ResponseCache) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		// Warm-up requests are served by the same handler as cache misses
+		cache.origin = next
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			requestBody, ok := bufferRequestBody(responseWriter, request)
+			if !ok {
+				return
+			}
+
+			cacheKey := responseCacheKey(request, requestBody)
+
+			if entry, found := cache.get(cacheKey); found {
+				responseWriter.Header().Set("Content-Type", entry.contentType)
+				responseWriter.Header().Set("X-Cache", "HIT")
+				responseWriter.Write(entry.body)
+				return
+			}
+
+			responseWriter.Header().Set("X-Cache", "MISS")
+			wrappedWriter := &recordingWriter{ResponseWriter: responseWriter, statusCode: http.StatusOK}
+			next.ServeHTTP(wrappedWriter, request)
+
+			if wrappedWriter.statusCode == http.StatusOK {
+				cache.put(cacheKey, wrappedWriter.Header().Get("Content-Type"), wrappedWriter.body.Bytes())
+			}
+		})
+	}
+}
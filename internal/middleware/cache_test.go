@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+)
+
+// TestCacheMiddleware tests that identical requests are served from cache and errors aren't cached
+func TestCacheMiddleware(t *testing.T) {
+	upstreamCalls := 0
+	statusCode := http.StatusOK
+	handler := CacheMiddleware(NewResponseCache(time.Minute))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		upstreamCalls++
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(statusCode)
+		writer.Write([]byte(`{"tier":"GOLD"}`))
+	}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/ranked", bytes.NewBufferString(body))
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	if responseRecorder := send(`{"puuid":"a"}`); responseRecorder.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected first request to miss, got '%s'", responseRecorder.Header().Get("X-Cache"))
+	}
+
+	responseRecorder := send(`{"puuid":"a"}`)
+	if responseRecorder.Header().Get("X-Cache") != "HIT" || responseRecorder.Body.String() != `{"tier":"GOLD"}` {
+		t.Errorf("Expected cached response, got '%s' (%s)", responseRecorder.Body.String(), responseRecorder.Header().Get("X-Cache"))
+	}
+	if upstreamCalls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", upstreamCalls)
+	}
+
+	// A different body is a different cache entry, and failures are never stored
+	statusCode = http.StatusBadGateway
+	send(`{"puuid":"b"}`)
+	send(`{"puuid":"b"}`)
+	if upstreamCalls != 3 {
+		t.Errorf("Expected failed responses not to be cached, got %d upstream calls", upstreamCalls)
+	}
+
+	// Bodies are buffered to build the key, so oversized ones are rejected before the handler
+	if responseRecorder := send(strings.Repeat("a", maxBufferedBodyBytes+1)); responseRecorder.Code != http.StatusBadRequest || upstreamCalls != 3 {
+		t.Errorf("Expected an oversized body to be rejected with status %d, got %d", http.StatusBadRequest, responseRecorder.Code)
+	}
+}
+
+// TestResponseCacheKey tests that tenants and experiment variants get their own entries
+func TestResponseCacheKey(t *testing.T) {
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/api/v1/ranked", nil)
+	}
+	body := []byte(`{"puuid":"a"}`)
+
+	baseKey := responseCacheKey(newRequest(), body)
+	otherHostRequest := newRequest()
+	otherHostRequest.Host = "gateway.internal"
+	if responseCacheKey(otherHostRequest, body) != baseKey {
+		t.Error("Expected hosts without a tenant to share entries")
+	}
+
+	tenantRequest := newRequest()
+	tenantRequest = tenantRequest.WithContext(tenants.WithTenant(tenantRequest.Context(), &tenants.Tenant{ID: "acme"}))
+	variantRequest := newRequest()
+	variantRequest = variantRequest.WithContext(experiments.WithAssignments(variantRequest.Context(), []experiments.Assignment{{Experiment: "model", Variant: "b"}}))
+
+	keys := map[string]bool{baseKey: true, responseCacheKey(tenantRequest, body): true, responseCacheKey(variantRequest, body): true}
+	if len(keys) != 3 {
+		t.Errorf("Expected tenant and experiment variant requests to have their own keys, got %v", keys)
+	}
+}
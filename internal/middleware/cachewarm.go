@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WarmRequest is a request replayed to keep a route's response cache filled
+type WarmRequest struct {
+	Method string
+	// URI is the gateway path and query, e.g. /api/v1/leaderboard?queue=solo
+	URI  string
+	Body string
+}
+
+// warmTarget is a response cache and the requests that keep it warm
+type warmTarget struct {
+	cache    *ResponseCache
+	requests []WarmRequest
+}
+
+// CacheWarmer pre-fills route response caches on startup and refreshes them before they expire
+// so the first callers after a deploy don't all miss at once and stampede the upstream.
+type CacheWarmer struct {
+	mutex   sync.Mutex
+	targets []warmTarget
+}
+
+// NewCacheWarmer creates an empty CacheWarmer
+func NewCacheWarmer() *CacheWarmer {
+	return &CacheWarmer{}
+}
+
+// Add registers requests to replay into cache
+// cache must be wrapped by CacheMiddleware before Run is called.
+func (warmer *CacheWarmer) Add(cache *ResponseCache, requests []WarmRequest) {
+	warmer.mutex.Lock()
+	defer warmer.mutex.Unlock()
+	warmer.targets = append(warmer.targets, warmTarget{cache: cache, requests: requests})
+}
+
+// Run warms every registered cache now and again each time 90% of its TTL has passed, until ctx is done
+// It returns immediately; warming happens in the background.
+func (warmer *CacheWarmer) Run(ctx context.Context) {
+	warmer.mutex.Lock()
+	defer warmer.mutex.Unlock()
+
+	for _, target := range warmer.targets {
+		go func(target warmTarget) {
+			ticker := time.NewTicker(target.cache.ttl * 9 / 10)
+			defer ticker.Stop()
+
+			for {
+				for _, request := range target.requests {
+					target.cache.warm(ctx, request)
+				}
+
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(target)
+	}
+}
+
+// warm sends a warm-up request to the cache's origin and stores a 200 response
+func (cache *ResponseCache) warm(ctx context.Context, warmRequest WarmRequest) {
+	if cache.origin == nil || ctx.Err() != nil {
+		return
+	}
+
+	request, err := http.NewRequestWithContext(ctx, warmRequest.Method, warmRequest.URI, strings.NewReader(warmRequest.Body))
+	if err != nil {
+		log.Warn().Err(err).Str("uri", warmRequest.URI).Msg("Invalid cache warm-up request")
+		return
+	}
+	if warmRequest.Body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	writer := &recordingWriter{ResponseWriter: &discardWriter{header: make(http.Header)}, statusCode: http.StatusOK}
+	cache.origin.ServeHTTP(writer, request)
+	if writer.statusCode != http.StatusOK {
+		log.Warn().
+			Str("method", warmRequest.Method).
+			Str("uri", warmRequest.URI).
+			Int("status", writer.statusCode).
+			Msg("Cache warm-up request failed")
+		return
+	}
+
+	cache.put(responseCacheKey(request, []byte(warmRequest.Body)), writer.Header().Get("Content-Type"), writer.body.Bytes())
+}
+
+// discardWriter is a ResponseWriter that keeps headers and drops everything else
+type discardWriter struct {
+	header http.Header
+}
+
+// Header returns the response headers
+func (writer *discardWriter) Header() http.Header {
+	return writer.header
+}
+
+// Write discards the body
+func (writer *discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader discards the status code
+func (writer *discardWriter) WriteHeader(statusCode int) {}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCacheWarmer_Run tests that warmed responses are served to the first client as hits
+func TestCacheWarmer_Run(t *testing.T) {
+	var originCalls atomic.Int32
+	cache := NewResponseCache(time.Minute)
+	handler := CacheMiddleware(cache)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		originCalls.Add(1)
+		writer.Header().Set("Content-Type", "application/json")
+		if request.URL.Query().Get("queue") != "solo" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Write([]byte(`{"leaders":["Newyenn"]}`))
+	}))
+
+	warmer := NewCacheWarmer()
+	warmer.Add(cache, []WarmRequest{
+		{Method: http.MethodPost, URI: "/api/v1/leaderboard?queue=solo", Body: `{"region":"na"}`},
+		{Method: http.MethodPost, URI: "/api/v1/leaderboard?queue=flex", Body: `{"region":"na"}`},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	warmer.Run(ctx)
+
+	// Warming runs in the background, flex (which fails) after solo
+	deadline := time.Now().Add(2 * time.Second)
+	for originCalls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	soloKey := responseCacheKey(httptest.NewRequest(http.MethodPost, "/api/v1/leaderboard?queue=solo", nil), []byte(`{"region":"na"}`))
+	if _, found := cache.get(soloKey); !found {
+		t.Fatal("Expected the solo leaderboard to be warmed")
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/leaderboard?queue=solo", strings.NewReader(`{"region":"na"}`)))
+	if responseRecorder.Header().Get("X-Cache") != "HIT" || responseRecorder.Body.String() != `{"leaders":["Newyenn"]}` {
+		t.Errorf("Expected a warmed HIT, got %s '%s'", responseRecorder.Header().Get("X-Cache"), responseRecorder.Body.String())
+	}
+
+	// Failed warm-up responses aren't cached
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/leaderboard?queue=flex", strings.NewReader(`{"region":"na"}`)))
+	if responseRecorder.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected a MISS for a request whose warm-up failed, got %s", responseRecorder.Header().Get("X-Cache"))
+	}
+	if originCalls.Load() != 3 {
+		t.Errorf("Expected 2 warm-up calls and 1 miss, got %d origin calls", originCalls.Load())
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// ClientTagHeader identifies the calling app, e.g. "app=web; version=2.14.0; platform=ios"
+const ClientTagHeader = "X-OPGL-Client"
+
+// Client tag limits
+const (
+	// maxClientTagLength bounds the whole header value
+	maxClientTagLength = 128
+	// maxClientTagValueLength bounds each app, version and platform value
+	maxClientTagValueLength = 32
+	// maxClientTagSeries caps the distinct tags tracked in metrics; later ones are counted as "other"
+	maxClientTagSeries = 500
+)
+
+// clientTagContextKey is the context key for the request's client tag
+type clientTagContextKey struct{}
+
+// ClientTag is the parsed X-OPGL-Client header
+type ClientTag struct {
+	App      string
+	Version  string
+	Platform string
+}
+
+// String formats the tag as it is sent in the header
+func (tag ClientTag) String() string {
+	parts := []string{"app=" + tag.App}
+	if tag.Version != "" {
+		parts = append(parts, "version="+tag.Version)
+	}
+	if tag.Platform != "" {
+		parts = append(parts, "platform="+tag.Platform)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseClientTag parses an X-OPGL-Client value
+// app is required; version and platform are optional. Values are 1-32 characters
+// of letters, digits, '.', '_', '-' and '+'.
+func ParseClientTag(value string) (ClientTag, error) {
+	var tag ClientTag
+	if len(value) > maxClientTagLength {
+		return tag, fmt.Errorf("client tag is longer than %d characters", maxClientTagLength)
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		key, tagValue, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || !isClientTagValue(tagValue) {
+			return ClientTag{}, fmt.Errorf("client tag entry %q must be key=value with a 1-%d character value of letters, digits, '.', '_', '-' or '+'", part, maxClientTagValueLength)
+		}
+
+		var target *string
+		switch key {
+		case "app":
+			target = &tag.App
+		case "version":
+			target = &tag.Version
+		case "platform":
+			target = &tag.Platform
+		default:
+			return ClientTag{}, fmt.Errorf("unknown client tag key %q (expected app, version or platform)", key)
+		}
+		if *target != "" {
+			return ClientTag{}, fmt.Errorf("client tag key %q is repeated", key)
+		}
+		*target = tagValue
+	}
+
+	if tag.App == "" {
+		return ClientTag{}, fmt.Errorf("client tag must include app")
+	}
+	return tag, nil
+}
+
+// isClientTagValue accepts short values of letters, digits and version punctuation
+func isClientTagValue(value string) bool {
+	if value == "" || len(value) > maxClientTagValueLength {
+		return false
+	}
+	for _, character := range value {
+		switch {
+		case character >= 'a' && character <= 'z', character >= 'A' && character <= 'Z', character >= '0' && character <= '9':
+		case character == '.', character == '_', character == '-', character == '+':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ClientTagFromContext returns the request's valid client tag, if it sent one
+func ClientTagFromContext(ctx context.Context) (ClientTag, bool) {
+	tag, ok := ctx.Value(clientTagContextKey{}).(ClientTag)
+	return tag, ok
+}
+
+// ClientTagMetrics counts requests per client tag and response status class
+type ClientTagMetrics struct {
+	requests *metrics.Counter
+
+	mutex sync.Mutex
+	// seen are the tags already given their own series
+	seen map[ClientTag]bool
+}
+
+// NewClientTagMetrics registers the client tag metrics on registry
+func NewClientTagMetrics(registry *metrics.Registry) *ClientTagMetrics {
+	return &ClientTagMetrics{
+		requests: registry.NewCounter("opgl_gateway_client_requests_total", "Requests by X-OPGL-Client app, version and platform, and response status class.", "app", "version", "platform", "status"),
+		seen:     make(map[ClientTag]bool),
+	}
+}
+
+// record counts one response for tag
+// Once maxClientTagSeries distinct tags have been seen, new ones are counted as app "other"
+// so clients sending arbitrary versions can't grow /metrics without bound.
+func (clientMetrics *ClientTagMetrics) record(tag ClientTag, statusCode int) {
+	clientMetrics.mutex.Lock()
+	if !clientMetrics.seen[tag] {
+		if len(clientMetrics.seen) >= maxClientTagSeries {
+			tag = ClientTag{App: "other"}
+		} else {
+			clientMetrics.seen[tag] = true
+		}
+	}
+	clientMetrics.mutex.Unlock()
+
+	clientMetrics.requests.Inc(tag.App, tag.Version, tag.Platform, strconv.Itoa(statusCode/100)+"xx")
+}
+
+// ClientTagMiddleware reads the X-OPGL-Client header into the request context and counts the response
+// Requests without the header are counted as app "none" and malformed tags as app "invalid";
+// malformed tags never fail the request. clientMetrics may be nil to skip counting.
+func ClientTagMiddleware(clientMetrics *ClientTagMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			metricTag := ClientTag{App: "none"}
+			if headerValue := request.Header.Get(ClientTagHeader); headerValue != "" {
+				tag, err := ParseClientTag(headerValue)
+				if err != nil {
+					metricTag = ClientTag{App: "invalid"}
+				} else {
+					metricTag = tag
+					request = request.WithContext(context.WithValue(request.Context(), clientTagContextKey{}, tag))
+				}
+			}
+
+			if clientMetrics == nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			wrappedWriter := newResponseWriter(writer)
+			next.ServeHTTP(wrappedWriter, request)
+			clientMetrics.record(metricTag, wrappedWriter.statusCode)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// TestParseClientTag tests accepted and rejected client tags
+func TestParseClientTag(t *testing.T) {
+	tag, err := ParseClientTag("app=web; version=2.14.0-rc.1; platform=ios")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tag != (ClientTag{App: "web", Version: "2.14.0-rc.1", Platform: "ios"}) {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	if tag.String() != "app=web; version=2.14.0-rc.1; platform=ios" {
+		t.Errorf("Expected the tag to format as sent, got '%s'", tag.String())
+	}
+
+	invalidTags := []string{
+		"version=1.0",
+		"app=web; browser=firefox",
+		"app=web; app=ios",
+		"app=",
+		"app=web app",
+		"app=" + strings.Repeat("a", 33),
+		"web/2.14.0",
+	}
+	for _, value := range invalidTags {
+		if _, err := ParseClientTag(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+// TestClientTagMiddleware tests that tags reach the context and are counted by status class
+func TestClientTagMiddleware(t *testing.T) {
+	registry := metrics.NewRegistry()
+	var contextTag ClientTag
+	handler := ClientTagMiddleware(NewClientTagMetrics(registry))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contextTag, _ = ClientTagFromContext(request.Context())
+		writer.WriteHeader(http.StatusNotFound)
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set(ClientTagHeader, "app=web; version=2.14.0")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	if contextTag.App != "web" || contextTag.Version != "2.14.0" {
+		t.Errorf("Expected the tag in the request context, got %+v", contextTag)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set(ClientTagHeader, "nonsense")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	responseRecorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := responseRecorder.Body.String()
+	for _, expected := range []string{
+		`opgl_gateway_client_requests_total{app="web",version="2.14.0",platform="",status="4xx"} 1`,
+		`opgl_gateway_client_requests_total{app="invalid",version="",platform="",status="4xx"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %s in:\n%s", expected, body)
+		}
+	}
+}
+
+// TestClientTagMetrics_SeriesCap tests that tags past the cap are counted as "other"
+func TestClientTagMetrics_SeriesCap(t *testing.T) {
+	registry := metrics.NewRegistry()
+	clientMetrics := NewClientTagMetrics(registry)
+	for i := 0; i <= maxClientTagSeries; i++ {
+		clientMetrics.record(ClientTag{App: "web", Version: "1.0." + strconv.Itoa(i)}, http.StatusOK)
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(responseRecorder.Body.String(), `app="other"`) {
+		t.Error("Expected tags past the cap to be counted as other")
+	}
+	if strings.Contains(responseRecorder.Body.String(), `version="1.0.`+strconv.Itoa(maxClientTagSeries)+`"`) {
+		t.Error("Expected no series for the tag past the cap")
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// gzipResponseWriter is a wrapper around http.ResponseWriter that gzip-compresses the body
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gzipWriter  *gzip.Writer
+	wroteHeader bool
+	compress    bool
+}
+
+// WriteHeader decides whether to compress and calls the underlying WriteHeader
+// Responses that are already encoded or have no body are passed through unchanged.
+func (writer *gzipResponseWriter) WriteHeader(statusCode int) {
+	if writer.wroteHeader {
+		return
+	}
+	writer.wroteHeader = true
+
+	header := writer.Header()
+	if header.Get("Content-Encoding") == "" && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified {
+		writer.compress = true
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+	}
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write compresses the body when compression was chosen
+func (writer *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !writer.wroteHeader {
+		writer.WriteHeader(http.StatusOK)
+	}
+	if !writer.compress {
+		return writer.ResponseWriter.Write(data)
+	}
+
+	if writer.gzipWriter == nil {
+		writer.gzipWriter = gzip.NewWriter(writer.ResponseWriter)
+	}
+	return writer.gzipWriter.Write(data)
+}
+
+// CompressMiddleware creates middleware that gzip-compresses responses for clients sending Accept-Encoding: gzip
+func CompressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		responseWriter.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(request) {
+			next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		wrappedWriter := &gzipResponseWriter{ResponseWriter: responseWriter}
+		next.ServeHTTP(wrappedWriter, request)
+
+		if wrappedWriter.gzipWriter != nil {
+			wrappedWriter.gzipWriter.Close()
+		}
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(request *http.Request) bool {
+	for _, encoding := range strings.Split(request.Header.Get("Accept-Encoding"), ",") {
+		name, parameters, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(parameters, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCompressMiddleware tests that responses are gzipped only for clients that accept gzip
+func TestCompressMiddleware(t *testing.T) {
+	handler := CompressMiddleware(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte(`{"matchId":"NA1_1"}`))
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/matches", nil)
+	request.Header.Set("Accept-Encoding", "br, gzip")
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got '%s'", responseRecorder.Header().Get("Content-Encoding"))
+	}
+	gzipReader, err := gzip.NewReader(responseRecorder.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body: %v", err)
+	}
+	body, _ := io.ReadAll(gzipReader)
+	if string(body) != `{"matchId":"NA1_1"}` {
+		t.Errorf("Expected decompressed body, got '%s'", body)
+	}
+
+	// Clients that don't accept gzip get the plain body
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/matches", nil)
+	request.Header.Set("Accept-Encoding", "gzip;q=0")
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Header().Get("Content-Encoding") != "" || responseRecorder.Body.String() != `{"matchId":"NA1_1"}` {
+		t.Errorf("Expected an uncompressed response, got '%s'", responseRecorder.Body.String())
+	}
+	if responseRecorder.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected Vary: Accept-Encoding, got '%s'", responseRecorder.Header().Get("Vary"))
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// concurrencyLimitContextKey is the context key for the accepted API key's own concurrency limit
+type concurrencyLimitContextKey struct{}
+
+// withConcurrencyLimit stores the in-flight limit the auth service reported for the request's API key
+// A zero limit is not stored, so the limiter's default applies.
+func withConcurrencyLimit(request *http.Request, limit int) *http.Request {
+	if limit <= 0 {
+		return request
+	}
+	return request.WithContext(context.WithValue(request.Context(), concurrencyLimitContextKey{}, limit))
+}
+
+// ConcurrencyLimiter caps the requests each API key may have in flight at once
+// Counts are in memory, so each replica enforces the limit on its own.
+type ConcurrencyLimiter struct {
+	// defaultLimit applies to keys the auth service reports no limit for (0 means unlimited)
+	defaultLimit int
+
+	mutex    sync.Mutex
+	inFlight map[string]int
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter allowing defaultLimit in-flight requests per key
+func NewConcurrencyLimiter(defaultLimit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		defaultLimit: defaultLimit,
+		inFlight:     make(map[string]int),
+	}
+}
+
+// acquire takes an in-flight slot for consumer, returning false when all limit slots are taken
+func (limiter *ConcurrencyLimiter) acquire(consumer string, limit int) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if limiter.inFlight[consumer] >= limit {
+		return false
+	}
+	limiter.inFlight[consumer]++
+	return true
+}
+
+// release frees an in-flight slot of consumer, dropping its entry once it has none left
+func (limiter *ConcurrencyLimiter) release(consumer string) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.inFlight[consumer]--
+	if limiter.inFlight[consumer] <= 0 {
+		delete(limiter.inFlight, consumer)
+	}
+}
+
+// ConcurrencyMiddleware creates middleware that rejects requests with TOO_MANY_CONCURRENT_REQUESTS (429)
+// while their API key already has its limit of requests in flight, so one key's slow /analyze calls
+// can't tie up the gateway. The limit is the key's maxConcurrency from the auth service, or the
+// limiter's default. Requests without an accepted API key pass through, so the middleware must run
+// after rate limiting.
+func ConcurrencyMiddleware(limiter *ConcurrencyLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			consumer := identity.APIKeyID(request.Context())
+			limit, hasKeyLimit := request.Context().Value(concurrencyLimitContextKey{}).(int)
+			if !hasKeyLimit {
+				limit = limiter.defaultLimit
+			}
+			if consumer == "" || limit <= 0 {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if !limiter.acquire(consumer, limit) {
+				writer.Header().Set("Retry-After", "1")
+				apierrors.WriteError(writer, apierrors.TooManyInFlight(limit))
+				return
+			}
+			defer limiter.release(consumer)
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
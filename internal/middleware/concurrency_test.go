@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// TestConcurrencyMiddleware tests that a key over its in-flight limit is rejected until a request finishes
+func TestConcurrencyMiddleware(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := ConcurrencyMiddleware(limiter)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/slow" {
+			close(started)
+			<-finish
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(path string, apiKey string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, nil)
+		if apiKey != "" {
+			request = withConsumer(request, apiKey)
+		}
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	done := make(chan struct{})
+	go func() {
+		send("/slow", "test-key")
+		close(done)
+	}()
+	<-started
+
+	responseRecorder := send("/fast", "test-key")
+	if responseRecorder.Code != http.StatusTooManyRequests || responseRecorder.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected 429 with Retry-After while the key's slot is taken, got %d", responseRecorder.Code)
+	}
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeTooManyInFlight {
+		t.Errorf("Expected code %s, got %s", apierrors.ErrCodeTooManyInFlight, code)
+	}
+	if responseRecorder := send("/fast", "other-key"); responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected another key to have its own slots, got %d", responseRecorder.Code)
+	}
+	if responseRecorder := send("/fast", ""); responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected requests without an accepted key to pass, got %d", responseRecorder.Code)
+	}
+
+	close(finish)
+	<-done
+	if responseRecorder := send("/fast", "test-key"); responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected the slot to be freed once the request finished, got %d", responseRecorder.Code)
+	}
+	if len(limiter.inFlight) != 0 {
+		t.Errorf("Expected no in-flight entries left, got %v", limiter.inFlight)
+	}
+}
+
+// TestConcurrencyMiddleware_KeyLimit tests that the auth service's per-key limit overrides the default
+func TestConcurrencyMiddleware_KeyLimit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0)
+	handler := ConcurrencyMiddleware(limiter)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	limiter.acquire(apiKeyFingerprint("test-key"), 1)
+	request := withConcurrencyLimit(withConsumer(httptest.NewRequest(http.MethodPost, "/", nil), "test-key"), 1)
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the key's own limit to apply, got %d", responseRecorder.Code)
+	}
+
+	request = withConsumer(httptest.NewRequest(http.MethodPost, "/", nil), "test-key")
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected no limit without a key limit and a zero default, got %d", responseRecorder.Code)
+	}
+}
@@ -1,22 +1,100 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+)
+
+// corsAllowedMethods lists the methods browser clients may use: every method a declared route may have
+var corsAllowedMethods = strings.Join(append(slices.Clone(routes.Methods), http.MethodOptions), ", ")
+
+// corsAllowedHeaders lists the request headers browser clients may send
+var corsAllowedHeaders = strings.Join([]string{
+	"Content-Type",
+	"Authorization",
+	"X-API-Key",
+	"Idempotency-Key",
+	apierrors.RequestIDHeader,
+	SignatureHeader,
+	SignatureTimestampHeader,
+	SignatureNonceHeader,
+	CSRFHeader,
+	ClientTagHeader,
+	ChallengeHeader,
+}, ", ")
+
+// corsExposedHeaders lists the response headers browser clients may read
+var corsExposedHeaders = strings.Join([]string{
+	apierrors.RequestIDHeader,
+	announcements.Header,
+	DegradedHeader,
+	experiments.Header,
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"X-Quota-Limit",
+	"X-Quota-Remaining",
+	"X-Quota-Reset",
+	"Retry-After",
+	"Idempotent-Replayed",
+	"X-Cache",
+}, ", ")
+
+// tenantAllowsOrigin reports whether the tenant of the request's host allows origin
+func tenantAllowsOrigin(request *http.Request, origin string) bool {
+	tenant := tenants.FromContext(request.Context())
+	return tenant != nil && tenant.AllowsOrigin(origin)
+}
 
 // CORSMiddleware handles Cross-Origin Resource Sharing (CORS) preflight requests
-// and adds appropriate headers to allow browser-based clients to access the API
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		// Set CORS headers to allow cross-origin requests
-		responseWriter.Header().Set("Access-Control-Allow-Origin", "*")
-		responseWriter.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		responseWriter.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		// Handle preflight OPTIONS requests immediately
-		if request.Method == http.MethodOptions {
-			responseWriter.WriteHeader(http.StatusOK)
-			return
+// and adds appropriate headers to allow browser-based clients to access the API.
+// allowedOrigins lists the origins permitted to call the gateway; "*" allows any origin.
+// On a tenant's host (see TenantMiddleware) the tenant's allowed origins are permitted too.
+func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowAnyOrigin := false
+	originSet := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAnyOrigin = true
 		}
+		originSet[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			// Set CORS headers only for origins that are allowed to call the API
+			origin := request.Header.Get("Origin")
+			switch {
+			case allowAnyOrigin:
+				responseWriter.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && (originSet[origin] || tenantAllowsOrigin(request, origin)):
+				// Explicitly allowed origins may send cookies (cookie session mode)
+				responseWriter.Header().Set("Access-Control-Allow-Origin", origin)
+				responseWriter.Header().Set("Access-Control-Allow-Credentials", "true")
+				responseWriter.Header().Add("Vary", "Origin")
+			}
+			responseWriter.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			responseWriter.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			responseWriter.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+
+			// Handle preflight OPTIONS requests immediately
+			if request.Method == http.MethodOptions {
+				responseWriter.WriteHeader(http.StatusOK)
+				return
+			}
 
-		next.ServeHTTP(responseWriter, request)
-	})
+			next.ServeHTTP(responseWriter, request)
+		})
+	}
 }
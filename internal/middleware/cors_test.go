@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+)
+
+// TestCORSMiddleware_AllowAnyOrigin tests that "*" allows every origin
+func TestCORSMiddleware_AllowAnyOrigin(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("Origin", "https://example.com")
+	recorder := httptest.NewRecorder()
+
+	CORSMiddleware([]string{"*"})(nextHandler).ServeHTTP(recorder, request)
+
+	if allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "*" {
+		t.Errorf("Expected Access-Control-Allow-Origin '*', got '%s'", allowOrigin)
+	}
+
+	if allowCredentials := recorder.Header().Get("Access-Control-Allow-Credentials"); allowCredentials != "" {
+		t.Errorf("Expected no Access-Control-Allow-Credentials with '*', got '%s'", allowCredentials)
+	}
+}
+
+// TestCORSMiddleware_ExposedHeaders tests that browser clients may read the gateway's response headers
+func TestCORSMiddleware_ExposedHeaders(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("Origin", "https://example.com")
+	recorder := httptest.NewRecorder()
+
+	CORSMiddleware([]string{"*"})(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})).ServeHTTP(recorder, request)
+
+	exposedHeaders := strings.Split(recorder.Header().Get("Access-Control-Expose-Headers"), ", ")
+	for _, header := range []string{"RateLimit-Limit", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset", "X-OPGL-Experiments", "Idempotent-Replayed", "X-Cache"} {
+		if !slices.Contains(exposedHeaders, header) {
+			t.Errorf("Expected %s in Access-Control-Expose-Headers, got %v", header, exposedHeaders)
+		}
+	}
+}
+
+// TestCORSMiddleware_ListedOrigin tests that a listed origin is echoed back
+func TestCORSMiddleware_ListedOrigin(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("Origin", "https://opgl.gg")
+	recorder := httptest.NewRecorder()
+
+	CORSMiddleware([]string{"https://opgl.gg"})(nextHandler).ServeHTTP(recorder, request)
+
+	if allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "https://opgl.gg" {
+		t.Errorf("Expected Access-Control-Allow-Origin 'https://opgl.gg', got '%s'", allowOrigin)
+	}
+
+	if vary := recorder.Header().Get("Vary"); vary != "Origin" {
+		t.Errorf("Expected Vary 'Origin', got '%s'", vary)
+	}
+
+	if allowCredentials := recorder.Header().Get("Access-Control-Allow-Credentials"); allowCredentials != "true" {
+		t.Errorf("Expected Access-Control-Allow-Credentials 'true', got '%s'", allowCredentials)
+	}
+}
+
+// TestCORSMiddleware_UnlistedOrigin tests that unlisted origins get no Allow-Origin header
+func TestCORSMiddleware_UnlistedOrigin(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("Origin", "https://evil.example")
+	recorder := httptest.NewRecorder()
+
+	CORSMiddleware([]string{"https://opgl.gg"})(nextHandler).ServeHTTP(recorder, request)
+
+	if allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "" {
+		t.Errorf("Expected no Access-Control-Allow-Origin, got '%s'", allowOrigin)
+	}
+}
+
+// TestCORSMiddleware_Preflight tests that OPTIONS requests are answered without calling next
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	nextCalled := false
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		nextCalled = true
+	})
+
+	request := httptest.NewRequest(http.MethodOptions, "/api/v1/summoner", nil)
+	recorder := httptest.NewRecorder()
+
+	CORSMiddleware([]string{"*"})(nextHandler).ServeHTTP(recorder, request)
+
+	if nextCalled {
+		t.Error("Expected preflight request to not reach next handler")
+	}
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	// Declared routes may use any of these methods
+	if recorder.Header().Get("Access-Control-Allow-Methods") != "GET, POST, PUT, PATCH, DELETE, OPTIONS" {
+		t.Errorf("Unexpected Access-Control-Allow-Methods '%s'", recorder.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	// Browser frontends send challenge tokens on public routes
+	if !strings.Contains(recorder.Header().Get("Access-Control-Allow-Headers"), ChallengeHeader) {
+		t.Errorf("Expected %s in Access-Control-Allow-Headers, got '%s'", ChallengeHeader, recorder.Header().Get("Access-Control-Allow-Headers"))
+	}
+}
+
+// TestCORSMiddleware_TenantOrigin tests that a tenant's origins are allowed only on its hosts
+func TestCORSMiddleware_TenantOrigin(t *testing.T) {
+	testTenants := []tenants.Tenant{{ID: "acme", Hosts: []string{"stats.acme.gg"}, AllowedOrigins: []string{"https://stats.acme.gg"}}}
+	if err := tenants.Validate(testTenants); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+	handler := TenantMiddleware(tenants.NewDirectory(testTenants))(CORSMiddleware([]string{"https://opgl.gg"})(nextHandler))
+
+	for host, expected := range map[string]string{"stats.acme.gg": "https://stats.acme.gg", "api.opgl.gg": ""} {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+		request.Host = host
+		request.Header.Set("Origin", "https://stats.acme.gg")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		if allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin"); allowOrigin != expected {
+			t.Errorf("Expected Access-Control-Allow-Origin '%s' on %s, got '%s'", expected, host, allowOrigin)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// Cookie session and CSRF names
+const (
+	// SessionCookieName holds the access token in cookie session mode
+	SessionCookieName = "opgl_session"
+	// CSRFCookieName holds the double-submit CSRF token; it is readable by the frontend
+	CSRFCookieName = "opgl_csrf"
+	// CSRFHeader must echo the CSRF cookie on state-changing cookie-authenticated requests
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// csrfTokenBytes is the amount of randomness in a CSRF token
+const csrfTokenBytes = 32
+
+// CSRFMiddleware creates middleware enforcing double-submit CSRF tokens for cookie sessions
+// Only state-changing requests (anything but GET, HEAD and OPTIONS) that carry the session
+// or refresh cookie are checked: they must send the CSRF cookie's value in X-CSRF-Token. Requests
+// with an Authorization header are exempt, since the auth middleware then ignores the session
+// cookie and browsers never attach the header cross-site. X-API-Key doesn't exempt a request:
+// it identifies the caller's key, not the user, so the cookie would still authenticate it.
+func CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !csrfProtected(request) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		csrfCookie, err := request.Cookie(CSRFCookieName)
+		headerToken := request.Header.Get(CSRFHeader)
+		if err != nil || csrfCookie.Value == "" || headerToken == "" {
+			apierrors.WriteError(writer, apierrors.CSRFTokenInvalid("Include the CSRF token in the X-CSRF-Token header"))
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(csrfCookie.Value), []byte(headerToken)) != 1 {
+			apierrors.WriteError(writer, apierrors.CSRFTokenInvalid("CSRF token does not match"))
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// csrfProtected reports whether a request needs a CSRF token
+func csrfProtected(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if request.Header.Get("Authorization") != "" {
+		return false
+	}
+	for _, name := range []string{SessionCookieName, RefreshCookieName} {
+		if _, err := request.Cookie(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueCSRFToken sets a new CSRF cookie on the response and returns its token
+// The cookie isn't HttpOnly so same-site frontends can read it; cross-site frontends
+// use the token returned by CSRFTokenHandler instead.
+func IssueCSRFToken(writer http.ResponseWriter) (string, error) {
+	tokenBytes := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	http.SetCookie(writer, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+// CSRFTokenHandler issues a CSRF token as a cookie and in the JSON body
+func CSRFTokenHandler(writer http.ResponseWriter, request *http.Request) {
+	token, err := IssueCSRFToken(writer)
+	if err != nil {
+		apierrors.WriteError(writer, apierrors.InternalError("Failed to issue CSRF token"))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(writer).Encode(map[string]string{"csrfToken": token})
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCSRFMiddleware tests which requests need a matching CSRF token
+func TestCSRFMiddleware(t *testing.T) {
+	handler := CSRFMiddleware(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	cookieRequest := func(method string, csrfCookie string, csrfHeader string) *http.Request {
+		request := httptest.NewRequest(method, "/api/v1/analyze", nil)
+		request.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "access-token"})
+		if csrfCookie != "" {
+			request.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: csrfCookie})
+		}
+		if csrfHeader != "" {
+			request.Header.Set(CSRFHeader, csrfHeader)
+		}
+		return request
+	}
+	bearerRequest := cookieRequest(http.MethodPost, "", "")
+	bearerRequest.Header.Set("Authorization", "Bearer access-token")
+	apiKeyRequest := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil)
+	apiKeyRequest.Header.Set("X-API-Key", "test-key")
+	apiKeyCookieRequest := cookieRequest(http.MethodPost, "", "")
+	apiKeyCookieRequest.Header.Set("X-API-Key", "attacker-key")
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		expectedStatus int
+	}{
+		{"cookie POST with matching token", cookieRequest(http.MethodPost, "token", "token"), http.StatusOK},
+		{"cookie POST without token", cookieRequest(http.MethodPost, "", ""), http.StatusForbidden},
+		{"cookie POST without header", cookieRequest(http.MethodPost, "token", ""), http.StatusForbidden},
+		{"cookie DELETE with mismatched token", cookieRequest(http.MethodDelete, "token", "forged"), http.StatusForbidden},
+		{"cookie GET", cookieRequest(http.MethodGet, "", ""), http.StatusOK},
+		{"bearer POST", bearerRequest, http.StatusOK},
+		{"API key POST", apiKeyRequest, http.StatusOK},
+		{"API key POST with session cookie", apiKeyCookieRequest, http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, testCase.request)
+
+			if responseRecorder.Code != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got %d", testCase.expectedStatus, responseRecorder.Code)
+			}
+			if testCase.expectedStatus == http.StatusForbidden && !strings.Contains(responseRecorder.Body.String(), "CSRF_TOKEN_INVALID") {
+				t.Errorf("Expected CSRF_TOKEN_INVALID, got '%s'", responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+// TestCSRFTokenHandler tests that the issued cookie and body token match and differ per call
+func TestCSRFTokenHandler(t *testing.T) {
+	issue := func() (string, *http.Cookie) {
+		responseRecorder := httptest.NewRecorder()
+		CSRFTokenHandler(responseRecorder, httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil))
+
+		var body map[string]string
+		if err := json.Unmarshal(responseRecorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Expected JSON body, got '%s'", responseRecorder.Body.String())
+		}
+		cookies := responseRecorder.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != CSRFCookieName {
+			t.Fatalf("Expected one %s cookie, got %v", CSRFCookieName, cookies)
+		}
+		return body["csrfToken"], cookies[0]
+	}
+
+	token, cookie := issue()
+	if token == "" || cookie.Value != token {
+		t.Errorf("Expected cookie value to match body token %q, got %q", token, cookie.Value)
+	}
+	if !cookie.Secure || cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("Expected a Secure, script-readable, SameSite=Strict cookie, got %+v", cookie)
+	}
+
+	if nextToken, _ := issue(); nextToken == token {
+		t.Error("Expected a new token on every call")
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/health"
+)
+
+// DegradedHeader is set to "true" on responses while an upstream is down
+const DegradedHeader = "X-OPGL-Degraded"
+
+// DegradedMiddleware creates middleware that marks responses with X-OPGL-Degraded while the
+// last health check found an upstream down, so clients can back off or show a notice
+// before requests start failing outright
+func DegradedMiddleware(checker *health.Checker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if checker.Degraded() {
+				writer.Header().Set(DegradedHeader, "true")
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// ExperimentMiddleware creates middleware that assigns API key holders to the experiments running on path
+// Assignments are returned in the X-OPGL-Experiments header, stored in the request context for
+// upstream calls, and published to eventBus (which may be nil) as exposure events. Requests
+// without an accepted API key aren't enrolled, so the middleware must run after rate limiting.
+func ExperimentMiddleware(assigner *experiments.Assigner, eventBus *events.Bus, path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			consumer := identity.APIKeyID(request.Context())
+			if consumer == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			assignments := assigner.Assign(path, consumer)
+			if len(assignments) == 0 {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			writer.Header().Set(experiments.Header, experiments.FormatHeader(assignments))
+			for _, assignment := range assignments {
+				eventBus.Publish(events.TypeExperimentExposure, map[string]interface{}{
+					"experiment":        assignment.Experiment,
+					"variant":           assignment.Variant,
+					"apiKeyFingerprint": consumer,
+					"path":              path,
+				})
+			}
+
+			next.ServeHTTP(writer, request.WithContext(experiments.WithAssignments(request.Context(), assignments)))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+)
+
+// TestExperimentMiddleware tests that only requests with an accepted API key are assigned
+func TestExperimentMiddleware(t *testing.T) {
+	assigner := experiments.NewAssigner([]experiments.Experiment{{
+		Name:     "analysis-model",
+		Paths:    []string{"/api/v1/analyze"},
+		Variants: []experiments.Variant{{Name: "control", Weight: 1}, {Name: "cortex-v2", Weight: 1}},
+	}})
+
+	var contextAssignments []experiments.Assignment
+	handler := ExperimentMiddleware(assigner, nil, "/api/v1/analyze")(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contextAssignments = experiments.FromContext(request.Context())
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil))
+	if responseRecorder.Header().Get(experiments.Header) != "" || contextAssignments != nil {
+		t.Errorf("Expected anonymous requests not to be enrolled, got '%s'", responseRecorder.Header().Get(experiments.Header))
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, withConsumer(httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil), "test-key"))
+	expectedHeader := experiments.FormatHeader(assigner.Assign("/api/v1/analyze", apiKeyFingerprint("test-key")))
+	if responseRecorder.Header().Get(experiments.Header) != expectedHeader {
+		t.Errorf("Expected header '%s', got '%s'", expectedHeader, responseRecorder.Header().Get(experiments.Header))
+	}
+	if len(contextAssignments) != 1 {
+		t.Errorf("Expected the assignment in the request context, got %v", contextAssignments)
+	}
+}
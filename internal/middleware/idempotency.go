@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header to keep store keys small
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotencyEntries bounds the memory an idempotency store can hold
+const maxIdempotencyEntries = 10000
+
+// idempotencyEntry holds the state of a single (consumer, key) pair
+type idempotencyEntry struct {
+	requestHash [32]byte
+	completed   bool
+	statusCode  int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// IdempotencyStore keeps the first response for each (consumer, Idempotency-Key) pair for a TTL
+type IdempotencyStore struct {
+	ttl       time.Duration
+	mutex     sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+// NewIdempotencyStore creates a new in-memory idempotency store
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:       ttl,
+		entries:   make(map[string]*idempotencyEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// begin claims storeKey for a new request or returns the existing entry
+// The boolean is true when the caller claimed the key and must call complete or release
+func (store *IdempotencyStore) begin(storeKey string, requestHash [32]byte) (*idempotencyEntry, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweepExpired(now)
+
+	if entry, exists := store.entries[storeKey]; exists && now.Before(entry.expiresAt) {
+		// Copy so callers can read the entry without holding the lock
+		entryCopy := *entry
+		return &entryCopy, false
+	}
+	if _, exists := store.entries[storeKey]; !exists && len(store.entries) >= maxIdempotencyEntries {
+		store.evictOldest(now)
+	}
+
+	store.entries[storeKey] = &idempotencyEntry{
+		requestHash: requestHash,
+		expiresAt:   now.Add(store.ttl),
+	}
+	return nil, true
+}
+
+// complete stores the response for a claimed key
+func (store *IdempotencyStore) complete(storeKey string, statusCode int, contentType string, body []byte) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if entry, exists := store.entries[storeKey]; exists {
+		entry.completed = true
+		entry.statusCode = statusCode
+		entry.contentType = contentType
+		entry.body = body
+	}
+}
+
+// release forgets a claimed key so the request can be retried
+func (store *IdempotencyStore) release(storeKey string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.entries, storeKey)
+}
+
+// sweepExpired removes expired entries at most once per minute (caller must hold the lock)
+func (store *IdempotencyStore) sweepExpired(now time.Time) {
+	if now.Sub(store.lastSweep) < time.Minute {
+		return
+	}
+	store.lastSweep = now
+
+	for storeKey, entry := range store.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(store.entries, storeKey)
+		}
+	}
+}
+
+// evictOldest makes room for a new entry when the store is full (caller must hold the lock)
+// Expired entries go first; otherwise the oldest entry is dropped, so a retry of that request
+// is no longer deduplicated but new keys can always be claimed.
+func (store *IdempotencyStore) evictOldest(now time.Time) {
+	store.lastSweep = time.Time{}
+	store.sweepExpired(now)
+	if len(store.entries) < maxIdempotencyEntries {
+		return
+	}
+
+	var oldestKey string
+	var oldestExpiry time.Time
+	for storeKey, entry := range store.entries {
+		if oldestKey == "" || entry.expiresAt.Before(oldestExpiry) {
+			oldestKey, oldestExpiry = storeKey, entry.expiresAt
+		}
+	}
+	delete(store.entries, oldestKey)
+}
+
+// recordingWriter is a wrapper around http.ResponseWriter that keeps a copy of the response
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader captures the status code and calls the underlying WriteHeader
+func (writer *recordingWriter) WriteHeader(statusCode int) {
+	writer.statusCode = statusCode
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write copies the body and calls the underlying Write
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	writer.body.Write(data)
+	return writer.ResponseWriter.Write(data)
+}
+
+// idempotencyConsumer returns who an Idempotency-Key belongs to: the API key accepted by rate
+// limiting or the signed-in user, or "" when the request has neither
+func idempotencyConsumer(ctx context.Context) string {
+	if apiKeyID := identity.APIKeyID(ctx); apiKeyID != "" {
+		return "key:" + apiKeyID
+	}
+	if userID, ok := identity.UserID(ctx); ok {
+		return "user:" + userID.String()
+	}
+	return ""
+}
+
+// IdempotencyMiddleware creates middleware that honors the Idempotency-Key header
+// The first response for a (consumer, key) pair is stored and replayed on retries,
+// so client retry logic doesn't trigger duplicate upstream work. Server errors (5xx)
+// are not stored, which lets clients retry transient failures with the same key.
+// Anonymous requests are never stored, since their callers can't be told apart.
+func IdempotencyMiddleware(store *IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			idempotencyKey := request.Header.Get("Idempotency-Key")
+			if idempotencyKey == "" {
+				next.ServeHTTP(responseWriter, request)
+				return
+			}
+
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				apierrors.WriteError(responseWriter, apierrors.InvalidRequestBody(
+					"Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters"))
+				return
+			}
+
+			consumer := idempotencyConsumer(request.Context())
+			if consumer == "" {
+				next.ServeHTTP(responseWriter, request)
+				return
+			}
+
+			// Read the body so it can be fingerprinted and replayed to the handler
+			requestBody, ok := bufferRequestBody(responseWriter, request)
+			if !ok {
+				return
+			}
+
+			// Keys are scoped per consumer so different clients can't collide
+			storeKey := consumer + ":" + request.URL.Path + ":" + idempotencyKey
+			requestHash := sha256.Sum256(requestBody)
+
+			existingEntry, claimed := store.begin(storeKey, requestHash)
+			if !claimed {
+				switch {
+				case existingEntry.requestHash != requestHash:
+					apierrors.WriteError(responseWriter, apierrors.IdempotencyKeyReused())
+				case !existingEntry.completed:
+					apierrors.WriteError(responseWriter, apierrors.IdempotencyKeyInUse())
+				default:
+					responseWriter.Header().Set("Content-Type", existingEntry.contentType)
+					responseWriter.Header().Set("Idempotent-Replayed", "true")
+					responseWriter.WriteHeader(existingEntry.statusCode)
+					responseWriter.Write(existingEntry.body)
+				}
+				return
+			}
+
+			wrappedWriter := &recordingWriter{ResponseWriter: responseWriter, statusCode: http.StatusOK}
+			next.ServeHTTP(wrappedWriter, request)
+
+			// Cancelled requests are released too, since the client never saw the response
+			if wrappedWriter.statusCode >= http.StatusInternalServerError || wrappedWriter.statusCode == apierrors.StatusClientClosedRequest {
+				store.release(storeKey)
+				return
+			}
+
+			store.complete(storeKey, wrappedWriter.statusCode, wrappedWriter.Header().Get("Content-Type"), wrappedWriter.body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/google/uuid"
+)
+
+// newIdempotentRequest creates a POST request with an accepted API key and Idempotency-Key
+func newIdempotentRequest(body string, idempotencyKey string) *http.Request {
+	request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(body))
+	request.Header.Set("Idempotency-Key", idempotencyKey)
+	return withConsumer(request, "test-api-key")
+}
+
+// TestIdempotencyMiddleware_ReplaysStoredResponse tests that a retry returns the first response without re-running the handler
+func TestIdempotencyMiddleware_ReplaysStoredResponse(t *testing.T) {
+	callCount := 0
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte(`{"result":"analysis"}`))
+	}))
+
+	firstRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(firstRecorder, newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	secondRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(secondRecorder, newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	if callCount != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", callCount)
+	}
+
+	if secondRecorder.Body.String() != firstRecorder.Body.String() {
+		t.Errorf("Expected replayed body '%s', got '%s'", firstRecorder.Body.String(), secondRecorder.Body.String())
+	}
+
+	if secondRecorder.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected Idempotent-Replayed header on replayed response")
+	}
+}
+
+// TestIdempotencyMiddleware_DifferentBody tests that reusing a key with another body is rejected
+func TestIdempotencyMiddleware_DifferentBody(t *testing.T) {
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, newIdempotentRequest(`{"region":"euw"}`, "retry-1"))
+
+	if responseRecorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, responseRecorder.Code)
+	}
+
+	var errorResponse apierrors.ErrorResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&errorResponse)
+	if errorResponse.Error.Code != apierrors.ErrCodeIdempotencyReused {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeIdempotencyReused, errorResponse.Error.Code)
+	}
+}
+
+// TestIdempotencyMiddleware_InFlight tests that a concurrent retry is rejected while the first request runs
+func TestIdempotencyMiddleware_InFlight(t *testing.T) {
+	store := NewIdempotencyStore(time.Hour)
+	var retryRecorder *httptest.ResponseRecorder
+
+	var handler http.Handler
+	handler = IdempotencyMiddleware(store)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Issue the retry while the original request is still being processed
+		if retryRecorder == nil {
+			retryRecorder = httptest.NewRecorder()
+			handler.ServeHTTP(retryRecorder, newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	if retryRecorder.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, retryRecorder.Code)
+	}
+}
+
+// TestIdempotencyMiddleware_ServerErrorNotStored tests that 5xx responses can be retried with the same key
+func TestIdempotencyMiddleware_ServerErrorNotStored(t *testing.T) {
+	callCount := 0
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+		apierrors.WriteError(writer, apierrors.CortexServiceError("Analysis service error"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	if callCount != 2 {
+		t.Errorf("Expected handler to run twice, ran %d times", callCount)
+	}
+}
+
+// TestIdempotencyMiddleware_ScopedPerConsumer tests that the same key from different API keys doesn't collide
+func TestIdempotencyMiddleware_ScopedPerConsumer(t *testing.T) {
+	callCount := 0
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(`{"region":"na"}`, "retry-1"))
+
+	otherConsumerRequest := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{"region":"na"}`))
+	otherConsumerRequest.Header.Set("Idempotency-Key", "retry-1")
+	handler.ServeHTTP(httptest.NewRecorder(), withConsumer(otherConsumerRequest, "other-api-key"))
+
+	userRequest := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{"region":"na"}`))
+	userRequest.Header.Set("Idempotency-Key", "retry-1")
+	userRequest = userRequest.WithContext(identity.WithUserID(userRequest.Context(), uuid.New()))
+	handler.ServeHTTP(httptest.NewRecorder(), userRequest)
+
+	if callCount != 3 {
+		t.Errorf("Expected handler to run for each consumer, ran %d times", callCount)
+	}
+}
+
+// TestIdempotencyMiddleware_AnonymousNotStored tests that callers without an accepted key or session never share responses
+func TestIdempotencyMiddleware_AnonymousNotStored(t *testing.T) {
+	callCount := 0
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{"region":"na"}`))
+		request.Header.Set("X-API-Key", "unchecked-key")
+		request.Header.Set("Idempotency-Key", "retry-1")
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+
+		if responseRecorder.Header().Get("Idempotent-Replayed") != "" {
+			t.Error("Expected an anonymous request never to be replayed")
+		}
+	}
+
+	if callCount != 2 {
+		t.Errorf("Expected handler to run twice, ran %d times", callCount)
+	}
+}
+
+// TestIdempotencyMiddleware_NoHeader tests that requests without the header pass through
+func TestIdempotencyMiddleware_NoHeader(t *testing.T) {
+	callCount := 0
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+	}))
+
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	if callCount != 2 {
+		t.Errorf("Expected handler to run twice, ran %d times", callCount)
+	}
+}
+
+// TestIdempotencyMiddleware_OversizedBody tests that bodies over the buffering limit are rejected before the handler
+func TestIdempotencyMiddleware_OversizedBody(t *testing.T) {
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Expected the handler not to run")
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, newIdempotentRequest(strings.Repeat("a", maxBufferedBodyBytes+1), "key-1"))
+	if responseRecorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, responseRecorder.Code)
+	}
+}
+
+// TestIdempotencyStore_EvictsOldest tests that a full store drops its oldest entry to claim a new key
+func TestIdempotencyStore_EvictsOldest(t *testing.T) {
+	store := NewIdempotencyStore(time.Hour)
+	for i := 0; i < maxIdempotencyEntries; i++ {
+		store.begin(strconv.Itoa(i), [32]byte{})
+	}
+	store.entries["0"].expiresAt = time.Now().Add(time.Minute)
+
+	if _, claimed := store.begin("new", [32]byte{}); !claimed {
+		t.Fatal("Expected a new key to be claimed in a full store")
+	}
+	if len(store.entries) != maxIdempotencyEntries {
+		t.Errorf("Expected %d entries, got %d", maxIdempotencyEntries, len(store.entries))
+	}
+	if _, exists := store.entries["0"]; exists {
+		t.Error("Expected the oldest entry to be evicted")
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// allowedIPsContextKey is the context key for the accepted API key's IP allowlist
+type allowedIPsContextKey struct{}
+
+// withAllowedIPs stores the IP allowlist the auth service reported for the request's API key
+// Keys without an allowlist may be used from anywhere, so nothing is stored for them.
+func withAllowedIPs(request *http.Request, allowedIPs []string) *http.Request {
+	if len(allowedIPs) == 0 {
+		return request
+	}
+	return request.WithContext(context.WithValue(request.Context(), allowedIPsContextKey{}, allowedIPs))
+}
+
+// allowlistContains reports whether address is in an allowlist of CIDRs and single IPs
+// Entries that are neither never match, so a malformed allowlist rejects rather than admits.
+func allowlistContains(allowedIPs []string, address netip.Addr) bool {
+	for _, entry := range allowedIPs {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Masked().Contains(address) {
+				return true
+			}
+			continue
+		}
+		if allowedAddress, err := netip.ParseAddr(entry); err == nil && allowedAddress.Unmap() == address {
+			return true
+		}
+	}
+	return false
+}
+
+// IPAllowlistMiddleware creates middleware that rejects requests with IP_NOT_ALLOWED (403) when their
+// API key is pinned to an IP allowlist that doesn't contain the client IP. The client IP is resolved
+// like the per-IP limit's: X-Forwarded-For is only believed from trustedProxies. Keys without an
+// allowlist and requests without an accepted API key pass through, so the middleware must run after
+// rate limiting.
+func IPAllowlistMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			allowedIPs, pinned := request.Context().Value(allowedIPsContextKey{}).([]string)
+			if !pinned {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			address, ok := forwardedClientAddress(request, trustedProxies)
+			if !ok || !allowlistContains(allowedIPs, address) {
+				apierrors.WriteError(writer, apierrors.IPNotAllowed())
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
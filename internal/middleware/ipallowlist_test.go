@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// TestIPAllowlistMiddleware tests that pinned keys are only accepted from their allowlist
+func TestIPAllowlistMiddleware(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	testCases := []struct {
+		name           string
+		allowedIPs     []string
+		remoteAddr     string
+		forwardedFor   string
+		expectedStatus int
+	}{
+		{"key without allowlist", nil, "198.51.100.9:4000", "", http.StatusOK},
+		{"address in CIDR", []string{"203.0.113.0/24"}, "203.0.113.7:4000", "", http.StatusOK},
+		{"single IP", []string{"2001:db8::1", "203.0.113.7"}, "203.0.113.7:4000", "", http.StatusOK},
+		{"address outside allowlist", []string{"203.0.113.0/24"}, "198.51.100.9:4000", "", http.StatusForbidden},
+		{"client behind trusted proxy", []string{"203.0.113.0/24"}, "10.1.2.3:4000", "203.0.113.7", http.StatusOK},
+		{"forwarded header from untrusted peer", []string{"203.0.113.0/24"}, "198.51.100.9:4000", "203.0.113.7", http.StatusForbidden},
+		{"malformed allowlist", []string{"office"}, "203.0.113.7:4000", "", http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newMockAuthService(t, checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Now().Add(time.Minute).Unix(), AllowedIPs: testCase.allowedIPs})
+			handler := RateLimitMiddleware(client, nil)(IPAllowlistMiddleware(trustedProxies)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusOK)
+			})))
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+			request.RemoteAddr = testCase.remoteAddr
+			request.Header.Set("X-API-Key", "test-key")
+			if testCase.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", testCase.forwardedFor)
+			}
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			if responseRecorder.Code != testCase.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", testCase.expectedStatus, responseRecorder.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ipRateLimitPolicy names the per-IP limit in 429 details
+const ipRateLimitPolicy = "ip"
+
+// ipv6ClientPrefixBits is the IPv6 prefix counted as one client; a single subscriber usually
+// holds a whole /64 and could otherwise take a fresh address for every request
+const ipv6ClientPrefixBits = 64
+
+// maxIPWindows bounds the number of clients counted separately in a window
+const maxIPWindows = 100000
+
+// ipWindow counts one client IP's requests in the current fixed window
+type ipWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// IPRateLimiter limits requests without an API key per client IP (per /64 for IPv6)
+// Counters are in memory, so each replica allows the limit on its own. Windows are fixed
+// like the auth service's key limits, and expired counters are swept at most once per window
+// or when maxIPWindows is reached. Clients beyond that share one counter until windows expire.
+type IPRateLimiter struct {
+	limit  int
+	window time.Duration
+	// trustedProxies are the load balancers whose X-Forwarded-For is believed
+	trustedProxies []netip.Prefix
+
+	mutex     sync.Mutex
+	windows   map[netip.Prefix]*ipWindow
+	lastSweep time.Time
+}
+
+// NewIPRateLimiter creates an IPRateLimiter allowing limit requests per window per IP
+// Requests from trustedProxies are attributed to the client address they forwarded.
+func NewIPRateLimiter(limit int, window time.Duration, trustedProxies []netip.Prefix) *IPRateLimiter {
+	return &IPRateLimiter{
+		limit:          limit,
+		window:         window,
+		trustedProxies: trustedProxies,
+		windows:        make(map[netip.Prefix]*ipWindow),
+		lastSweep:      time.Now(),
+	}
+}
+
+// check counts a request of the given cost from address and returns the resulting limit state
+func (limiter *IPRateLimiter) check(address netip.Addr, cost int, now time.Time) *checkRateLimitResponse {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if now.Sub(limiter.lastSweep) >= limiter.window {
+		limiter.sweep(now)
+	}
+
+	client := clientPrefix(address)
+	window, exists := limiter.windows[client]
+	if !exists && len(limiter.windows) >= maxIPWindows {
+		limiter.sweep(now)
+		if len(limiter.windows) >= maxIPWindows {
+			// Neither let new clients go uncounted nor evict counted ones they could reset
+			client = netip.Prefix{}
+			window, exists = limiter.windows[client]
+		}
+	}
+	if !exists || !now.Before(window.resetAt) {
+		window = &ipWindow{resetAt: now.Add(limiter.window)}
+		limiter.windows[client] = window
+	}
+	window.count += max(cost, 1)
+
+	return &checkRateLimitResponse{
+		Allowed:   window.count <= limiter.limit,
+		Limit:     limiter.limit,
+		Remaining: max(limiter.limit-window.count, 0),
+		Reset:     window.resetAt.Unix(),
+		Window:    int64(limiter.window / time.Second),
+		Policy:    ipRateLimitPolicy,
+	}
+}
+
+// sweep drops expired counters; callers hold the mutex
+func (limiter *IPRateLimiter) sweep(now time.Time) {
+	for client, window := range limiter.windows {
+		if !now.Before(window.resetAt) {
+			delete(limiter.windows, client)
+		}
+	}
+	limiter.lastSweep = now
+}
+
+// clientPrefix returns the addresses counted as one client: the IPv4 address, or its IPv6 /64
+func clientPrefix(address netip.Addr) netip.Prefix {
+	if address.Is4() {
+		return netip.PrefixFrom(address, address.BitLen())
+	}
+	prefix, _ := address.Prefix(ipv6ClientPrefixBits)
+	return prefix
+}
+
+// clientAddress returns the IP a request is counted against
+func (limiter *IPRateLimiter) clientAddress(request *http.Request) (netip.Addr, bool) {
+	return forwardedClientAddress(request, limiter.trustedProxies)
+}
+
+// forwardedClientAddress returns the client IP of a request
+// X-Forwarded-For is read right to left while the hops are trustedProxies, so the first
+// untrusted address is used and a client can't pick its own identity by prepending entries.
+func forwardedClientAddress(request *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	address, err := netip.ParseAddr(clientIP(request))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	address = address.Unmap()
+
+	forwardedFor := strings.Split(strings.Join(request.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0 && prefixesContain(trustedProxies, address); i-- {
+		forwarded, err := netip.ParseAddr(strings.TrimSpace(forwardedFor[i]))
+		if err != nil {
+			break
+		}
+		address = forwarded.Unmap()
+	}
+	return address, true
+}
+
+// prefixesContain reports whether address is in one of prefixes
+func prefixesContain(prefixes []netip.Prefix, address netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow counts an anonymous request of the given cost and writes the limit headers, or a 429 when the
+// IP is over its limit. It returns false when the request was rejected.
+func (limiter *IPRateLimiter) allow(responseWriter http.ResponseWriter, request *http.Request, cost int) bool {
+	address, ok := limiter.clientAddress(request)
+	if !ok {
+		return true
+	}
+
+	rateLimitResult := limiter.check(address, cost, time.Now())
+	setRateLimitHeaders(responseWriter.Header(), rateLimitResult)
+	if !rateLimitResult.Allowed {
+		writeRateLimitExceeded(responseWriter, rateLimitResult, "")
+		return false
+	}
+	return true
+}
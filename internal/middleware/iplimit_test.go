@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// TestOptionalRateLimitMiddleware_IPLimit tests that keyless requests are limited per IP and keyed ones aren't
+func TestOptionalRateLimitMiddleware_IPLimit(t *testing.T) {
+	rateLimitClient := newMockAuthService(t, checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Now().Add(time.Minute).Unix()})
+	ipLimiter := NewIPRateLimiter(2, time.Minute, nil)
+	handler := OptionalRateLimitClassMiddleware(rateLimitClient, nil, "", 1, ipLimiter)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string, apiKey string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/champions", nil)
+		request.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			request.Header.Set("X-API-Key", apiKey)
+		}
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if responseRecorder := send("203.0.113.7:5000", ""); responseRecorder.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to be allowed, got %d", i+1, responseRecorder.Code)
+		}
+	}
+
+	responseRecorder := send("203.0.113.7:5001", "")
+	if responseRecorder.Code != http.StatusTooManyRequests || responseRecorder.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After for the third request, got %d", responseRecorder.Code)
+	}
+	if responseRecorder.Header().Get("RateLimit-Limit") != "2" || responseRecorder.Header().Get("RateLimit-Remaining") != "0" || responseRecorder.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("Expected the IP limit in the RateLimit headers, got %v", responseRecorder.Header())
+	}
+
+	if responseRecorder := send("198.51.100.4:5000", ""); responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected another IP to have its own limit, got %d", responseRecorder.Code)
+	}
+	if responseRecorder := send("203.0.113.7:5000", "test-key"); responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected a keyed request to skip the IP limit, got %d", responseRecorder.Code)
+	}
+}
+
+// TestIPRateLimiter_ClientAddress tests X-Forwarded-For handling behind trusted proxies
+func TestIPRateLimiter_ClientAddress(t *testing.T) {
+	limiter := NewIPRateLimiter(1, time.Minute, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expected     string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted peer can't spoof", "203.0.113.7:5000", "198.51.100.4", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", "198.51.100.4", "198.51.100.4"},
+		{"chained trusted proxies", "10.0.0.2:5000", "198.51.100.4, 10.0.0.9", "198.51.100.4"},
+		{"prepended entry is ignored", "10.0.0.2:5000", "1.2.3.4, 198.51.100.4", "198.51.100.4"},
+		{"malformed entry stops the walk", "10.0.0.2:5000", "garbage", "10.0.0.2"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = testCase.remoteAddr
+			if testCase.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", testCase.forwardedFor)
+			}
+
+			address, _ := limiter.clientAddress(request)
+			if address.String() != testCase.expected {
+				t.Errorf("Expected %s, got %s", testCase.expected, address)
+			}
+		})
+	}
+}
+
+// TestIPRateLimiter_WindowReset tests that a new window starts once the old one ends
+func TestIPRateLimiter_WindowReset(t *testing.T) {
+	limiter := NewIPRateLimiter(1, time.Minute, nil)
+	address := netip.MustParseAddr("203.0.113.7")
+	now := time.Now()
+
+	if !limiter.check(address, 1, now).Allowed || limiter.check(address, 1, now).Allowed {
+		t.Fatal("Expected only the first request in the window to be allowed")
+	}
+	if !limiter.check(address, 1, now.Add(time.Minute)).Allowed {
+		t.Error("Expected the next window to allow the IP again")
+	}
+}
+
+// TestIPRateLimiter_IPv6Prefix tests that addresses in one IPv6 /64 share a counter
+func TestIPRateLimiter_IPv6Prefix(t *testing.T) {
+	limiter := NewIPRateLimiter(1, time.Minute, nil)
+	now := time.Now()
+
+	if !limiter.check(netip.MustParseAddr("2001:db8:1:2::1"), 1, now).Allowed {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if limiter.check(netip.MustParseAddr("2001:db8:1:2:abcd::9"), 1, now).Allowed {
+		t.Error("Expected another address in the same /64 to share the limit")
+	}
+	if !limiter.check(netip.MustParseAddr("2001:db8:1:3::1"), 1, now).Allowed {
+		t.Error("Expected a different /64 to have its own limit")
+	}
+}
+
+// TestIPRateLimiter_MaxWindows tests that clients beyond the cap share one counter
+func TestIPRateLimiter_MaxWindows(t *testing.T) {
+	limiter := NewIPRateLimiter(1, time.Minute, nil)
+	now := time.Now()
+
+	for i := range maxIPWindows {
+		limiter.check(netip.AddrFrom4([4]byte{10, byte(i >> 16), byte(i >> 8), byte(i)}), 1, now)
+	}
+	if !limiter.check(netip.MustParseAddr("203.0.113.7"), 1, now).Allowed {
+		t.Fatal("Expected the first untracked client to be allowed")
+	}
+	if limiter.check(netip.MustParseAddr("203.0.113.8"), 1, now).Allowed {
+		t.Error("Expected untracked clients to share one counter")
+	}
+	if len(limiter.windows) > maxIPWindows+1 {
+		t.Errorf("Expected at most %d counters, got %d", maxIPWindows+1, len(limiter.windows))
+	}
+
+	if !limiter.check(netip.MustParseAddr("203.0.113.9"), 1, now.Add(time.Minute)).Allowed {
+		t.Error("Expected expired counters to be swept for new clients")
+	}
+}
+
+// TestIPRateLimiter_Cost tests that costly requests use up the per-IP limit faster
+func TestIPRateLimiter_Cost(t *testing.T) {
+	limiter := NewIPRateLimiter(10, time.Minute, nil)
+	address := netip.MustParseAddr("203.0.113.7")
+	now := time.Now()
+
+	if result := limiter.check(address, 10, now); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("Expected a cost of 10 to use up the limit, got %+v", result)
+	}
+	if limiter.check(address, 1, now).Allowed {
+		t.Error("Expected the next request to be rejected")
+	}
+}
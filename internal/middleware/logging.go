@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -38,6 +39,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log incoming request
 		log.Info().
+			Str("request_id", identity.RequestID(request.Context())).
 			Str("method", request.Method).
 			Str("path", request.URL.Path).
 			Str("remote_addr", request.RemoteAddr).
@@ -63,8 +65,14 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			logEvent = log.Info()
 		}
 
+		// Tag the line with the calling app so errors can be broken down by client version
+		if clientTag, ok := ClientTagFromContext(request.Context()); ok {
+			logEvent = logEvent.Str("client", clientTag.String())
+		}
+
 		// Log request completion with details
 		logEvent.
+			Str("request_id", identity.RequestID(request.Context())).
 			Str("method", request.Method).
 			Str("path", request.URL.Path).
 			Int("status", statusCode).
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceStore records nonces that have already been used so replayed requests can be rejected
+type NonceStore interface {
+	// CheckAndStore records nonce until expiresAt and reports whether it was unused
+	CheckAndStore(nonce string, expiresAt time.Time) bool
+}
+
+// MemoryNonceStore is an in-process NonceStore
+// Each gateway instance tracks its own nonces, which is sufficient for single-instance deployments
+type MemoryNonceStore struct {
+	mutex     sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore creates a new in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces:    make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// CheckAndStore records nonce until expiresAt and reports whether it was unused
+func (store *MemoryNonceStore) CheckAndStore(nonce string, expiresAt time.Time) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweepExpired(now)
+
+	if existingExpiry, exists := store.nonces[nonce]; exists && now.Before(existingExpiry) {
+		return false
+	}
+
+	store.nonces[nonce] = expiresAt
+	return true
+}
+
+// sweepExpired removes expired nonces at most once per minute (caller must hold the lock)
+func (store *MemoryNonceStore) sweepExpired(now time.Time) {
+	if now.Sub(store.lastSweep) < time.Minute {
+		return
+	}
+	store.lastSweep = now
+
+	for nonce, expiresAt := range store.nonces {
+		if !now.Before(expiresAt) {
+			delete(store.nonces, nonce)
+		}
+	}
+}
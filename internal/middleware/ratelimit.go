@@ -2,34 +2,110 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+	"github.com/rs/zerolog/log"
 )
 
+// Rate limit failure policies decide what happens to requests while the auth service can't answer checks
+const (
+	// FailClosed reuses a key's last answer within the fallback TTL and rejects other requests with INTERNAL_ERROR (500)
+	FailClosed = "closed"
+	// FailStale also reuses last answers older than the fallback TTL, for as long as they are remembered.
+	// Keys without a remembered answer are still rejected: their scopes and IP allowlist are unknown.
+	FailStale = "stale"
+)
+
+// maxFallbackEntries bounds the number of remembered check answers
+const maxFallbackEntries = 10000
+
+// RateLimitClientConfig holds the settings of a RateLimitServiceClient
+type RateLimitClientConfig struct {
+	BaseURL string
+	// FailurePolicy is FailClosed (the default) or FailStale
+	FailurePolicy string
+	// FallbackTTL is how long a key's last answer is reused while checks fail (0 disables)
+	FallbackTTL time.Duration
+	// MetricsRegistry receives the failed check counter (optional)
+	MetricsRegistry *metrics.Registry
+	// Transport sends the checks, e.g. through the egress guard (nil uses http.DefaultTransport)
+	Transport http.RoundTripper
+}
+
 // RateLimitServiceClient handles communication with the auth service for rate limiting
 type RateLimitServiceClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL       string
+	httpClient    *http.Client
+	failurePolicy string
+	fallbackTTL   time.Duration
+	failures      *metrics.Counter
+
+	mutex    sync.Mutex
+	fallback map[fallbackKey]fallbackAnswer
+}
+
+// fallbackKey identifies a remembered answer; the key is stored hashed, never in the clear
+type fallbackKey struct {
+	apiKeyHash     [sha256.Size]byte
+	rateLimitClass string
+}
+
+// fallbackAnswer is a key's last answer from the auth service
+type fallbackAnswer struct {
+	result    checkRateLimitResponse
+	checkedAt time.Time
 }
 
 // NewRateLimitServiceClient creates a new rate limit service client
 func NewRateLimitServiceClient(baseURL string) *RateLimitServiceClient {
-	return &RateLimitServiceClient{
-		baseURL: baseURL,
+	return NewRateLimitServiceClientWithConfig(RateLimitClientConfig{BaseURL: baseURL})
+}
+
+// NewRateLimitServiceClientWithConfig creates a rate limit service client with a failure policy and fallback
+func NewRateLimitServiceClientWithConfig(config RateLimitClientConfig) *RateLimitServiceClient {
+	failurePolicy := config.FailurePolicy
+	if failurePolicy == "" {
+		failurePolicy = FailClosed
+	}
+
+	client := &RateLimitServiceClient{
+		baseURL: config.BaseURL,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
+			Transport: config.Transport,
+			Timeout:   5 * time.Second,
 		},
+		failurePolicy: failurePolicy,
+		fallbackTTL:   config.FallbackTTL,
+		fallback:      make(map[fallbackKey]fallbackAnswer),
 	}
+	if config.MetricsRegistry != nil {
+		client.failures = config.MetricsRegistry.NewCounter(
+			"opgl_gateway_ratelimit_check_failures_total",
+			"Rate limit checks the auth service couldn't answer, by how the request was handled: cached, stale or rejected.",
+			"outcome",
+		)
+	}
+	return client
 }
 
 // checkRateLimitRequest represents the request to check rate limit
 type checkRateLimitRequest struct {
 	APIKey string `json:"apiKey"`
+	Class  string `json:"class,omitempty"`
+	// Cost is how many requests this one counts as; omitted for the usual cost of 1
+	Cost int `json:"cost,omitempty"`
 }
 
 // checkRateLimitResponse represents the response from rate limit check
@@ -38,24 +114,65 @@ type checkRateLimitResponse struct {
 	Limit     int   `json:"limit"`
 	Remaining int   `json:"remaining"`
 	Reset     int64 `json:"reset"`
+	// Window is the window length in seconds (optional)
+	Window int64 `json:"window"`
+	// Policy names the auth service's policy that applied (optional)
+	Policy string `json:"policy"`
+	// Exempt marks keys the auth service records usage for without limiting them (optional)
+	Exempt bool `json:"exempt"`
+	// MaxConcurrency is the key's own limit of requests in flight (optional)
+	MaxConcurrency int `json:"maxConcurrency"`
+	// Scopes restrict the key to the routes with these scopes; empty means unrestricted (optional)
+	Scopes []string `json:"scopes"`
+	// AllowedIPs pins the key to these CIDRs or IPs; empty means any source IP (optional)
+	AllowedIPs []string `json:"allowedIps"`
+	// Quota is the key's monthly quota, when the auth service tracks one (optional)
+	Quota *quotaStatus `json:"quota"`
+
+	// recalled marks a fallback answer reused while the auth service can't be reached
+	recalled bool
+}
+
+// quotaStatus is a key's long-period request quota as counted by the auth service
+// An exhausted quota comes back as a denied check whose reset is the end of the quota period.
+type quotaStatus struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"`
 }
 
 // CheckRateLimit calls the auth service to check rate limit
-func (client *RateLimitServiceClient) CheckRateLimit(apiKey string) (*checkRateLimitResponse, error) {
-	requestBody := checkRateLimitRequest{APIKey: apiKey}
+// rateLimitClass selects a per-route limit on the auth service; empty uses the key's default limit.
+// cost is the number of requests counted against the limit; costs below 2 count as one request.
+func (client *RateLimitServiceClient) CheckRateLimit(ctx context.Context, apiKey string, rateLimitClass string, cost int) (*checkRateLimitResponse, error) {
+	requestBody := checkRateLimitRequest{APIKey: apiKey, Class: rateLimitClass}
+	if cost > 1 {
+		requestBody.Cost = cost
+	}
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, err
 	}
 
 	url := client.baseURL + "/api/v1/ratelimit/check"
-	resp, err := client.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.httpClient.Do(httpRequest)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// If auth service returns non-200, API key is invalid
+	// A 5xx means the auth service (or its store) failed, not that the key is bad
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+
+	// If auth service returns any other non-200, API key is invalid
 	if resp.StatusCode != http.StatusOK {
 		return &checkRateLimitResponse{
 			Allowed:   false,
@@ -73,8 +190,253 @@ func (client *RateLimitServiceClient) CheckRateLimit(apiKey string) (*checkRateL
 	return &response, nil
 }
 
+// check runs CheckRateLimit, applying the fallback and failure policy when the auth service can't answer
+// While checks fail, a key's last answer younger than the fallback TTL (or any age, under FailStale) is
+// reused: valid keys keep passing with their scopes and IP allowlist, invalid keys stay rejected and
+// keys over their limit stay rejected until its reset. Without a usable answer the error is returned,
+// since letting an unknown key through would ignore the restrictions it may have.
+func (client *RateLimitServiceClient) check(ctx context.Context, apiKey string, rateLimitClass string, cost int) (*checkRateLimitResponse, error) {
+	key := fallbackKey{apiKeyHash: sha256.Sum256([]byte(apiKey)), rateLimitClass: rateLimitClass}
+
+	rateLimitResult, err := client.CheckRateLimit(ctx, apiKey, rateLimitClass, cost)
+	if err == nil {
+		client.remember(key, rateLimitResult)
+		return rateLimitResult, nil
+	}
+	// A client that went away isn't an auth service failure
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	if cachedResult, stale, found := client.recall(key); found {
+		outcome := "cached"
+		if stale {
+			outcome = "stale"
+		}
+		client.recordFailure(err, apiKey, outcome)
+		return cachedResult, nil
+	}
+	client.recordFailure(err, apiKey, "rejected")
+	return nil, err
+}
+
+// remember stores a key's answer for the fallback
+func (client *RateLimitServiceClient) remember(key fallbackKey, rateLimitResult *checkRateLimitResponse) {
+	if client.fallbackTTL <= 0 && client.failurePolicy != FailStale {
+		return
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	now := time.Now()
+	if _, found := client.fallback[key]; !found && len(client.fallback) >= maxFallbackEntries {
+		for storedKey, answer := range client.fallback {
+			if now.Sub(answer.checkedAt) >= client.fallbackTTL {
+				delete(client.fallback, storedKey)
+			}
+		}
+		if len(client.fallback) >= maxFallbackEntries {
+			return
+		}
+	}
+	client.fallback[key] = fallbackAnswer{result: *rateLimitResult, checkedAt: now}
+}
+
+// recall returns a copy of a key's last answer while it is within the fallback TTL, or at any age
+// under FailStale, reporting whether it is past the TTL. A denial whose window has reset since is not reused.
+func (client *RateLimitServiceClient) recall(key fallbackKey) (*checkRateLimitResponse, bool, bool) {
+	client.mutex.Lock()
+	answer, found := client.fallback[key]
+	client.mutex.Unlock()
+
+	now := time.Now()
+	if !found {
+		return nil, false, false
+	}
+	stale := now.Sub(answer.checkedAt) >= client.fallbackTTL
+	if stale && client.failurePolicy != FailStale {
+		return nil, false, false
+	}
+	if !answer.result.Allowed && answer.result.Limit > 0 && answer.result.Reset <= now.Unix() {
+		return nil, false, false
+	}
+	answer.result.recalled = true
+	return &answer.result, stale, true
+}
+
+// recordFailure logs and counts a check the auth service couldn't answer
+func (client *RateLimitServiceClient) recordFailure(err error, apiKey string, outcome string) {
+	log.Warn().
+		Err(err).
+		Str("api_key_fingerprint", apiKeyFingerprint(apiKey)).
+		Str("failure_policy", client.failurePolicy).
+		Str("outcome", outcome).
+		Msg("Rate limit check failed")
+	if client.failures != nil {
+		client.failures.Inc(outcome)
+	}
+}
+
+// withAcceptedKey stores what later middleware needs to know about an accepted API key:
+// its ID, its concurrency limit, its scopes and its IP allowlist
+func withAcceptedKey(request *http.Request, apiKey string, rateLimitResult *checkRateLimitResponse) *http.Request {
+	request = withConcurrencyLimit(withConsumer(request, apiKey), rateLimitResult.MaxConcurrency)
+	return withAllowedIPs(withScopes(request, rateLimitResult.Scopes), rateLimitResult.AllowedIPs)
+}
+
+// withConsumer stores the ID of an accepted API key in the request context
+func withConsumer(request *http.Request, apiKey string) *http.Request {
+	return request.WithContext(identity.WithAPIKeyID(request.Context(), apiKeyFingerprint(apiKey)))
+}
+
+// apiKeyFingerprint returns a short, non-reversible identifier for an API key
+// so events and logs can correlate traffic without exposing the key itself
+func apiKeyFingerprint(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])[:12]
+}
+
+// publishRateLimitExceeded emits a ratelimit.exceeded event for the rejected request
+func publishRateLimitExceeded(eventBus *events.Bus, request *http.Request, apiKey string, rateLimitResult *checkRateLimitResponse) {
+	eventBus.Publish(events.TypeRateLimitExceeded, map[string]interface{}{
+		"apiKeyFingerprint": apiKeyFingerprint(apiKey),
+		"method":            request.Method,
+		"path":              request.URL.Path,
+		"limit":             rateLimitResult.Limit,
+		"reset":             rateLimitResult.Reset,
+	})
+}
+
+// usageThresholds are the percentages of a limit or quota whose crossing publishes a usage.threshold event
+var usageThresholds = []int{80, 100}
+
+// crossedUsageThresholds returns the usage thresholds an accepted request of cost moved past,
+// given the limit and what remained after it. The auth service counts each key's usage in one
+// place, so exactly one request crosses each threshold per window whichever replica serves it.
+func crossedUsageThresholds(limit int, remaining int, cost int) []int {
+	if limit <= 0 {
+		return nil
+	}
+
+	usedAfter := limit - remaining
+	usedBefore := usedAfter - cost
+	var crossed []int
+	for _, percent := range usageThresholds {
+		// Round up, so 80% of a limit of 3 is reached by the third request rather than the second
+		threshold := (limit*percent + 99) / 100
+		if usedBefore < threshold && usedAfter >= threshold {
+			crossed = append(crossed, percent)
+		}
+	}
+	return crossed
+}
+
+// publishUsageThresholds emits a usage.threshold event for each threshold of the key's limit or
+// quota the accepted request crossed, so consumers hear about a key running out before its 429s.
+// Answers reused from the fallback are skipped, since their counts are stale.
+func publishUsageThresholds(eventBus *events.Bus, request *http.Request, apiKey string, rateLimitResult *checkRateLimitResponse, rateLimitClass string, cost int) {
+	if eventBus == nil || rateLimitResult.recalled {
+		return
+	}
+	// A cost below 1 is counted as one request by the auth service
+	cost = max(cost, 1)
+
+	for _, percent := range crossedUsageThresholds(rateLimitResult.Limit, rateLimitResult.Remaining, cost) {
+		eventBus.Publish(events.TypeUsageThreshold, map[string]interface{}{
+			"apiKeyFingerprint": apiKeyFingerprint(apiKey),
+			"kind":              "limit",
+			"policy":            rateLimitPolicy(rateLimitResult, rateLimitClass),
+			"percent":           percent,
+			"limit":             rateLimitResult.Limit,
+			"remaining":         rateLimitResult.Remaining,
+			"reset":             rateLimitResult.Reset,
+			"path":              request.URL.Path,
+		})
+	}
+
+	if quota := rateLimitResult.Quota; quota != nil {
+		for _, percent := range crossedUsageThresholds(quota.Limit, quota.Remaining, cost) {
+			eventBus.Publish(events.TypeUsageThreshold, map[string]interface{}{
+				"apiKeyFingerprint": apiKeyFingerprint(apiKey),
+				"kind":              "quota",
+				"percent":           percent,
+				"limit":             quota.Limit,
+				"remaining":         quota.Remaining,
+				"reset":             quota.Reset,
+				"path":              request.URL.Path,
+			})
+		}
+	}
+}
+
+// setRateLimitHeaders adds the X-RateLimit-* headers and their IETF draft RateLimit-* equivalents
+// The draft's RateLimit-Reset is the number of seconds until the window resets, not a timestamp.
+func setRateLimitHeaders(header http.Header, rateLimitResult *checkRateLimitResponse) {
+	header.Set("X-RateLimit-Limit", strconv.Itoa(rateLimitResult.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(rateLimitResult.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(rateLimitResult.Reset, 10))
+
+	header.Set("RateLimit-Limit", strconv.Itoa(rateLimitResult.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(rateLimitResult.Remaining))
+	header.Set("RateLimit-Reset", strconv.FormatInt(max(rateLimitResult.Reset-time.Now().Unix(), 0), 10))
+
+	// RateLimit-Policy describes the quota, e.g. "100;w=60"; the window is only known when the auth service reports it
+	policy := strconv.Itoa(rateLimitResult.Limit)
+	if rateLimitResult.Window > 0 {
+		policy += ";w=" + strconv.FormatInt(rateLimitResult.Window, 10)
+	}
+	header.Set("RateLimit-Policy", policy)
+
+	if rateLimitResult.Quota != nil {
+		header.Set("X-Quota-Limit", strconv.Itoa(rateLimitResult.Quota.Limit))
+		header.Set("X-Quota-Remaining", strconv.Itoa(rateLimitResult.Quota.Remaining))
+		header.Set("X-Quota-Reset", strconv.FormatInt(rateLimitResult.Quota.Reset, 10))
+	}
+}
+
+// writeRateLimitExceeded rejects a request with 429, Retry-After and the limit's machine-readable details
+func writeRateLimitExceeded(responseWriter http.ResponseWriter, rateLimitResult *checkRateLimitResponse, rateLimitClass string) {
+	retryAfter := rateLimitResult.Reset - time.Now().Unix()
+	if retryAfter < 0 {
+		retryAfter = 1
+	}
+	responseWriter.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+
+	apierrors.WriteError(responseWriter, apierrors.RateLimitExceeded(
+		fmt.Sprintf("Rate limit exceeded. Try again in %d seconds.", retryAfter),
+		apierrors.RateLimitDetails{
+			Limit:     rateLimitResult.Limit,
+			Window:    rateLimitResult.Window,
+			Remaining: rateLimitResult.Remaining,
+			ResetAt:   time.Unix(rateLimitResult.Reset, 0).UTC(),
+			Policy:    rateLimitPolicy(rateLimitResult, rateLimitClass),
+		},
+	))
+}
+
+// rateLimitPolicy names the policy of a rate-limit result: the auth service's policy name
+// when it sends one, otherwise the route's rate-limit class or "default"
+func rateLimitPolicy(rateLimitResult *checkRateLimitResponse, rateLimitClass string) string {
+	switch {
+	case rateLimitResult.Policy != "":
+		return rateLimitResult.Policy
+	case rateLimitClass != "":
+		return rateLimitClass
+	default:
+		return "default"
+	}
+}
+
 // RateLimitMiddleware creates middleware that enforces rate limiting via auth service
-func RateLimitMiddleware(rateLimitClient *RateLimitServiceClient) func(http.Handler) http.Handler {
+// Rejected requests and crossed usage thresholds are published to eventBus, which may be nil
+func RateLimitMiddleware(rateLimitClient *RateLimitServiceClient, eventBus *events.Bus) func(http.Handler) http.Handler {
+	return RateLimitClassMiddleware(rateLimitClient, eventBus, "", 1)
+}
+
+// RateLimitClassMiddleware is RateLimitMiddleware counting requests against a named rate-limit class
+// Each request counts as cost requests, so expensive routes use up the limit faster.
+func RateLimitClassMiddleware(rateLimitClient *RateLimitServiceClient, eventBus *events.Bus, rateLimitClass string, cost int) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
 			// Extract API key from header
@@ -91,16 +453,20 @@ func RateLimitMiddleware(rateLimitClient *RateLimitServiceClient) func(http.Hand
 			}
 
 			// Check rate limit via auth service
-			rateLimitResult, err := rateLimitClient.CheckRateLimit(apiKey)
+			rateLimitResult, err := rateLimitClient.check(request.Context(), apiKey, rateLimitClass, cost)
 			if err != nil {
 				apierrors.WriteError(responseWriter, apierrors.InternalError("Rate limit check failed"))
 				return
 			}
 
+			// Exempt keys (internal services, monitoring probes) have no limit to report or enforce
+			if rateLimitResult.Exempt {
+				next.ServeHTTP(responseWriter, withAcceptedKey(request, apiKey, rateLimitResult))
+				return
+			}
+
 			// Add rate limit headers to response
-			responseWriter.Header().Set("X-RateLimit-Limit", strconv.Itoa(rateLimitResult.Limit))
-			responseWriter.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rateLimitResult.Remaining))
-			responseWriter.Header().Set("X-RateLimit-Reset", strconv.FormatInt(rateLimitResult.Reset, 10))
+			setRateLimitHeaders(responseWriter.Header(), rateLimitResult)
 
 			// If API key is invalid (Limit is 0), reject
 			if rateLimitResult.Limit == 0 {
@@ -114,50 +480,57 @@ func RateLimitMiddleware(rateLimitClient *RateLimitServiceClient) func(http.Hand
 
 			// If rate limit exceeded, reject with 429
 			if !rateLimitResult.Allowed {
-				retryAfter := rateLimitResult.Reset - time.Now().Unix()
-				if retryAfter < 0 {
-					retryAfter = 1
-				}
-				responseWriter.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
-
-				apierrors.WriteError(responseWriter, apierrors.NewAPIError(
-					apierrors.ErrCodeRateLimitExceeded,
-					fmt.Sprintf("Rate limit exceeded. Try again in %d seconds.", retryAfter),
-					http.StatusTooManyRequests,
-				))
+				publishRateLimitExceeded(eventBus, request, apiKey, rateLimitResult)
+				writeRateLimitExceeded(responseWriter, rateLimitResult, rateLimitClass)
 				return
 			}
 
 			// Request allowed, proceed to next handler
-			next.ServeHTTP(responseWriter, request)
+			publishUsageThresholds(eventBus, request, apiKey, rateLimitResult, rateLimitClass, cost)
+			next.ServeHTTP(responseWriter, withAcceptedKey(request, apiKey, rateLimitResult))
 		})
 	}
 }
 
 // OptionalRateLimitMiddleware creates middleware that enforces rate limiting only if API key is provided
-func OptionalRateLimitMiddleware(rateLimitClient *RateLimitServiceClient) func(http.Handler) http.Handler {
+// Rejected requests and crossed usage thresholds are published to eventBus, which may be nil
+func OptionalRateLimitMiddleware(rateLimitClient *RateLimitServiceClient, eventBus *events.Bus) func(http.Handler) http.Handler {
+	return OptionalRateLimitClassMiddleware(rateLimitClient, eventBus, "", 1, nil)
+}
+
+// OptionalRateLimitClassMiddleware is OptionalRateLimitMiddleware counting requests against a named rate-limit class
+// Each request counts as cost requests. Requests without an API key are limited per client IP by
+// ipLimiter at the same cost, or let through when it is nil.
+func OptionalRateLimitClassMiddleware(rateLimitClient *RateLimitServiceClient, eventBus *events.Bus, rateLimitClass string, cost int, ipLimiter *IPRateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
 			// Extract API key from header
 			apiKey := request.Header.Get("X-API-Key")
 
-			// If no API key provided, allow request without rate limiting
+			// If no API key provided, only the per-IP limit applies
 			if apiKey == "" {
+				if ipLimiter != nil && !ipLimiter.allow(responseWriter, request, cost) {
+					return
+				}
 				next.ServeHTTP(responseWriter, request)
 				return
 			}
 
 			// Check rate limit via auth service
-			rateLimitResult, err := rateLimitClient.CheckRateLimit(apiKey)
+			rateLimitResult, err := rateLimitClient.check(request.Context(), apiKey, rateLimitClass, cost)
 			if err != nil {
 				apierrors.WriteError(responseWriter, apierrors.InternalError("Rate limit check failed"))
 				return
 			}
 
+			// Exempt keys (internal services, monitoring probes) have no limit to report or enforce
+			if rateLimitResult.Exempt {
+				next.ServeHTTP(responseWriter, withAcceptedKey(request, apiKey, rateLimitResult))
+				return
+			}
+
 			// Add rate limit headers to response
-			responseWriter.Header().Set("X-RateLimit-Limit", strconv.Itoa(rateLimitResult.Limit))
-			responseWriter.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rateLimitResult.Remaining))
-			responseWriter.Header().Set("X-RateLimit-Reset", strconv.FormatInt(rateLimitResult.Reset, 10))
+			setRateLimitHeaders(responseWriter.Header(), rateLimitResult)
 
 			// If API key is invalid, reject
 			if rateLimitResult.Limit == 0 {
@@ -171,16 +544,13 @@ func OptionalRateLimitMiddleware(rateLimitClient *RateLimitServiceClient) func(h
 
 			// If rate limit exceeded, reject with 429
 			if !rateLimitResult.Allowed {
-				responseWriter.Header().Set("Retry-After", strconv.FormatInt(rateLimitResult.Reset, 10))
-				apierrors.WriteError(responseWriter, apierrors.NewAPIError(
-					apierrors.ErrCodeRateLimitExceeded,
-					"Rate limit exceeded.",
-					http.StatusTooManyRequests,
-				))
+				publishRateLimitExceeded(eventBus, request, apiKey, rateLimitResult)
+				writeRateLimitExceeded(responseWriter, rateLimitResult, rateLimitClass)
 				return
 			}
 
-			next.ServeHTTP(responseWriter, request)
+			publishUsageThresholds(eventBus, request, apiKey, rateLimitResult, rateLimitClass, cost)
+			next.ServeHTTP(responseWriter, withAcceptedKey(request, apiKey, rateLimitResult))
 		})
 	}
 }
@@ -0,0 +1,383 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// newMockAuthService starts an auth service answering every rate-limit check with result
+func newMockAuthService(t *testing.T, result checkRateLimitResponse) *RateLimitServiceClient {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(result)
+	}))
+	t.Cleanup(mockServer.Close)
+	return NewRateLimitServiceClient(mockServer.URL)
+}
+
+// TestRateLimitMiddleware_Headers tests that accepted requests get both header families
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Unix()
+	client := newMockAuthService(t, checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 42, Reset: reset})
+	handler := RateLimitMiddleware(client, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("X-API-Key", "test-key")
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	expectedHeaders := map[string]string{
+		"X-RateLimit-Limit":     "100",
+		"X-RateLimit-Remaining": "42",
+		"X-RateLimit-Reset":     strconv.FormatInt(reset, 10),
+		"RateLimit-Limit":       "100",
+		"RateLimit-Remaining":   "42",
+		"RateLimit-Policy":      "100",
+	}
+	for name, expected := range expectedHeaders {
+		if responseRecorder.Header().Get(name) != expected {
+			t.Errorf("Expected %s '%s', got '%s'", name, expected, responseRecorder.Header().Get(name))
+		}
+	}
+
+	// RateLimit-Reset counts seconds until the reset, allowing for a second boundary during the test
+	if resetSeconds, _ := strconv.Atoi(responseRecorder.Header().Get("RateLimit-Reset")); resetSeconds < 29 || resetSeconds > 30 {
+		t.Errorf("Expected RateLimit-Reset of about 30 seconds, got '%s'", responseRecorder.Header().Get("RateLimit-Reset"))
+	}
+}
+
+// TestRateLimitMiddleware_QuotaHeaders tests that a quota reported by the auth service is relayed
+func TestRateLimitMiddleware_QuotaHeaders(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Unix()
+	quotaReset := time.Now().Add(10 * 24 * time.Hour).Unix()
+	client := newMockAuthService(t, checkRateLimitResponse{
+		Allowed: true, Limit: 100, Remaining: 42, Reset: reset,
+		Quota: &quotaStatus{Limit: 100000, Remaining: 31337, Reset: quotaReset},
+	})
+	handler := RateLimitMiddleware(client, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("X-API-Key", "test-key")
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	expectedHeaders := map[string]string{
+		"X-Quota-Limit":     "100000",
+		"X-Quota-Remaining": "31337",
+		"X-Quota-Reset":     strconv.FormatInt(quotaReset, 10),
+	}
+	for name, expected := range expectedHeaders {
+		if responseRecorder.Header().Get(name) != expected {
+			t.Errorf("Expected %s '%s', got '%s'", name, expected, responseRecorder.Header().Get(name))
+		}
+	}
+
+	client = newMockAuthService(t, checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 42, Reset: reset})
+	handler = RateLimitMiddleware(client, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Header().Get("X-Quota-Limit") != "" {
+		t.Error("Expected no quota headers when the auth service reports no quota")
+	}
+}
+
+// TestRateLimitMiddleware_Exempt tests that exempt keys pass without rate limit headers
+func TestRateLimitMiddleware_Exempt(t *testing.T) {
+	client := newMockAuthService(t, checkRateLimitResponse{Allowed: true, Exempt: true})
+	for _, rateLimitMiddleware := range []func(http.Handler) http.Handler{
+		RateLimitMiddleware(client, nil),
+		OptionalRateLimitMiddleware(client, nil),
+	} {
+		var consumer string
+		handler := rateLimitMiddleware(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			consumer = identity.APIKeyID(request.Context())
+		}))
+
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+		request.Header.Set("X-API-Key", "monitoring-key")
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+
+		if responseRecorder.Code != http.StatusOK || consumer == "" {
+			t.Errorf("Expected the exempt key to be accepted despite a zero limit, got %d", responseRecorder.Code)
+		}
+		if responseRecorder.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("Expected no rate limit headers for an exempt key, got %v", responseRecorder.Header())
+		}
+	}
+}
+
+// TestRateLimitMiddleware_ExceededBody tests the structured 429 body
+func TestRateLimitMiddleware_ExceededBody(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	client := newMockAuthService(t, checkRateLimitResponse{Allowed: false, Limit: 100, Remaining: 0, Reset: reset, Window: 3600})
+	handler := RateLimitClassMiddleware(client, nil, "lookups", 1)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Expected the request to be rejected")
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ranked", nil)
+	request.Header.Set("X-API-Key", "test-key")
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", responseRecorder.Code)
+	}
+
+	var errorResponse struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Limit     int       `json:"limit"`
+				Window    int64     `json:"window"`
+				Remaining *int      `json:"remaining"`
+				ResetAt   time.Time `json:"resetAt"`
+				Policy    string    `json:"policy"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(responseRecorder.Body).Decode(&errorResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if errorResponse.Error.Code != string(apierrors.ErrCodeRateLimitExceeded) {
+		t.Errorf("Expected code RATE_LIMIT_EXCEEDED, got '%s'", errorResponse.Error.Code)
+	}
+	if errorResponse.Error.Details.Limit != 100 || errorResponse.Error.Details.Window != 3600 {
+		t.Errorf("Expected limit 100 per 3600s window, got %d per %ds", errorResponse.Error.Details.Limit, errorResponse.Error.Details.Window)
+	}
+	if errorResponse.Error.Details.Remaining == nil || *errorResponse.Error.Details.Remaining != 0 {
+		t.Errorf("Expected remaining 0, got %v", errorResponse.Error.Details.Remaining)
+	}
+	if errorResponse.Error.Details.ResetAt.Unix() != reset {
+		t.Errorf("Expected resetAt %d, got %v", reset, errorResponse.Error.Details.ResetAt)
+	}
+	if errorResponse.Error.Details.Policy != "lookups" {
+		t.Errorf("Expected policy 'lookups', got '%s'", errorResponse.Error.Details.Policy)
+	}
+}
+
+// TestRateLimitMiddleware_FailurePolicy tests requests while the auth service returns 5xx
+func TestRateLimitMiddleware_FailurePolicy(t *testing.T) {
+	var failing atomic.Bool
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if failing.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(writer).Encode(checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Now().Add(time.Minute).Unix()})
+	}))
+	defer mockServer.Close()
+
+	send := func(client *RateLimitServiceClient, apiKey string) int {
+		handler := RateLimitMiddleware(client, nil)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+		request.Header.Set("X-API-Key", apiKey)
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+		return responseRecorder.Code
+	}
+
+	registry := metrics.NewRegistry()
+	closedClient := NewRateLimitServiceClientWithConfig(RateLimitClientConfig{BaseURL: mockServer.URL, FallbackTTL: time.Minute, MetricsRegistry: registry})
+	staleClient := NewRateLimitServiceClientWithConfig(RateLimitClientConfig{BaseURL: mockServer.URL, FailurePolicy: FailStale})
+
+	if status := send(closedClient, "known-key"); status != http.StatusOK {
+		t.Fatalf("Expected status %d while the auth service is up, got %d", http.StatusOK, status)
+	}
+
+	failing.Store(true)
+	if status := send(closedClient, "known-key"); status != http.StatusOK {
+		t.Errorf("Expected the last answer to be reused (status %d), got %d", http.StatusOK, status)
+	}
+	if status := send(closedClient, "unknown-key"); status != http.StatusInternalServerError {
+		t.Errorf("Expected status %d when failing closed, got %d", http.StatusInternalServerError, status)
+	}
+	if status := send(staleClient, "unknown-key"); status != http.StatusInternalServerError {
+		t.Errorf("Expected status %d for a key without a remembered answer under the stale policy, got %d", http.StatusInternalServerError, status)
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{`opgl_gateway_ratelimit_check_failures_total{outcome="cached"} 1`, `opgl_gateway_ratelimit_check_failures_total{outcome="rejected"} 1`} {
+		if !strings.Contains(responseRecorder.Body.String(), expected) {
+			t.Errorf("Expected metrics to contain %s", expected)
+		}
+	}
+}
+
+// TestRateLimitMiddleware_FailStaleKeepsRestrictions tests that reusing stale answers never treats a key as unrestricted
+func TestRateLimitMiddleware_FailStaleKeepsRestrictions(t *testing.T) {
+	var failing atomic.Bool
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if failing.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(writer).Encode(checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Now().Add(time.Minute).Unix(), Scopes: []string{ScopeSummoner}})
+	}))
+	defer mockServer.Close()
+
+	// A fallback TTL of 1ns makes every remembered answer stale, so only the stale policy reuses it
+	client := NewRateLimitServiceClientWithConfig(RateLimitClientConfig{BaseURL: mockServer.URL, FailurePolicy: FailStale, FallbackTTL: time.Nanosecond})
+	handler := RateLimitMiddleware(client, nil)(ScopeMiddleware(ScopeAnalyze)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})))
+	send := func(apiKey string) int {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil)
+		request.Header.Set("X-API-Key", apiKey)
+		responseRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(responseRecorder, request)
+		return responseRecorder.Code
+	}
+
+	send("read-only-key")
+	failing.Store(true)
+
+	if status := send("read-only-key"); status != http.StatusForbidden {
+		t.Errorf("Expected the remembered scopes to reject /analyze with status %d, got %d", http.StatusForbidden, status)
+	}
+	if status := send("made-up-key"); status != http.StatusInternalServerError {
+		t.Errorf("Expected an unknown key to be rejected with status %d, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+// TestRateLimitMiddleware_Cost tests that route costs above 1 are sent to the auth service
+func TestRateLimitMiddleware_Cost(t *testing.T) {
+	var checkRequests []checkRateLimitRequest
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var checkRequest checkRateLimitRequest
+		json.NewDecoder(request.Body).Decode(&checkRequest)
+		checkRequests = append(checkRequests, checkRequest)
+		json.NewEncoder(writer).Encode(checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 90, Reset: time.Now().Add(time.Minute).Unix()})
+	}))
+	defer mockServer.Close()
+	client := NewRateLimitServiceClient(mockServer.URL)
+
+	for _, cost := range []int{10, 1} {
+		handler := RateLimitClassMiddleware(client, nil, "analysis", cost)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil)
+		request.Header.Set("X-API-Key", "test-key")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	if len(checkRequests) != 2 {
+		t.Fatalf("Expected 2 rate-limit checks, got %d", len(checkRequests))
+	}
+	if checkRequests[0].Cost != 10 || checkRequests[0].Class != "analysis" {
+		t.Errorf("Expected cost 10 for class analysis, got %+v", checkRequests[0])
+	}
+	if checkRequests[1].Cost != 0 {
+		t.Errorf("Expected the cost to be omitted for a cost of 1, got %d", checkRequests[1].Cost)
+	}
+}
+
+// recordingPublisher is an events.Publisher that stores published events for assertions
+type recordingPublisher struct {
+	mutex     sync.Mutex
+	published []*events.Event
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, event *events.Event) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.published = append(publisher.published, event)
+	return nil
+}
+
+func (publisher *recordingPublisher) Close() error {
+	return nil
+}
+
+// TestCrossedUsageThresholds tests which thresholds a request crosses for its cost
+func TestCrossedUsageThresholds(t *testing.T) {
+	testCases := []struct {
+		name      string
+		limit     int
+		remaining int
+		cost      int
+		expected  []int
+	}{
+		{"below 80%", 100, 21, 1, nil},
+		{"reaches 80%", 100, 20, 1, []int{80}},
+		{"past 80% already", 100, 19, 1, nil},
+		{"reaches 100%", 100, 0, 1, []int{100}},
+		{"costly request crosses both", 100, 0, 25, []int{80, 100}},
+		{"rounds the threshold up", 3, 0, 1, []int{80, 100}},
+		{"invalid key", 0, 0, 1, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			crossed := crossedUsageThresholds(testCase.limit, testCase.remaining, testCase.cost)
+			if !slices.Equal(crossed, testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, crossed)
+			}
+		})
+	}
+}
+
+// TestRateLimitMiddleware_UsageThresholds tests that crossing a threshold of the limit or quota publishes an event
+func TestRateLimitMiddleware_UsageThresholds(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	client := newMockAuthService(t, checkRateLimitResponse{
+		Allowed: true, Limit: 10, Remaining: 2, Reset: reset,
+		Quota: &quotaStatus{Limit: 1000, Remaining: 500, Reset: reset},
+	})
+
+	publisher := &recordingPublisher{}
+	eventBus := events.NewBus(10, publisher)
+	handler := RateLimitMiddleware(client, eventBus)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("X-API-Key", "test-key")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Close drains the queue so the event is guaranteed to be delivered
+	eventBus.Close()
+
+	if len(publisher.published) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(publisher.published))
+	}
+	event := publisher.published[0]
+	if event.Type != events.TypeUsageThreshold {
+		t.Errorf("Expected event type '%s', got '%s'", events.TypeUsageThreshold, event.Type)
+	}
+	if event.Data["kind"] != "limit" || event.Data["percent"] != 80 || event.Data["policy"] != "default" {
+		t.Errorf("Expected the default limit's 80%% threshold, got %v", event.Data)
+	}
+	if event.Data["apiKeyFingerprint"] != apiKeyFingerprint("test-key") {
+		t.Errorf("Expected the key's fingerprint, got %v", event.Data["apiKeyFingerprint"])
+	}
+}
+
+// TestRateLimitMiddleware_UsageThresholdsZeroCost tests that a route without a cost publishes like a cost of one
+func TestRateLimitMiddleware_UsageThresholdsZeroCost(t *testing.T) {
+	client := newMockAuthService(t, checkRateLimitResponse{
+		Allowed: true, Limit: 10, Remaining: 2, Reset: time.Now().Add(time.Minute).Unix(),
+	})
+
+	publisher := &recordingPublisher{}
+	eventBus := events.NewBus(10, publisher)
+	handler := RateLimitClassMiddleware(client, eventBus, "", 0)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil)
+	request.Header.Set("X-API-Key", "test-key")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	eventBus.Close()
+
+	if len(publisher.published) != 1 || publisher.published[0].Data["percent"] != 80 {
+		t.Errorf("Expected the 80%% threshold to be published, got %d events", len(publisher.published))
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID
+// A well-formed X-Request-ID from the client (or an upstream proxy) is kept so IDs
+// can be correlated across hops; otherwise a new UUID is generated. The ID is echoed
+// in the X-Request-ID response header, included in error bodies, and logged.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestID := request.Header.Get(apierrors.RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		writer.Header().Set(apierrors.RequestIDHeader, requestID)
+		next.ServeHTTP(writer, request.WithContext(identity.WithRequestID(request.Context(), requestID)))
+	})
+}
+
+// isValidRequestID accepts non-empty IDs of printable ASCII without spaces
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// TestRequestIDMiddleware_GeneratesID tests that a request without an ID gets a new one
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	var contextRequestID string
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contextRequestID = identity.RequestID(request.Context())
+	})
+
+	recorder := httptest.NewRecorder()
+	RequestIDMiddleware(nextHandler).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/health", nil))
+
+	headerRequestID := recorder.Header().Get("X-Request-ID")
+	if headerRequestID == "" {
+		t.Fatal("Expected X-Request-ID header to be set")
+	}
+
+	if contextRequestID != headerRequestID {
+		t.Errorf("Expected context request ID '%s', got '%s'", headerRequestID, contextRequestID)
+	}
+}
+
+// TestRequestIDMiddleware_KeepsClientID tests that a well-formed client ID is reused
+func TestRequestIDMiddleware_KeepsClientID(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})
+
+	request := httptest.NewRequest(http.MethodPost, "/health", nil)
+	request.Header.Set("X-Request-ID", "client-abc-123")
+	recorder := httptest.NewRecorder()
+	RequestIDMiddleware(nextHandler).ServeHTTP(recorder, request)
+
+	if requestID := recorder.Header().Get("X-Request-ID"); requestID != "client-abc-123" {
+		t.Errorf("Expected request ID 'client-abc-123', got '%s'", requestID)
+	}
+}
+
+// TestRequestIDMiddleware_ReplacesInvalidID tests that malformed client IDs are replaced
+func TestRequestIDMiddleware_ReplacesInvalidID(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})
+
+	invalidIDs := []string{"has space", strings.Repeat("a", 129), "tab\tid"}
+	for _, invalidID := range invalidIDs {
+		request := httptest.NewRequest(http.MethodPost, "/health", nil)
+		request.Header.Set("X-Request-ID", invalidID)
+		recorder := httptest.NewRecorder()
+		RequestIDMiddleware(nextHandler).ServeHTTP(recorder, request)
+
+		if requestID := recorder.Header().Get("X-Request-ID"); requestID == invalidID || requestID == "" {
+			t.Errorf("Expected invalid ID %q to be replaced, got '%s'", invalidID, requestID)
+		}
+	}
+}
+
+// TestRequestIDMiddleware_ErrorBodyIncludesID tests that error responses carry the request ID
+func TestRequestIDMiddleware_ErrorBodyIncludesID(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		apierrors.WriteError(writer, apierrors.InternalError("boom"))
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/health", nil)
+	request.Header.Set("X-Request-ID", "support-ticket-id")
+	recorder := httptest.NewRecorder()
+	RequestIDMiddleware(nextHandler).ServeHTTP(recorder, request)
+
+	if !strings.Contains(recorder.Body.String(), `"requestId":"support-ticket-id"`) {
+		t.Errorf("Expected error body to include requestId, got %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// Scopes of the built-in API routes
+const (
+	ScopeSummoner = "summoner"
+	ScopeMatches  = "matches"
+	ScopeAnalyze  = "analyze"
+)
+
+// scopesContextKey is the context key for the accepted API key's scopes
+type scopesContextKey struct{}
+
+// withScopes stores the scopes the auth service reported for the request's API key
+// Keys without scopes are unrestricted, so nothing is stored for them.
+func withScopes(request *http.Request, scopes []string) *http.Request {
+	if len(scopes) == 0 {
+		return request
+	}
+	return request.WithContext(context.WithValue(request.Context(), scopesContextKey{}, scopes))
+}
+
+// ScopeMiddleware creates middleware that rejects requests with INSUFFICIENT_SCOPE (403) when their
+// API key is restricted to scopes that don't include the route's scope, e.g. a read-only key with
+// ["summoner", "matches"] calling /analyze. Unrestricted keys and requests without an accepted
+// API key pass through, so the middleware must run after rate limiting.
+func ScopeMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			scopes, restricted := request.Context().Value(scopesContextKey{}).([]string)
+			if restricted && !slices.Contains(scopes, scope) {
+				apierrors.WriteError(writer, apierrors.InsufficientScope(scope))
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
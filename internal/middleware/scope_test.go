@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// TestScopeMiddleware tests that scoped keys are limited to their scopes and other keys are not
+func TestScopeMiddleware(t *testing.T) {
+	testCases := []struct {
+		name           string
+		scopes         []string
+		expectedStatus int
+	}{
+		{"unrestricted key", nil, http.StatusOK},
+		{"key holding the scope", []string{ScopeSummoner, ScopeAnalyze}, http.StatusOK},
+		{"read-only key", []string{ScopeSummoner, ScopeMatches}, http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newMockAuthService(t, checkRateLimitResponse{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Now().Add(time.Minute).Unix(), Scopes: testCase.scopes})
+			handler := RateLimitMiddleware(client, nil)(ScopeMiddleware(ScopeAnalyze)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusOK)
+			})))
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil)
+			request.Header.Set("X-API-Key", "test-key")
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			if responseRecorder.Code != testCase.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", testCase.expectedStatus, responseRecorder.Code)
+			}
+			if testCase.expectedStatus == http.StatusForbidden {
+				var response apierrors.ErrorResponse
+				json.NewDecoder(responseRecorder.Body).Decode(&response)
+				if response.Error.Code != apierrors.ErrCodeInsufficientScope {
+					t.Errorf("Expected error code %s, got %s", apierrors.ErrCodeInsufficientScope, response.Error.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// Refresh cookie settings
+const (
+	// RefreshCookieName holds the refresh token in cookie session mode
+	RefreshCookieName = "opgl_refresh"
+	// refreshCookiePath limits the refresh cookie to the session endpoints
+	refreshCookiePath = "/api/v1/auth"
+	// maxAuthResponseBytes bounds the auth service responses read by the session endpoints
+	maxAuthResponseBytes = 1 << 20
+)
+
+// sessionTokenFields are the auth service response fields moved into cookies
+var sessionTokenFields = []string{"accessToken", "refreshToken", "expiresIn", "refreshExpiresIn"}
+
+// postJSON sends a JSON body to an auth service endpoint and returns the raw response
+func (client *AuthServiceClient) postJSON(ctx context.Context, path string, body []byte) (int, []byte, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.httpClient.Do(httpRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxAuthResponseBytes))
+	return resp.StatusCode, responseBody, err
+}
+
+// SessionHandler serves the cookie session endpoints in front of the auth service
+// Login and refresh responses from the auth service are rewritten so the access and refresh
+// tokens travel in HttpOnly cookies instead of the JSON body, out of reach of page scripts.
+type SessionHandler struct {
+	authClient *AuthServiceClient
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(authClient *AuthServiceClient) *SessionHandler {
+	return &SessionHandler{authClient: authClient}
+}
+
+// Login forwards credentials to the auth service and stores the issued tokens in cookies
+func (handler *SessionHandler) Login(writer http.ResponseWriter, request *http.Request) {
+	body, ok := bufferRequestBody(writer, request)
+	if !ok {
+		return
+	}
+
+	handler.exchange(writer, request, "/api/v1/auth/login", body)
+}
+
+// Refresh exchanges the refresh cookie for new session cookies
+func (handler *SessionHandler) Refresh(writer http.ResponseWriter, request *http.Request) {
+	refreshCookie, err := request.Cookie(RefreshCookieName)
+	if err != nil || refreshCookie.Value == "" {
+		apierrors.WriteError(writer, apierrors.NewAPIError(
+			apierrors.ErrCodeUnauthorized,
+			"Refresh cookie is required",
+			http.StatusUnauthorized,
+		))
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{"refreshToken": refreshCookie.Value})
+	if err != nil {
+		apierrors.WriteError(writer, apierrors.InternalError("Failed to build refresh request"))
+		return
+	}
+
+	handler.exchange(writer, request, "/api/v1/auth/refresh", body)
+}
+
+// Logout revokes the refresh token (best effort) and clears the session cookies
+func (handler *SessionHandler) Logout(writer http.ResponseWriter, request *http.Request) {
+	if refreshCookie, err := request.Cookie(RefreshCookieName); err == nil && refreshCookie.Value != "" {
+		body, _ := json.Marshal(map[string]string{"refreshToken": refreshCookie.Value})
+		handler.authClient.postJSON(request.Context(), "/api/v1/auth/logout", body)
+	}
+
+	clearSessionCookies(writer)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// exchange posts body to the auth service and turns a successful token response into cookies
+// Auth service errors (e.g. INVALID_CREDENTIALS) are relayed unchanged.
+func (handler *SessionHandler) exchange(writer http.ResponseWriter, request *http.Request, path string, body []byte) {
+	statusCode, responseBody, err := handler.authClient.postJSON(request.Context(), path, body)
+	if err != nil {
+		apierrors.WriteError(writer, apierrors.UpstreamError("Failed to reach auth service"))
+		return
+	}
+	if statusCode < 200 || statusCode > 299 {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(statusCode)
+		writer.Write(responseBody)
+		return
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		apierrors.WriteError(writer, apierrors.UpstreamError("Invalid auth service response"))
+		return
+	}
+	accessToken, _ := response["accessToken"].(string)
+	refreshToken, _ := response["refreshToken"].(string)
+	if accessToken == "" {
+		apierrors.WriteError(writer, apierrors.UpstreamError("Auth service response has no access token"))
+		return
+	}
+
+	http.SetCookie(writer, sessionCookie(SessionCookieName, accessToken, "/", response["expiresIn"]))
+	if refreshToken != "" {
+		http.SetCookie(writer, sessionCookie(RefreshCookieName, refreshToken, refreshCookiePath, response["refreshExpiresIn"]))
+	}
+	for _, field := range sessionTokenFields {
+		delete(response, field)
+	}
+
+	// A new session gets a new CSRF token, returned for cross-origin frontends that can't read the cookie
+	csrfToken, err := IssueCSRFToken(writer)
+	if err != nil {
+		apierrors.WriteError(writer, apierrors.InternalError("Failed to issue CSRF token"))
+		return
+	}
+	response["csrfToken"] = csrfToken
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(writer).Encode(response)
+}
+
+// sessionCookie builds an HttpOnly, Secure, SameSite=Strict token cookie
+// expiresIn is the token lifetime in seconds from the auth service response; without it the
+// cookie lasts for the browser session.
+func sessionCookie(name string, value string, path string, expiresIn interface{}) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if seconds, ok := expiresIn.(float64); ok && seconds > 0 {
+		cookie.MaxAge = int(seconds)
+		cookie.Expires = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return cookie
+}
+
+// clearSessionCookies expires the session, refresh and CSRF cookies
+func clearSessionCookies(writer http.ResponseWriter) {
+	for _, cookie := range []*http.Cookie{
+		{Name: SessionCookieName, Path: "/", HttpOnly: true},
+		{Name: RefreshCookieName, Path: refreshCookiePath, HttpOnly: true},
+		{Name: CSRFCookieName, Path: "/"},
+	} {
+		cookie.MaxAge = -1
+		cookie.Secure = true
+		cookie.SameSite = http.SameSiteStrictMode
+		http.SetCookie(writer, cookie)
+	}
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/google/uuid"
+)
+
+// newMockSessionAuthService fakes the auth service's login, refresh, logout and validate endpoints
+func newMockSessionAuthService(t *testing.T, userID string) *AuthServiceClient {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var body map[string]string
+		json.NewDecoder(request.Body).Decode(&body)
+
+		switch request.URL.Path {
+		case "/api/v1/auth/login":
+			if body["password"] != "hunter2" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				writer.Write([]byte(`{"error":{"code":"INVALID_CREDENTIALS","message":"Invalid email or password"}}`))
+				return
+			}
+			json.NewEncoder(writer).Encode(map[string]interface{}{
+				"accessToken": "access-1", "refreshToken": "refresh-1", "expiresIn": 900, "userId": userID,
+			})
+		case "/api/v1/auth/refresh":
+			json.NewEncoder(writer).Encode(map[string]interface{}{
+				"accessToken": "access-2", "refreshToken": body["refreshToken"] + "-rotated",
+			})
+		case "/api/v1/auth/validate":
+			json.NewEncoder(writer).Encode(validateTokenResponse{Valid: strings.HasPrefix(body["token"], "access-"), UserID: userID})
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+	return NewAuthServiceClient(mockServer.URL, nil)
+}
+
+// cookiesByName indexes the cookies set on a response
+func cookiesByName(responseRecorder *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range responseRecorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+// TestSessionHandler_Login tests that tokens move from the JSON body into HttpOnly cookies
+func TestSessionHandler_Login(t *testing.T) {
+	handler := NewSessionHandler(newMockSessionAuthService(t, uuid.NewString()))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.Login(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"a@opgl.gg","password":"hunter2"}`)))
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	var body map[string]interface{}
+	json.Unmarshal(responseRecorder.Body.Bytes(), &body)
+	for _, field := range []string{"accessToken", "refreshToken", "expiresIn"} {
+		if _, exists := body[field]; exists {
+			t.Errorf("Expected %s to be removed from the body", field)
+		}
+	}
+	if body["userId"] == nil || body["csrfToken"] == nil {
+		t.Errorf("Expected userId and csrfToken in the body, got %v", body)
+	}
+
+	cookies := cookiesByName(responseRecorder)
+	session := cookies[SessionCookieName]
+	if session == nil || session.Value != "access-1" || !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteStrictMode || session.MaxAge != 900 {
+		t.Errorf("Expected an HttpOnly, Secure, SameSite=Strict session cookie lasting 900s, got %+v", session)
+	}
+	refresh := cookies[RefreshCookieName]
+	if refresh == nil || refresh.Value != "refresh-1" || !refresh.HttpOnly || refresh.Path != "/api/v1/auth" {
+		t.Errorf("Expected an HttpOnly refresh cookie scoped to /api/v1/auth, got %+v", refresh)
+	}
+	if csrf := cookies[CSRFCookieName]; csrf == nil || csrf.Value != body["csrfToken"] {
+		t.Errorf("Expected the CSRF cookie to match the body token, got %+v", csrf)
+	}
+}
+
+// TestSessionHandler_LoginFailure tests that auth service errors are relayed without cookies
+func TestSessionHandler_LoginFailure(t *testing.T) {
+	handler := NewSessionHandler(newMockSessionAuthService(t, uuid.NewString()))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.Login(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"a@opgl.gg","password":"wrong"}`)))
+
+	if responseRecorder.Code != http.StatusUnauthorized || !strings.Contains(responseRecorder.Body.String(), "INVALID_CREDENTIALS") {
+		t.Errorf("Expected relayed 401 INVALID_CREDENTIALS, got %d: %s", responseRecorder.Code, responseRecorder.Body.String())
+	}
+	if len(responseRecorder.Result().Cookies()) != 0 {
+		t.Errorf("Expected no cookies, got %v", responseRecorder.Result().Cookies())
+	}
+}
+
+// TestSessionHandler_LoginOversizedBody tests that oversized login bodies are rejected instead of truncated
+func TestSessionHandler_LoginOversizedBody(t *testing.T) {
+	handler := NewSessionHandler(newMockSessionAuthService(t, uuid.NewString()))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.Login(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(strings.Repeat("a", maxBufferedBodyBytes+1))))
+
+	if responseRecorder.Code != http.StatusBadRequest || !strings.Contains(responseRecorder.Body.String(), "INVALID_REQUEST_BODY") {
+		t.Errorf("Expected 400 INVALID_REQUEST_BODY, got %d: %s", responseRecorder.Code, responseRecorder.Body.String())
+	}
+}
+
+// TestSessionHandler_RefreshAndLogout tests refreshing from the refresh cookie and clearing cookies on logout
+func TestSessionHandler_RefreshAndLogout(t *testing.T) {
+	handler := NewSessionHandler(newMockSessionAuthService(t, uuid.NewString()))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.Refresh(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil))
+	if responseRecorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a refresh cookie, got %d", responseRecorder.Code)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	request.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: "refresh-1"})
+	responseRecorder = httptest.NewRecorder()
+	handler.Refresh(responseRecorder, request)
+
+	cookies := cookiesByName(responseRecorder)
+	if cookies[SessionCookieName] == nil || cookies[SessionCookieName].Value != "access-2" {
+		t.Errorf("Expected refreshed session cookie, got %+v", cookies[SessionCookieName])
+	}
+	if cookies[RefreshCookieName] == nil || cookies[RefreshCookieName].Value != "refresh-1-rotated" {
+		t.Errorf("Expected rotated refresh cookie, got %+v", cookies[RefreshCookieName])
+	}
+
+	responseRecorder = httptest.NewRecorder()
+	handler.Logout(responseRecorder, request)
+	if responseRecorder.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", responseRecorder.Code)
+	}
+	for _, name := range []string{SessionCookieName, RefreshCookieName, CSRFCookieName} {
+		if cookie := cookiesByName(responseRecorder)[name]; cookie == nil || cookie.MaxAge >= 0 {
+			t.Errorf("Expected %s to be cleared, got %+v", name, cookie)
+		}
+	}
+}
+
+// TestAuthMiddleware_CookieOrBearer tests that the access token is accepted from either source
+func TestAuthMiddleware_CookieOrBearer(t *testing.T) {
+	userID := uuid.NewString()
+	handler := AuthMiddleware(newMockSessionAuthService(t, userID))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if contextUserID, _ := identity.UserID(request.Context()); contextUserID.String() != userID {
+			t.Errorf("Expected user %s in context, got %v", userID, contextUserID)
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	cookieRequest := httptest.NewRequest(http.MethodPost, "/", nil)
+	cookieRequest.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "access-1"})
+	bearerRequest := httptest.NewRequest(http.MethodPost, "/", nil)
+	bearerRequest.Header.Set("Authorization", "Bearer access-1")
+	invalidCookieRequest := httptest.NewRequest(http.MethodPost, "/", nil)
+	invalidCookieRequest.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "expired"})
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		expectedStatus int
+	}{
+		{"session cookie", cookieRequest, http.StatusOK},
+		{"bearer token", bearerRequest, http.StatusOK},
+		{"invalid session cookie", invalidCookieRequest, http.StatusUnauthorized},
+		{"no credentials", httptest.NewRequest(http.MethodPost, "/", nil), http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, testCase.request)
+
+			if responseRecorder.Code != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got %d", testCase.expectedStatus, responseRecorder.Code)
+			}
+		})
+	}
+}
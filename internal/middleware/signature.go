@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+)
+
+// Request signing headers
+const (
+	SignatureHeader          = "X-OPGL-Signature"
+	SignatureTimestampHeader = "X-OPGL-Timestamp"
+	SignatureNonceHeader     = "X-OPGL-Nonce"
+)
+
+// Nonce length bounds for signed requests
+const (
+	minNonceLength = 16
+	maxNonceLength = 128
+)
+
+// SignatureVerifier holds request-signing policy
+type SignatureVerifier struct {
+	// requiredKeyHashes contains SHA-256 hex digests of API keys that must sign every request
+	requiredKeyHashes map[string]bool
+	// maxSkew is the largest allowed difference between the signed timestamp and now
+	maxSkew time.Duration
+	// nonceStore rejects nonces that were already used within the replay window
+	nonceStore NonceStore
+}
+
+// NewSignatureVerifier creates a new SignatureVerifier
+// requiredKeyHashes are SHA-256 hex digests of API keys (as stored by the auth service)
+func NewSignatureVerifier(requiredKeyHashes []string, maxSkew time.Duration, nonceStore NonceStore) *SignatureVerifier {
+	verifier := &SignatureVerifier{
+		requiredKeyHashes: make(map[string]bool, len(requiredKeyHashes)),
+		maxSkew:           maxSkew,
+		nonceStore:        nonceStore,
+	}
+	for _, keyHash := range requiredKeyHashes {
+		keyHash = strings.ToLower(strings.TrimSpace(keyHash))
+		if keyHash != "" {
+			verifier.requiredKeyHashes[keyHash] = true
+		}
+	}
+	return verifier
+}
+
+// signatureRequired reports whether the given API key must sign its requests
+func (verifier *SignatureVerifier) signatureRequired(apiKey string) bool {
+	hash := sha256.Sum256([]byte(apiKey))
+	return verifier.requiredKeyHashes[hex.EncodeToString(hash[:])]
+}
+
+// SignatureMiddleware creates middleware that verifies HMAC-signed requests
+// Clients sign "<timestamp>.<method>.<path>.<nonce>.<body>" with their API key as the HMAC secret
+// and send the result in X-OPGL-Signature, the unix timestamp in X-OPGL-Timestamp, and a
+// unique nonce in X-OPGL-Nonce. A nonce may only be used once within the replay window.
+// Signatures are verified whenever present and required for keys configured in the verifier.
+func SignatureMiddleware(verifier *SignatureVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			apiKey := request.Header.Get("X-API-Key")
+			signatureHeader := request.Header.Get(SignatureHeader)
+
+			if signatureHeader == "" {
+				if apiKey != "" && verifier.signatureRequired(apiKey) {
+					apierrors.WriteError(responseWriter, apierrors.SignatureRequired())
+					return
+				}
+				next.ServeHTTP(responseWriter, request)
+				return
+			}
+
+			if apiKey == "" {
+				apierrors.WriteError(responseWriter, apierrors.InvalidSignature("Signed requests must include X-API-Key"))
+				return
+			}
+
+			// Reject stale or future timestamps to limit replay windows
+			timestamp, err := strconv.ParseInt(request.Header.Get(SignatureTimestampHeader), 10, 64)
+			if err != nil {
+				apierrors.WriteError(responseWriter, apierrors.InvalidSignature(SignatureTimestampHeader+" must be a unix timestamp"))
+				return
+			}
+
+			skew := time.Since(time.Unix(timestamp, 0))
+			if skew < 0 {
+				skew = -skew
+			}
+			if skew > verifier.maxSkew {
+				apierrors.WriteError(responseWriter, apierrors.InvalidSignature("Request timestamp is outside the allowed window"))
+				return
+			}
+
+			nonce := request.Header.Get(SignatureNonceHeader)
+			if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+				apierrors.WriteError(responseWriter, apierrors.InvalidSignature(
+					SignatureNonceHeader+" must be between "+strconv.Itoa(minNonceLength)+" and "+strconv.Itoa(maxNonceLength)+" characters"))
+				return
+			}
+
+			// Read the body for verification and restore it for the next handler
+			requestBody, ok := bufferRequestBody(responseWriter, request)
+			if !ok {
+				return
+			}
+
+			message := signing.RequestMessage(request.Method, request.URL.RequestURI(), nonce, requestBody)
+			if !signing.Verify(apiKey, timestamp, message, signatureHeader) {
+				apierrors.WriteError(responseWriter, apierrors.InvalidSignature("Request signature does not match"))
+				return
+			}
+
+			// Record the nonce only after the signature checks out, so forged requests can't burn nonces.
+			// Nonces are kept until the timestamp leaves the skew window, after which the timestamp check rejects replays.
+			nonceExpiry := time.Unix(timestamp, 0).Add(verifier.maxSkew)
+			if !verifier.nonceStore.CheckAndStore(apiKeyFingerprint(apiKey)+":"+nonce, nonceExpiry) {
+				apierrors.WriteError(responseWriter, apierrors.ReplayedRequest())
+				return
+			}
+
+			next.ServeHTTP(responseWriter, request)
+		})
+	}
+}
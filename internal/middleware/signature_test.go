@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+)
+
+const testSigningAPIKey = "partner-api-key"
+
+// hashAPIKey returns the SHA-256 hex digest of an API key
+func hashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// newSignedRequest creates a request signed with the given API key at the given time
+func newSignedRequest(apiKey string, body string, signedAt time.Time) *http.Request {
+	return newSignedRequestWithNonce(apiKey, body, signedAt, "nonce-"+strconv.FormatInt(signedAt.UnixNano(), 10))
+}
+
+// newSignedRequestWithNonce creates a signed request using a specific nonce
+func newSignedRequestWithNonce(apiKey string, body string, signedAt time.Time, nonce string) *http.Request {
+	request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(body))
+	request.Header.Set("X-API-Key", apiKey)
+
+	timestamp := signedAt.Unix()
+	signature := signing.Sign(apiKey, timestamp, signing.RequestMessage("POST", "/api/v1/analyze", nonce, []byte(body)))
+	request.Header.Set(SignatureHeader, signature)
+	request.Header.Set(SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureNonceHeader, nonce)
+	return request
+}
+
+// decodeErrorCode extracts the error code from a recorded error response
+func decodeErrorCode(responseRecorder *httptest.ResponseRecorder) apierrors.ErrorCode {
+	var errorResponse apierrors.ErrorResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&errorResponse)
+	return errorResponse.Error.Code
+}
+
+// TestSignatureMiddleware_ValidSignature tests that a correctly signed request reaches the handler with its body intact
+func TestSignatureMiddleware_ValidSignature(t *testing.T) {
+	var receivedBody string
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		bodyBytes, _ := io.ReadAll(request.Body)
+		receivedBody = string(bodyBytes)
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, newSignedRequest(testSigningAPIKey, `{"region":"na"}`, time.Now()))
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+
+	if receivedBody != `{"region":"na"}` {
+		t.Errorf("Expected body to be passed through, got '%s'", receivedBody)
+	}
+}
+
+// TestSignatureMiddleware_AlteredBody tests that a body changed after signing is rejected
+func TestSignatureMiddleware_AlteredBody(t *testing.T) {
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	request := newSignedRequest(testSigningAPIKey, `{"region":"na"}`, time.Now())
+	request.Body = io.NopCloser(bytes.NewBufferString(`{"region":"kr"}`))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, responseRecorder.Code)
+	}
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeInvalidSignature {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeInvalidSignature, code)
+	}
+}
+
+// TestSignatureMiddleware_AlteredQuery tests that changing the signed query string invalidates the signature
+func TestSignatureMiddleware_AlteredQuery(t *testing.T) {
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	nonce := "nonce-altered-query"
+	timestamp := time.Now().Unix()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/ranked?queue=flex", nil)
+	request.Header.Set("X-API-Key", testSigningAPIKey)
+	request.Header.Set(SignatureHeader, signing.Sign(testSigningAPIKey, timestamp, signing.RequestMessage(http.MethodGet, "/api/v1/ranked?queue=solo", nonce, nil)))
+	request.Header.Set(SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureNonceHeader, nonce)
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeInvalidSignature {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeInvalidSignature, code)
+	}
+}
+
+// TestSignatureMiddleware_OversizedBody tests that bodies over the buffering limit are rejected before verification
+func TestSignatureMiddleware_OversizedBody(t *testing.T) {
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	request := newSignedRequest(testSigningAPIKey, strings.Repeat("a", maxBufferedBodyBytes+1), time.Now())
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeInvalidRequestBody {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeInvalidRequestBody, code)
+	}
+}
+
+// TestSignatureMiddleware_StaleTimestamp tests that signatures outside the skew window are rejected
+func TestSignatureMiddleware_StaleTimestamp(t *testing.T) {
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, newSignedRequest(testSigningAPIKey, `{}`, time.Now().Add(-10*time.Minute)))
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeInvalidSignature {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeInvalidSignature, code)
+	}
+}
+
+// TestSignatureMiddleware_RequiredKeyUnsigned tests that configured keys cannot skip signing
+func TestSignatureMiddleware_RequiredKeyUnsigned(t *testing.T) {
+	verifier := NewSignatureVerifier([]string{hashAPIKey(testSigningAPIKey)}, time.Minute, NewMemoryNonceStore())
+	handler := SignatureMiddleware(verifier)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{}`))
+	request.Header.Set("X-API-Key", testSigningAPIKey)
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeSignatureRequired {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeSignatureRequired, code)
+	}
+}
+
+// TestSignatureMiddleware_OptionalKeyUnsigned tests that other keys may send unsigned requests
+func TestSignatureMiddleware_OptionalKeyUnsigned(t *testing.T) {
+	verifier := NewSignatureVerifier([]string{hashAPIKey(testSigningAPIKey)}, time.Minute, NewMemoryNonceStore())
+	handlerCalled := false
+	handler := SignatureMiddleware(verifier)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		handlerCalled = true
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/analyze", bytes.NewBufferString(`{}`))
+	request.Header.Set("X-API-Key", "regular-api-key")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !handlerCalled {
+		t.Error("Expected unsigned request from non-partner key to pass")
+	}
+}
+
+// TestSignatureMiddleware_ReplayedNonce tests that resending an identical signed request is rejected
+func TestSignatureMiddleware_ReplayedNonce(t *testing.T) {
+	callCount := 0
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		callCount++
+	}))
+
+	signedAt := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), newSignedRequestWithNonce(testSigningAPIKey, `{}`, signedAt, "replayed-nonce-0001"))
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, newSignedRequestWithNonce(testSigningAPIKey, `{}`, signedAt, "replayed-nonce-0001"))
+
+	if callCount != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", callCount)
+	}
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeReplayedRequest {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeReplayedRequest, code)
+	}
+}
+
+// TestSignatureMiddleware_MissingNonce tests that signed requests must carry a nonce
+func TestSignatureMiddleware_MissingNonce(t *testing.T) {
+	handler := SignatureMiddleware(NewSignatureVerifier(nil, time.Minute, NewMemoryNonceStore()))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	request := newSignedRequest(testSigningAPIKey, `{}`, time.Now())
+	request.Header.Del(SignatureNonceHeader)
+
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if code := decodeErrorCode(responseRecorder); code != apierrors.ErrCodeInvalidSignature {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeInvalidSignature, code)
+	}
+}
+
+// TestMemoryNonceStore_CheckAndStore tests first-use and reuse detection
+func TestMemoryNonceStore_CheckAndStore(t *testing.T) {
+	store := NewMemoryNonceStore()
+	expiresAt := time.Now().Add(time.Minute)
+
+	if !store.CheckAndStore("nonce", expiresAt) {
+		t.Error("Expected first use of nonce to succeed")
+	}
+
+	if store.CheckAndStore("nonce", expiresAt) {
+		t.Error("Expected reuse of nonce to fail")
+	}
+
+	if !store.CheckAndStore("expired", time.Now().Add(-time.Second)) {
+		t.Error("Expected first use of expired nonce to succeed")
+	}
+
+	if !store.CheckAndStore("expired", expiresAt) {
+		t.Error("Expected nonce to be reusable after it expired")
+	}
+}
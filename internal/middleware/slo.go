@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/slo"
+	"github.com/gorilla/mux"
+)
+
+// SLOMiddleware creates middleware that records every request against the tracker's SLOs
+// Requests are labelled by route template rather than raw path to keep cardinality bounded.
+func SLOMiddleware(tracker *slo.Tracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			startTime := time.Now()
+			wrappedWriter := newResponseWriter(writer)
+
+			next.ServeHTTP(wrappedWriter, request)
+
+			route := request.URL.Path
+			if currentRoute := mux.CurrentRoute(request); currentRoute != nil {
+				if pathTemplate, err := currentRoute.GetPathTemplate(); err == nil {
+					route = pathTemplate
+				}
+			}
+
+			tracker.Record(route, wrappedWriter.statusCode, time.Since(startTime))
+		})
+	}
+}
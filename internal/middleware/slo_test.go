@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+	"github.com/OPGLOL/opgl-gateway-service/internal/slo"
+	"github.com/gorilla/mux"
+)
+
+// TestSLOMiddleware_RecordsByRouteTemplate tests that requests are recorded against matching SLOs
+func TestSLOMiddleware_RecordsByRouteTemplate(t *testing.T) {
+	registry := metrics.NewRegistry()
+	tracker := slo.NewTracker(registry, slo.Definition{
+		Name:      "availability",
+		Objective: 0.995,
+		Routes:    []string{"/api/v1/summoner"},
+	})
+
+	router := mux.NewRouter()
+	router.Use(SLOMiddleware(tracker))
+	router.HandleFunc("/api/v1/summoner", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadGateway)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/summoner", nil))
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	expectedLine := `opgl_gateway_slo_events_total{slo="availability",result="bad"} 1`
+	if !strings.Contains(recorder.Body.String(), expectedLine) {
+		t.Errorf("Expected metrics to contain %q, got:\n%s", expectedLine, recorder.Body.String())
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+)
+
+// TenantMiddleware creates middleware that resolves the request's Host to a tenant and stores it in
+// the request context, where CORS reads the tenant's allowed origins and handlers its default region.
+// Requests to hosts without a tenant pass through unchanged. It must run outside CORSMiddleware.
+func TenantMiddleware(directory *tenants.Directory) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if tenant := directory.Match(request.Host); tenant != nil {
+				request = request.WithContext(tenants.WithTenant(request.Context(), tenant))
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
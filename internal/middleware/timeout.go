@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// timeoutWriter is a wrapper around http.ResponseWriter that records whether a response was started
+type timeoutWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader records that the response was started and calls the underlying WriteHeader
+func (writer *timeoutWriter) WriteHeader(statusCode int) {
+	writer.wroteHeader = true
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records that the response was started and calls the underlying Write
+func (writer *timeoutWriter) Write(data []byte) (int, error) {
+	writer.wroteHeader = true
+	return writer.ResponseWriter.Write(data)
+}
+
+// TimeoutMiddleware creates middleware that bounds each request with the given deadline
+// Upstream calls observe the deadline through the request context. If the handler
+// returns without responding after the deadline passed, a 504 GATEWAY_TIMEOUT is written.
+// A zero or negative timeout disables the middleware.
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			ctx, cancel := context.WithTimeout(request.Context(), timeout)
+			defer cancel()
+
+			wrappedWriter := &timeoutWriter{ResponseWriter: responseWriter}
+			next.ServeHTTP(wrappedWriter, request.WithContext(ctx))
+
+			if !wrappedWriter.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				apierrors.WriteError(responseWriter, apierrors.GatewayTimeout("Request exceeded the "+timeout.String()+" deadline"))
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// TestTimeoutMiddleware_DeadlineExceeded tests that a handler outliving its deadline yields a 504
+func TestTimeoutMiddleware_DeadlineExceeded(t *testing.T) {
+	handler := TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/analyze", nil)
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status code %d, got %d", http.StatusGatewayTimeout, responseRecorder.Code)
+	}
+
+	var errorResponse apierrors.ErrorResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&errorResponse)
+	if errorResponse.Error.Code != apierrors.ErrCodeGatewayTimeout {
+		t.Errorf("Expected error code '%s', got '%s'", apierrors.ErrCodeGatewayTimeout, errorResponse.Error.Code)
+	}
+}
+
+// TestTimeoutMiddleware_HandlerResponds tests that responses written by the handler are left untouched
+func TestTimeoutMiddleware_HandlerResponds(t *testing.T) {
+	handler := TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+		apierrors.WriteError(writer, apierrors.GatewayTimeout("Upstream timed out"))
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/summoner", nil)
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	var errorResponse apierrors.ErrorResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&errorResponse)
+	if errorResponse.Error.Message != "Upstream timed out" {
+		t.Errorf("Expected handler's error message, got '%s'", errorResponse.Error.Message)
+	}
+}
+
+// TestTimeoutMiddleware_SetsDeadline tests that the request context carries the deadline
+func TestTimeoutMiddleware_SetsDeadline(t *testing.T) {
+	var hasDeadline bool
+	handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, hasDeadline = request.Context().Deadline()
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/summoner", nil)
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if !hasDeadline {
+		t.Error("Expected request context to have a deadline")
+	}
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+}
+
+// TestTimeoutMiddleware_Disabled tests that a zero timeout leaves the request unbounded
+func TestTimeoutMiddleware_Disabled(t *testing.T) {
+	var hasDeadline bool
+	handler := TimeoutMiddleware(0)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, hasDeadline = request.Context().Deadline()
+	}))
+
+	request := httptest.NewRequest("POST", "/api/v1/summoner", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if hasDeadline {
+		t.Error("Expected no deadline when timeout is disabled")
+	}
+}
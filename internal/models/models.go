@@ -22,6 +22,17 @@ type SummonerResponse struct {
 	SummonerLevel int64  `json:"summonerLevel"`
 }
 
+// NewSummonerResponse maps an internal Summoner to its external representation
+func NewSummonerResponse(summoner *Summoner) *SummonerResponse {
+	return &SummonerResponse{
+		ID:            summoner.ID,
+		AccountID:     summoner.AccountID,
+		Name:          summoner.Name,
+		ProfileIconID: summoner.ProfileIconID,
+		SummonerLevel: summoner.SummonerLevel,
+	}
+}
+
 // Match represents a single League of Legends match
 type Match struct {
 	MatchID      string        `json:"matchId"`
@@ -50,6 +61,69 @@ type Participant struct {
 	TeamPosition                string `json:"teamPosition"`
 }
 
+// MatchResponse represents match data returned to external clients
+// Participant PUUIDs are excluded for security reasons
+type MatchResponse struct {
+	MatchID      string                `json:"matchId"`
+	GameCreation time.Time             `json:"gameCreation"`
+	GameDuration int                   `json:"gameDuration"`
+	GameMode     string                `json:"gameMode"`
+	GameType     string                `json:"gameType"`
+	Participants []ParticipantResponse `json:"participants"`
+}
+
+// ParticipantResponse represents a participant returned to external clients (no PUUID)
+type ParticipantResponse struct {
+	SummonerName                string `json:"summonerName"`
+	ChampionID                  int    `json:"championId"`
+	ChampionName                string `json:"championName"`
+	Kills                       int    `json:"kills"`
+	Deaths                      int    `json:"deaths"`
+	Assists                     int    `json:"assists"`
+	GoldEarned                  int    `json:"goldEarned"`
+	TotalDamageDealtToChampions int    `json:"totalDamageDealtToChampions"`
+	TotalDamageTaken            int    `json:"totalDamageTaken"`
+	VisionScore                 int    `json:"visionScore"`
+	TotalMinionsKilled          int    `json:"totalMinionsKilled"`
+	Win                         bool   `json:"win"`
+	TeamPosition                string `json:"teamPosition"`
+}
+
+// NewMatchResponses maps internal matches to their external representation
+func NewMatchResponses(matches []Match) []MatchResponse {
+	matchResponses := make([]MatchResponse, len(matches))
+	for i, match := range matches {
+		participants := make([]ParticipantResponse, len(match.Participants))
+		for j, participant := range match.Participants {
+			participants[j] = ParticipantResponse{
+				SummonerName:                participant.SummonerName,
+				ChampionID:                  participant.ChampionID,
+				ChampionName:                participant.ChampionName,
+				Kills:                       participant.Kills,
+				Deaths:                      participant.Deaths,
+				Assists:                     participant.Assists,
+				GoldEarned:                  participant.GoldEarned,
+				TotalDamageDealtToChampions: participant.TotalDamageDealtToChampions,
+				TotalDamageTaken:            participant.TotalDamageTaken,
+				VisionScore:                 participant.VisionScore,
+				TotalMinionsKilled:          participant.TotalMinionsKilled,
+				Win:                         participant.Win,
+				TeamPosition:                participant.TeamPosition,
+			}
+		}
+
+		matchResponses[i] = MatchResponse{
+			MatchID:      match.MatchID,
+			GameCreation: match.GameCreation,
+			GameDuration: match.GameDuration,
+			GameMode:     match.GameMode,
+			GameType:     match.GameType,
+			Participants: participants,
+		}
+	}
+	return matchResponses
+}
+
 // AnalysisResult contains the complete analysis for a player
 type AnalysisResult struct {
 	PlayerStats      interface{} `json:"playerStats"`
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+)
+
+// Upstream authentication modes
+const (
+	UpstreamAuthNone   = "none"
+	UpstreamAuthBearer = "bearer"
+	UpstreamAuthJWT    = "jwt"
+)
+
+// Claims of gateway-signed upstream tokens: the issuer, and the audience of each internal upstream
+const (
+	upstreamJWTIssuer = "opgl-gateway"
+	audienceData      = "opgl-data"
+	audienceCortex    = "opgl-cortex"
+)
+
+// jwtHeader is the pre-encoded header of gateway-signed upstream tokens
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// UpstreamAuth configures the credentials attached to requests to the data and cortex services
+// External declared-route upstreams never receive them. mTLS client certificates are
+// configured on the transport instead (Config.TLSClientConfig) and combine with any mode.
+type UpstreamAuth struct {
+	// Mode is none, bearer or jwt (empty means none)
+	Mode string
+	// Token is sent as "Authorization: Bearer <token>" in bearer mode
+	Token string
+	// JWTSecret signs short-lived HS256 tokens in jwt mode
+	JWTSecret string
+	// JWTTTL is how long a signed token is valid
+	JWTTTL time.Duration
+}
+
+// credentialTransport attaches upstream credentials to requests for known upstream hosts
+type credentialTransport struct {
+	next http.RoundTripper
+	auth UpstreamAuth
+	// audiences maps "scheme://host" of each internal upstream to the service it belongs to
+	audiences map[string]string
+	now       func() time.Time
+}
+
+// newCredentialTransport wraps next so requests to the given upstreams carry credentials
+// upstreams maps base URLs to the service they belong to (the JWT audience); next is
+// returned unchanged when no credentials are configured.
+func newCredentialTransport(next http.RoundTripper, auth UpstreamAuth, upstreams map[string]string) http.RoundTripper {
+	if auth.Mode == "" || auth.Mode == UpstreamAuthNone {
+		return next
+	}
+
+	audiences := make(map[string]string, len(upstreams))
+	for baseURL, service := range upstreams {
+		parsedURL, err := url.Parse(baseURL)
+		if err != nil || parsedURL.Host == "" {
+			continue
+		}
+		audiences[parsedURL.Scheme+"://"+parsedURL.Host] = service
+	}
+
+	return &credentialTransport{next: next, auth: auth, audiences: audiences, now: time.Now}
+}
+
+// RoundTrip adds the Authorization header when the request goes to an internal upstream
+func (transport *credentialTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	audience, internal := transport.audiences[request.URL.Scheme+"://"+request.URL.Host]
+	if !internal {
+		return transport.next.RoundTrip(request)
+	}
+
+	var token string
+	switch transport.auth.Mode {
+	case UpstreamAuthBearer:
+		token = transport.auth.Token
+	case UpstreamAuthJWT:
+		token = transport.signJWT(audience, identity.APIKeyID(request.Context()))
+	}
+
+	// RoundTrippers must not modify the caller's request
+	authenticatedRequest := request.Clone(request.Context())
+	authenticatedRequest.Header.Set("Authorization", "Bearer "+token)
+	return transport.next.RoundTrip(authenticatedRequest)
+}
+
+// signJWT issues an HS256 token for one upstream request
+// The subject is the caller's API key fingerprint when the request has one.
+func (transport *credentialTransport) signJWT(audience string, subject string) string {
+	now := transport.now()
+	claims := map[string]interface{}{
+		"iss": upstreamJWTIssuer,
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(transport.auth.JWTTTL).Unix(),
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+	claimsJSON, _ := json.Marshal(claims)
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	mac := hmac.New(sha256.New, []byte(transport.auth.JWTSecret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
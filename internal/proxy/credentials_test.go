@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// newAuthorizationRecorder starts an upstream that records the Authorization header it receives
+func newAuthorizationRecorder(t *testing.T, responseBody string) (*httptest.Server, *string) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorization = request.Header.Get("Authorization")
+		writer.Write([]byte(responseBody))
+	}))
+	t.Cleanup(server.Close)
+	return server, &authorization
+}
+
+// TestUpstreamAuth_Bearer tests that data and cortex get the static token and external upstreams don't
+func TestUpstreamAuth_Bearer(t *testing.T) {
+	dataServer, dataAuthorization := newAuthorizationRecorder(t, `{"puuid":"abc"}`)
+	cortexServer, cortexAuthorization := newAuthorizationRecorder(t, `{}`)
+	externalServer, externalAuthorization := newAuthorizationRecorder(t, `{}`)
+
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL:   dataServer.URL,
+		CortexServiceURL: cortexServer.URL,
+		Auth:             UpstreamAuth{Mode: UpstreamAuthBearer, Token: "s3cret"},
+	})
+
+	if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "Newyenn", "GGEZ"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := proxy.AnalyzePlayer(context.Background(), &models.Summoner{}, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *dataAuthorization != "Bearer s3cret" || *cortexAuthorization != "Bearer s3cret" {
+		t.Errorf("Expected 'Bearer s3cret' on data and cortex, got '%s' and '%s'", *dataAuthorization, *cortexAuthorization)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/external", nil)
+	request.Header.Set("Authorization", "Bearer client-token")
+	proxy.Forward(routes.Route{Upstream: externalServer.URL, UpstreamPath: "/x"}).ServeHTTP(httptest.NewRecorder(), request)
+	if *externalAuthorization != "" {
+		t.Errorf("Expected no credentials for an external upstream, got '%s'", *externalAuthorization)
+	}
+}
+
+// TestUpstreamAuth_JWT tests that gateway-signed tokens verify and carry the upstream's audience
+func TestUpstreamAuth_JWT(t *testing.T) {
+	dataServer, dataAuthorization := newAuthorizationRecorder(t, `{"puuid":"abc"}`)
+
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL:   dataServer.URL,
+		CortexServiceURL: "http://localhost:0",
+		Auth:             UpstreamAuth{Mode: UpstreamAuthJWT, JWTSecret: "jwt-secret", JWTTTL: time.Minute},
+	})
+	if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "Newyenn", "GGEZ"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	token, isBearer := strings.CutPrefix(*dataAuthorization, "Bearer ")
+	parts := strings.Split(token, ".")
+	if !isBearer || len(parts) != 3 {
+		t.Fatalf("Expected a bearer JWT, got '%s'", *dataAuthorization)
+	}
+
+	mac := hmac.New(sha256.New, []byte("jwt-secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+		t.Error("Expected the JWT signature to verify with the shared secret")
+	}
+
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Issuer   string `json:"iss"`
+		Audience string `json:"aud"`
+		IssuedAt int64  `json:"iat"`
+		Expiry   int64  `json:"exp"`
+	}
+	json.Unmarshal(claimsJSON, &claims)
+	if claims.Issuer != "opgl-gateway" || claims.Audience != "opgl-data" || claims.Expiry-claims.IssuedAt != 60 {
+		t.Errorf("Expected iss opgl-gateway, aud opgl-data and a 60s lifetime, got %+v", claims)
+	}
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// hostResolver is the subset of *net.Resolver used by dnsCache
+type hostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dnsCache caches upstream hostname lookups so new connections don't each pay for DNS
+// The standard resolver doesn't expose record TTLs, so entries live for a configured TTL.
+// An expired entry keeps serving its addresses while a single background lookup refreshes
+// it; a failed refresh keeps the old addresses, so a DNS outage doesn't take upstreams down.
+type dnsCache struct {
+	resolver hostResolver
+	ttl      time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*dnsCacheEntry
+}
+
+// dnsCacheEntry holds the resolved addresses of one hostname
+type dnsCacheEntry struct {
+	addresses  []string
+	expiresAt  time.Time
+	refreshing bool
+}
+
+// newDNSCache creates a dnsCache whose entries are refreshed after ttl
+func newDNSCache(resolver hostResolver, ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		resolver: resolver,
+		ttl:      ttl,
+		entries:  make(map[string]*dnsCacheEntry),
+	}
+}
+
+// lookup returns the addresses of host, resolving it only on a cache miss
+func (cache *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	cache.mutex.Lock()
+	entry, found := cache.entries[host]
+	if found {
+		addresses := entry.addresses
+		if time.Now().After(entry.expiresAt) && !entry.refreshing {
+			entry.refreshing = true
+			go cache.refresh(host)
+		}
+		cache.mutex.Unlock()
+		return addresses, nil
+	}
+	cache.mutex.Unlock()
+
+	// The request context carries the httptrace hooks, so misses still show up in DNS metrics
+	addresses, err := cache.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	cache.entries[host] = &dnsCacheEntry{addresses: addresses, expiresAt: time.Now().Add(cache.ttl)}
+	cache.mutex.Unlock()
+	return addresses, nil
+}
+
+// refresh re-resolves host in the background, keeping the cached addresses if the lookup fails
+func (cache *dnsCache) refresh(host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	addresses, err := cache.resolve(ctx, host)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, found := cache.entries[host]
+	if !found {
+		return
+	}
+	entry.refreshing = false
+	if err == nil {
+		entry.addresses = addresses
+	}
+	// Retry a failed refresh after another TTL rather than on every dial
+	entry.expiresAt = time.Now().Add(cache.ttl)
+}
+
+// invalidate drops host from the cache so the next dial resolves it again
+func (cache *dnsCache) invalidate(host string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.entries, host)
+}
+
+// resolve looks host up with the underlying resolver
+func (cache *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	ipAddresses, err := cache.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, len(ipAddresses))
+	for i, ipAddress := range ipAddresses {
+		addresses[i] = ipAddress.String()
+	}
+	return addresses, nil
+}
+
+// dialContext wraps dial so hostnames are resolved through the cache
+// Cached addresses are tried in order; if none accepts a connection the entry is dropped,
+// so a backend that moved to a new IP is picked up on the next dial instead of after the TTL.
+func (cache *dnsCache) dialContext(dial func(ctx context.Context, network string, address string) (net.Conn, error)) func(ctx context.Context, network string, address string) (net.Conn, error) {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		addresses, err := cache.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var dialErr error
+		for _, ipAddress := range addresses {
+			conn, err := dial(ctx, network, net.JoinHostPort(ipAddress, port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+			if ctx.Err() != nil {
+				return nil, dialErr
+			}
+		}
+
+		cache.invalidate(host)
+		if dialErr == nil {
+			dialErr = &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+		}
+		return nil, dialErr
+	}
+}
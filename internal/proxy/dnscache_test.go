@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver returns preset addresses and counts lookups
+type fakeResolver struct {
+	mutex     sync.Mutex
+	addresses []string
+	err       error
+	lookups   int
+	done      chan struct{}
+}
+
+// LookupIPAddr returns the fake resolver's current addresses
+func (resolver *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	resolver.mutex.Lock()
+	defer func() {
+		resolver.mutex.Unlock()
+		if resolver.done != nil {
+			resolver.done <- struct{}{}
+		}
+	}()
+
+	resolver.lookups++
+	if resolver.err != nil {
+		return nil, resolver.err
+	}
+
+	ipAddresses := make([]net.IPAddr, len(resolver.addresses))
+	for i, address := range resolver.addresses {
+		ipAddresses[i] = net.IPAddr{IP: net.ParseIP(address)}
+	}
+	return ipAddresses, nil
+}
+
+// TestDNSCache_CachesLookups tests that repeated lookups within the TTL hit the resolver once
+func TestDNSCache_CachesLookups(t *testing.T) {
+	resolver := &fakeResolver{addresses: []string{"10.0.0.1"}}
+	cache := newDNSCache(resolver, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		addresses, err := cache.lookup(context.Background(), "data.internal")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(addresses) != 1 || addresses[0] != "10.0.0.1" {
+			t.Errorf("Expected [10.0.0.1], got %v", addresses)
+		}
+	}
+
+	if resolver.lookups != 1 {
+		t.Errorf("Expected 1 lookup, got %d", resolver.lookups)
+	}
+}
+
+// TestDNSCache_BackgroundRefresh tests that expired entries are served stale while refreshing,
+// and that a failed refresh keeps the previous addresses
+func TestDNSCache_BackgroundRefresh(t *testing.T) {
+	resolver := &fakeResolver{addresses: []string{"10.0.0.1"}}
+	cache := newDNSCache(resolver, time.Millisecond)
+
+	cache.lookup(context.Background(), "data.internal")
+	time.Sleep(5 * time.Millisecond)
+
+	// The backend moved; the expired entry is served once while the refresh runs
+	resolver.mutex.Lock()
+	resolver.addresses = []string{"10.0.0.2"}
+	resolver.done = make(chan struct{}, 1)
+	resolver.mutex.Unlock()
+
+	addresses, _ := cache.lookup(context.Background(), "data.internal")
+	if addresses[0] != "10.0.0.1" {
+		t.Errorf("Expected stale address 10.0.0.1 during refresh, got %v", addresses)
+	}
+	<-resolver.done
+	waitForRefresh(t, cache, "data.internal")
+
+	addresses, _ = cache.lookup(context.Background(), "data.internal")
+	if addresses[0] != "10.0.0.2" {
+		t.Errorf("Expected refreshed address 10.0.0.2, got %v", addresses)
+	}
+
+	// A failing resolver keeps the last known addresses
+	time.Sleep(5 * time.Millisecond)
+	resolver.mutex.Lock()
+	resolver.err = errors.New("dns unavailable")
+	resolver.mutex.Unlock()
+
+	cache.lookup(context.Background(), "data.internal")
+	<-resolver.done
+	waitForRefresh(t, cache, "data.internal")
+
+	addresses, err := cache.lookup(context.Background(), "data.internal")
+	if err != nil || addresses[0] != "10.0.0.2" {
+		t.Errorf("Expected 10.0.0.2 to survive a failed refresh, got %v (err %v)", addresses, err)
+	}
+}
+
+// TestDNSCache_DialFailureInvalidates tests that an entry whose addresses all refuse connections is dropped
+func TestDNSCache_DialFailureInvalidates(t *testing.T) {
+	resolver := &fakeResolver{addresses: []string{"10.0.0.1", "10.0.0.2"}}
+	cache := newDNSCache(resolver, time.Minute)
+
+	var dialed []string
+	dial := cache.dialContext(func(ctx context.Context, network string, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errors.New("connection refused")
+	})
+
+	if _, err := dial(context.Background(), "tcp", "data.internal:8081"); err == nil {
+		t.Fatal("Expected dial error")
+	}
+	if len(dialed) != 2 || dialed[0] != "10.0.0.1:8081" || dialed[1] != "10.0.0.2:8081" {
+		t.Errorf("Expected both cached addresses to be dialed, got %v", dialed)
+	}
+
+	dial(context.Background(), "tcp", "data.internal:8081")
+	if resolver.lookups != 2 {
+		t.Errorf("Expected a fresh lookup after the failed dial, got %d lookups", resolver.lookups)
+	}
+}
+
+// waitForRefresh waits until the background refresh of host has stored its result
+func waitForRefresh(t *testing.T, cache *dnsCache, host string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		cache.mutex.Lock()
+		refreshing := cache.entries[host].refreshing
+		cache.mutex.Unlock()
+		if !refreshing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for DNS refresh")
+}
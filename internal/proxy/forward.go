@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/identity"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// maxTransformedBodyBytes bounds bodies read into memory to rename fields
+const maxTransformedBodyBytes = 1 << 20
+
+// forwardedHeadersStripped are client credentials that stay at the gateway
+var forwardedHeadersStripped = []string{"X-API-Key", "Authorization", "Cookie"}
+
+// Forward returns a handler that proxies a declared route's requests to its upstream
+// The upstream is "data", "cortex" or an absolute http(s) URL. Method, query string,
+// body and headers pass through unchanged except for client credentials and the
+// route's transform, and requests use the same transport (DNS cache, proxy settings,
+// metrics) as the typed calls. Successful JSON responses get the route's response rewrite.
+func (proxy *ServiceProxy) Forward(route routes.Route) http.Handler {
+	baseURL := route.Upstream
+	upstreamError := apierrors.UpstreamError
+	switch route.Upstream {
+	case routes.UpstreamData:
+		baseURL = proxy.dataServiceURL
+		upstreamError = apierrors.DataServiceError
+	case routes.UpstreamCortex:
+		baseURL = proxy.cortexServiceURL
+		upstreamError = apierrors.CortexServiceError
+	}
+
+	target, err := url.Parse(baseURL)
+	if err != nil {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			apierrors.WriteError(writer, apierrors.InternalError("Route upstream is misconfigured"))
+		})
+	}
+
+	transform := route.Transform
+	reverseProxy := &httputil.ReverseProxy{
+		Rewrite: func(proxyRequest *httputil.ProxyRequest) {
+			proxyRequest.Out.URL.Scheme = target.Scheme
+			proxyRequest.Out.URL.Host = target.Host
+			proxyRequest.Out.URL.Path = strings.TrimSuffix(target.Path, "/") + route.UpstreamPath
+			proxyRequest.Out.URL.RawPath = ""
+			proxyRequest.Out.Host = target.Host
+			for _, header := range forwardedHeadersStripped {
+				proxyRequest.Out.Header.Del(header)
+			}
+
+			for name, value := range transform.SetHeaders {
+				if value == "" {
+					proxyRequest.Out.Header.Del(name)
+				} else {
+					proxyRequest.Out.Header.Set(name, value)
+				}
+			}
+
+			// The consumer header is always set by the gateway, never passed through from the client
+			if transform.ConsumerHeader != "" {
+				proxyRequest.Out.Header.Del(transform.ConsumerHeader)
+				if consumer := identity.APIKeyID(proxyRequest.In.Context()); consumer != "" {
+					proxyRequest.Out.Header.Set(transform.ConsumerHeader, consumer)
+				}
+			}
+
+			// Experiment assignments are likewise only ever set by the gateway
+			proxyRequest.Out.Header.Del(experiments.Header)
+			if assignments := experiments.FromContext(proxyRequest.In.Context()); len(assignments) > 0 {
+				proxyRequest.Out.Header.Set(experiments.Header, experiments.FormatHeader(assignments))
+			}
+
+			// Let the transport negotiate compression so rewritten bodies arrive decoded
+			if !route.Response.IsZero() {
+				proxyRequest.Out.Header.Del("Accept-Encoding")
+			}
+
+			if proxy.clientMetrics != nil {
+				proxyRequest.Out = proxyRequest.Out.WithContext(proxy.clientMetrics.withTrace(proxyRequest.Out.Context(), hostLabel(proxyRequest.Out)))
+			}
+		},
+		Transport: proxy.httpClient.Transport,
+		ErrorHandler: func(writer http.ResponseWriter, request *http.Request, err error) {
+			if request.Context().Err() != nil {
+				apierrors.WriteError(writer, contextError(request.Context()))
+				return
+			}
+			apierrors.WriteError(writer, proxy.responseError(err, upstreamError("Unable to connect to upstream service")))
+		},
+		// Bodies past the size limit are rejected up front when their Content-Length says so;
+		// streamed bodies that outgrow it are cut off and the client connection is aborted.
+		ModifyResponse: func(response *http.Response) error {
+			proxy.limitResponse(response)
+			if proxy.maxResponseBytes > 0 && response.ContentLength > proxy.maxResponseBytes {
+				response.Body.Close()
+				return errResponseTooLarge
+			}
+			if route.Response.IsZero() {
+				return nil
+			}
+			return rewriteResponse(response, route.Response)
+		},
+	}
+
+	if len(transform.RenameFields) == 0 {
+		return reverseProxy
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestBody, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxTransformedBodyBytes))
+		if err != nil {
+			apierrors.WriteError(writer, apierrors.InvalidRequestBody("Unable to read request body"))
+			return
+		}
+
+		requestBody = renameJSONFields(requestBody, transform.RenameFields)
+		request.Body = io.NopCloser(bytes.NewReader(requestBody))
+		request.ContentLength = int64(len(requestBody))
+		reverseProxy.ServeHTTP(writer, request)
+	})
+}
+
+// renameJSONFields renames top-level fields of a JSON object body
+// Bodies that aren't JSON objects are returned unchanged for the upstream to judge.
+func renameJSONFields(body []byte, renames map[string]string) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body
+	}
+
+	// Move values out before writing them back so swapped names don't overwrite each other
+	movedFields := make(map[string]json.RawMessage, len(renames))
+	for oldName, newName := range renames {
+		if value, exists := fields[oldName]; exists {
+			delete(fields, oldName)
+			movedFields[newName] = value
+		}
+	}
+	for name, value := range movedFields {
+		fields[name] = value
+	}
+
+	renamedBody, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return renamedBody
+}
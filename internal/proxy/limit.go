@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// errResponseTooLarge is returned by reads past the upstream response size limit
+var errResponseTooLarge = errors.New("upstream response exceeds the size limit")
+
+// limitedBody fails reads once an upstream body grows past its limit
+// Decoding stops at the limit instead of buffering whatever the upstream sends, and the
+// bytes read are recorded per upstream when the body is closed.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	read      int64
+	onClose   func(read int64, tooLarge bool)
+}
+
+// limitResponse wraps response.Body in the proxy's size limit and records its size
+// A Content-Length above the limit fails the first read without reading anything.
+func (proxy *ServiceProxy) limitResponse(response *http.Response) {
+	if proxy.maxResponseBytes <= 0 && proxy.clientMetrics == nil {
+		return
+	}
+
+	// Without a limit the body is only wrapped to be measured
+	remaining := int64(math.MaxInt64 - 1)
+	if proxy.maxResponseBytes > 0 {
+		remaining = proxy.maxResponseBytes
+		if response.ContentLength > proxy.maxResponseBytes {
+			remaining = -1
+		}
+	}
+
+	host := hostLabel(response.Request)
+	response.Body = &limitedBody{
+		body:      response.Body,
+		remaining: remaining,
+		onClose: func(read int64, tooLarge bool) {
+			if proxy.clientMetrics != nil {
+				proxy.clientMetrics.recordResponseSize(host, read, tooLarge)
+			}
+		},
+	}
+}
+
+// Read reads from the upstream body until the limit is exceeded
+func (body *limitedBody) Read(buffer []byte) (int, error) {
+	if body.remaining < 0 {
+		return 0, errResponseTooLarge
+	}
+
+	// Read at most one byte past the limit, which is enough to tell it was exceeded
+	if int64(len(buffer)) > body.remaining+1 {
+		buffer = buffer[:body.remaining+1]
+	}
+	readBytes, err := body.body.Read(buffer)
+	body.read += int64(readBytes)
+	body.remaining -= int64(readBytes)
+	if body.remaining < 0 {
+		return readBytes - 1, errResponseTooLarge
+	}
+	return readBytes, err
+}
+
+// Close closes the upstream body and records how much of it was read
+func (body *limitedBody) Close() error {
+	if body.onClose != nil {
+		body.onClose(body.read, body.remaining < 0)
+		body.onClose = nil
+	}
+	return body.body.Close()
+}
+
+// responseError classifies a failure to read an upstream response
+// Bodies over the size limit become UPSTREAM_RESPONSE_TOO_LARGE and payloads in an unknown
+// schema version DATA_SERVICE_ERROR; anything else gets fallback.
+func (proxy *ServiceProxy) responseError(err error, fallback *apierrors.APIError) *apierrors.APIError {
+	var schemaErr *unsupportedSchemaError
+	switch {
+	case errors.Is(err, errResponseTooLarge):
+		return apierrors.UpstreamResponseTooLarge(proxy.maxResponseBytes)
+	case errors.As(err, &schemaErr):
+		return apierrors.DataServiceError("Data service response is in an unsupported format")
+	}
+	return fallback
+}
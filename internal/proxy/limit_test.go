@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// TestMaxResponseBytes_TypedCall tests that oversized bodies become UPSTREAM_RESPONSE_TOO_LARGE
+func TestMaxResponseBytes_TypedCall(t *testing.T) {
+	oversizedBody := `{"puuid":"` + strings.Repeat("a", 200) + `"}`
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Flush first so the body is chunked and only the streamed size gives it away
+		writer.(http.Flusher).Flush()
+		writer.Write([]byte(oversizedBody))
+	}))
+	defer mockServer.Close()
+
+	registry := metrics.NewRegistry()
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL:   mockServer.URL,
+		CortexServiceURL: "http://localhost:0",
+		MaxResponseBytes: 100,
+		MetricsRegistry:  registry,
+	})
+
+	_, err := proxy.GetSummonerByRiotID(context.Background(), "na", "Newyenn", "GGEZ")
+	apiError := apierrors.FromError(err)
+	if apiError.Code != apierrors.ErrCodeResponseTooLarge || apiError.Status != http.StatusBadGateway {
+		t.Errorf("Expected 502 UPSTREAM_RESPONSE_TOO_LARGE, got %d %s", apiError.Status, apiError.Code)
+	}
+
+	responseRecorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(responseRecorder.Body.String(), "opgl_gateway_upstream_responses_too_large_total{host=") {
+		t.Errorf("Expected the oversized response to be counted, got:\n%s", responseRecorder.Body.String())
+	}
+
+	// The same body fits once the limit is raised
+	proxy = NewServiceProxyWithConfig(Config{
+		DataServiceURL:   mockServer.URL,
+		CortexServiceURL: "http://localhost:0",
+		MaxResponseBytes: int64(len(oversizedBody)),
+	})
+	if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "Newyenn", "GGEZ"); err != nil {
+		t.Errorf("Expected a body at the limit to be accepted, got %v", err)
+	}
+}
+
+// TestMaxResponseBytes_Forward tests that declared routes reject bodies whose Content-Length is over the limit
+func TestMaxResponseBytes_Forward(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte(`{"tier":"` + strings.Repeat("x", 200) + `"}`))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL:   mockServer.URL,
+		CortexServiceURL: "http://localhost:0",
+		MaxResponseBytes: 100,
+	})
+
+	responseRecorder := httptest.NewRecorder()
+	proxy.Forward(routes.Route{Upstream: "data", UpstreamPath: "/ranked"}).ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/api/v1/ranked", nil))
+	if responseRecorder.Code != http.StatusBadGateway || !strings.Contains(responseRecorder.Body.String(), "UPSTREAM_RESPONSE_TOO_LARGE") {
+		t.Errorf("Expected 502 UPSTREAM_RESPONSE_TOO_LARGE, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+}
@@ -0,0 +1,223 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// Mirror limits: shadow calls never queue behind each other, and diff events stay small
+const (
+	maxConcurrentMirrors = 16
+	maxMirrorDifferences = 20
+	mirrorTimeout        = 60 * time.Second
+)
+
+// mirrorIgnoredFields differ on every call and are left out of comparisons
+var mirrorIgnoredFields = map[string]bool{"analyzedAt": true}
+
+// MirrorConfig sends a sample of analysis requests to a shadow cortex for comparison
+type MirrorConfig struct {
+	// CortexServiceURL is the shadow cortex (e.g. cortex-v2); empty disables mirroring
+	CortexServiceURL string
+	// Percent of analysis requests mirrored, from 0 to 100
+	Percent float64
+	// EventBus receives mirror.diff events for shadow responses that differ (may be nil)
+	EventBus *events.Bus
+}
+
+// mirror replays sampled upstream calls against a shadow upstream in the background
+// The client response never waits for the shadow: calls run on their own goroutine with
+// their own deadline, and are dropped when too many are already in flight.
+type mirror struct {
+	url      string
+	percent  float64
+	client   *http.Client
+	eventBus *events.Bus
+	requests *metrics.Counter
+	slots    chan struct{}
+	inFlight sync.WaitGroup
+}
+
+// newMirror creates a mirror posting to url, or returns nil when mirroring is disabled
+func newMirror(url string, config MirrorConfig, client *http.Client, registry *metrics.Registry) *mirror {
+	if config.CortexServiceURL == "" || config.Percent <= 0 {
+		return nil
+	}
+
+	shadow := &mirror{
+		url:      url,
+		percent:  config.Percent,
+		client:   client,
+		eventBus: config.EventBus,
+		slots:    make(chan struct{}, maxConcurrentMirrors),
+	}
+	if registry != nil {
+		shadow.requests = registry.NewCounter(
+			"opgl_gateway_mirror_requests_total",
+			"Mirrored upstream requests, by whether the shadow response matched the primary one.",
+			"result",
+		)
+	}
+	return shadow
+}
+
+// maybeSend mirrors a request body with the configured probability and compares the shadow's
+// response with the primary response body once it arrives
+func (shadow *mirror) maybeSend(ctx context.Context, path string, requestBody []byte, primaryBody []byte) {
+	if shadow == nil || rand.Float64()*100 >= shadow.percent {
+		return
+	}
+
+	select {
+	case shadow.slots <- struct{}{}:
+	default:
+		shadow.record("dropped")
+		return
+	}
+
+	shadow.inFlight.Add(1)
+	go func() {
+		defer shadow.inFlight.Done()
+		defer func() { <-shadow.slots }()
+
+		// Keep request values (e.g. experiment assignments) but not the client's cancellation
+		mirrorContext, cancelMirror := context.WithTimeout(context.WithoutCancel(ctx), mirrorTimeout)
+		defer cancelMirror()
+
+		shadowStatus, shadowBody, err := shadow.send(mirrorContext, requestBody)
+		if err != nil {
+			shadow.record("error")
+			return
+		}
+
+		differences := compareJSON(primaryBody, shadowBody)
+		if shadowStatus == http.StatusOK && len(differences) == 0 {
+			shadow.record("match")
+			return
+		}
+
+		shadow.record("diff")
+		shadow.eventBus.Publish(events.TypeMirrorDiff, map[string]interface{}{
+			"path":         path,
+			"shadowURL":    shadow.url,
+			"shadowStatus": shadowStatus,
+			"differences":  differences,
+		})
+	}()
+}
+
+// wait blocks until in-flight shadow calls have finished or ctx is done
+func (shadow *mirror) wait(ctx context.Context) error {
+	if shadow == nil {
+		return nil
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		shadow.inFlight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitForMirrors waits for in-flight shadow calls and their diff events, or until ctx is done
+// Call it after the HTTP server has shut down, so no new shadow calls start, and before the event bus is closed.
+func (proxy *ServiceProxy) WaitForMirrors(ctx context.Context) error {
+	return proxy.mirror.wait(ctx)
+}
+
+// send posts the request body to the shadow upstream
+func (shadow *mirror) send(ctx context.Context, requestBody []byte) (int, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, shadow.url, bytes.NewReader(requestBody))
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-OPGL-Mirror", "true")
+
+	response, err := shadow.client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxRewrittenBodyBytes))
+	return response.StatusCode, body, err
+}
+
+// record counts a mirror outcome when metrics are enabled
+func (shadow *mirror) record(result string) {
+	if shadow.requests != nil {
+		shadow.requests.Inc(result)
+	}
+}
+
+// compareJSON lists the paths at which two JSON documents differ, sorted and capped
+// A body that isn't JSON is reported as a difference at the root.
+func compareJSON(primaryBody []byte, shadowBody []byte) []string {
+	var primary, shadow interface{}
+	if json.Unmarshal(primaryBody, &primary) != nil || json.Unmarshal(shadowBody, &shadow) != nil {
+		if bytes.Equal(primaryBody, shadowBody) {
+			return nil
+		}
+		return []string{"$"}
+	}
+
+	var differences []string
+	collectDifferences("$", primary, shadow, &differences)
+	sort.Strings(differences)
+	if len(differences) > maxMirrorDifferences {
+		differences = differences[:maxMirrorDifferences]
+	}
+	return differences
+}
+
+// collectDifferences walks both documents and appends the paths of differing values
+func collectDifferences(path string, primary interface{}, shadow interface{}, differences *[]string) {
+	primaryObject, primaryIsObject := primary.(map[string]interface{})
+	shadowObject, shadowIsObject := shadow.(map[string]interface{})
+	if primaryIsObject && shadowIsObject {
+		for name, value := range primaryObject {
+			if !mirrorIgnoredFields[name] {
+				collectDifferences(path+"."+name, value, shadowObject[name], differences)
+			}
+		}
+		for name := range shadowObject {
+			if _, exists := primaryObject[name]; !exists && !mirrorIgnoredFields[name] {
+				*differences = append(*differences, path+"."+name)
+			}
+		}
+		return
+	}
+
+	primaryArray, primaryIsArray := primary.([]interface{})
+	shadowArray, shadowIsArray := shadow.([]interface{})
+	if primaryIsArray && shadowIsArray && len(primaryArray) == len(shadowArray) {
+		for i := range primaryArray {
+			collectDifferences(fmt.Sprintf("%s[%d]", path, i), primaryArray[i], shadowArray[i], differences)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(primary, shadow) {
+		*differences = append(*differences, path)
+	}
+}
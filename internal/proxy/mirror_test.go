@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+)
+
+// eventRecorder is an events.Publisher that stores published events
+type eventRecorder struct {
+	mutex     sync.Mutex
+	published []*events.Event
+}
+
+func (recorder *eventRecorder) Publish(ctx context.Context, event *events.Event) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.published = append(recorder.published, event)
+	return nil
+}
+
+func (recorder *eventRecorder) Close() error {
+	return nil
+}
+
+// TestCompareJSON tests that differing paths are reported and ignored fields are skipped
+func TestCompareJSON(t *testing.T) {
+	primary := []byte(`{"analyzedAt":"2026-01-01T00:00:00Z","playerStats":{"kda":3.1,"cs":[1,2]},"tips":["ward"]}`)
+	shadow := []byte(`{"analyzedAt":"2026-01-02T00:00:00Z","playerStats":{"kda":3.4,"cs":[1,2]},"tips":["ward","farm"],"model":"v2"}`)
+
+	differences := compareJSON(primary, shadow)
+	expected := []string{"$.model", "$.playerStats.kda", "$.tips"}
+	if !reflect.DeepEqual(differences, expected) {
+		t.Errorf("Expected differences %v, got %v", expected, differences)
+	}
+
+	if differences := compareJSON(primary, primary); len(differences) != 0 {
+		t.Errorf("Expected identical documents to match, got %v", differences)
+	}
+	if differences := compareJSON(primary, []byte("oops")); !reflect.DeepEqual(differences, []string{"$"}) {
+		t.Errorf("Expected a root difference for a non-JSON body, got %v", differences)
+	}
+}
+
+// TestAnalyzePlayer_Mirror tests that mirrored analyses reach the shadow and differences are published
+func TestAnalyzePlayer_Mirror(t *testing.T) {
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(models.AnalysisResult{PlayerStats: map[string]interface{}{"kda": 3.1}})
+	}))
+	defer primaryServer.Close()
+
+	var shadowRequest map[string]interface{}
+	shadowServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("X-OPGL-Mirror") != "true" {
+			t.Errorf("Expected X-OPGL-Mirror 'true', got '%s'", request.Header.Get("X-OPGL-Mirror"))
+		}
+		json.NewDecoder(request.Body).Decode(&shadowRequest)
+		json.NewEncoder(writer).Encode(models.AnalysisResult{PlayerStats: map[string]interface{}{"kda": 3.4}})
+	}))
+	defer shadowServer.Close()
+
+	recorder := &eventRecorder{}
+	eventBus := events.NewBus(10, recorder)
+	proxy := NewServiceProxyWithConfig(Config{
+		CortexServiceURL: primaryServer.URL,
+		Mirror:           MirrorConfig{CortexServiceURL: shadowServer.URL, Percent: 100, EventBus: eventBus},
+	})
+
+	result, err := proxy.AnalyzePlayer(context.Background(), &models.Summoner{PUUID: "test-puuid"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stats, _ := result.PlayerStats.(map[string]interface{}); stats["kda"] != 3.1 {
+		t.Errorf("Expected the primary analysis, got %v", result.PlayerStats)
+	}
+
+	if err := proxy.WaitForMirrors(context.Background()); err != nil {
+		t.Fatalf("Expected in-flight mirrors to finish, got %v", err)
+	}
+	eventBus.Close()
+
+	if summoner, _ := shadowRequest["summoner"].(map[string]interface{}); summoner["puuid"] != "test-puuid" {
+		t.Errorf("Expected the shadow to receive the analysis request, got %v", shadowRequest)
+	}
+	if len(recorder.published) != 1 || recorder.published[0].Type != events.TypeMirrorDiff {
+		t.Fatalf("Expected one mirror.diff event, got %v", recorder.published)
+	}
+	differences, _ := recorder.published[0].Data["differences"].([]string)
+	if !reflect.DeepEqual(differences, []string{"$.playerStats.kda"}) {
+		t.Errorf("Expected difference at $.playerStats.kda, got %v", recorder.published[0].Data["differences"])
+	}
+}
+
+// TestNewMirror_Disabled tests that mirroring is off without a shadow URL or percentage
+func TestNewMirror_Disabled(t *testing.T) {
+	if newMirror("http://shadow/api/v1/analyze", MirrorConfig{Percent: 50}, http.DefaultClient, nil) != nil {
+		t.Error("Expected no mirror without a shadow URL")
+	}
+	if newMirror("http://shadow/api/v1/analyze", MirrorConfig{CortexServiceURL: "http://shadow"}, http.DefaultClient, nil) != nil {
+		t.Error("Expected no mirror with a zero percentage")
+	}
+}
+
+// TestWaitForMirrors_Deadline tests that waiting for a hung shadow call gives up when the context ends
+func TestWaitForMirrors_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	shadowServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+	}))
+	defer shadowServer.Close()
+	defer close(release)
+
+	proxy := NewServiceProxyWithConfig(Config{Mirror: MirrorConfig{CortexServiceURL: shadowServer.URL, Percent: 100}})
+	proxy.mirror.maybeSend(context.Background(), "/api/v1/analyze", []byte(`{}`), []byte(`{}`))
+
+	waitContext, cancelWait := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelWait()
+	if err := proxy.WaitForMirrors(waitContext); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if err := (&ServiceProxy{}).WaitForMirrors(context.Background()); err != nil {
+		t.Errorf("Expected no error without mirroring, got %v", err)
+	}
+}
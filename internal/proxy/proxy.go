@@ -2,33 +2,135 @@ package proxy
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/OPGLOL/opgl-gateway-service/internal/egress"
 	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
 	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+	"github.com/OPGLOL/opgl-gateway-service/internal/unixsocket"
 )
 
+// Paths holds the upstream endpoint paths appended to each service's base URL
+type Paths struct {
+	Summoner string
+	Matches  string
+	Analyze  string
+}
+
+// DefaultPaths returns the /api/v1 paths served by opgl-data and opgl-cortex-engine
+func DefaultPaths() Paths {
+	return Paths{
+		Summoner: "/api/v1/summoner",
+		Matches:  "/api/v1/matches",
+		Analyze:  "/api/v1/analyze",
+	}
+}
+
+// Config holds the settings for a ServiceProxy
+type Config struct {
+	// Service URLs may be unix:///path/to/socket to reach a sidecar over a unix domain socket
+	DataServiceURL   string
+	CortexServiceURL string
+	// Paths overrides the upstream endpoint paths; empty fields keep the defaults
+	Paths Paths
+	// MaxResponseBytes aborts reading upstream responses larger than this (0 disables the limit)
+	MaxResponseBytes int64
+	// GzipMinBytes gzip-compresses request bodies of at least this size (0 disables compression)
+	GzipMinBytes int
+	// DNSCacheTTL caches upstream hostname lookups for this long (0 disables caching)
+	DNSCacheTTL time.Duration
+	// Auth attaches credentials to data and cortex requests so they can reject traffic that bypassed the gateway
+	Auth UpstreamAuth
+	// TLSClientConfig sets the client certificate (mTLS identity) and trusted CAs for https upstreams
+	TLSClientConfig *tls.Config
+	// DialContext opens upstream connections (e.g. through a bastion) instead of a plain net.Dialer
+	// The EgressGuard only checks connections opened by the default dialer.
+	DialContext func(ctx context.Context, network string, address string) (net.Conn, error)
+	// EgressGuard refuses connections to blocked addresses such as cloud metadata endpoints (nil allows any)
+	EgressGuard *egress.Guard
+	// MetricsRegistry receives connection-level client metrics when set
+	MetricsRegistry *metrics.Registry
+	// Mirror shadows a sample of analysis requests to another cortex (disabled when its URL is empty)
+	Mirror MirrorConfig
+}
+
 // ServiceProxy handles communication with microservices
 type ServiceProxy struct {
 	dataServiceURL   string
 	cortexServiceURL string
+	paths            Paths
 	httpClient       *http.Client
+	clientMetrics    *clientMetrics
+	gzipMinBytes     int
+	maxResponseBytes int64
+	mirror           *mirror
+	// gzipUnsupported records upstream URLs that answered 415 to a compressed body
+	gzipUnsupported sync.Map
 }
 
 // NewServiceProxy creates a new ServiceProxy instance
 func NewServiceProxy(dataServiceURL string, cortexServiceURL string) *ServiceProxy {
+	return NewServiceProxyWithConfig(Config{
+		DataServiceURL:   dataServiceURL,
+		CortexServiceURL: cortexServiceURL,
+	})
+}
+
+// NewServiceProxyWithConfig creates a new ServiceProxy instance from a Config
+func NewServiceProxyWithConfig(config Config) *ServiceProxy {
+	var upstreamMetrics *clientMetrics
+	if config.MetricsRegistry != nil {
+		upstreamMetrics = newClientMetrics(config.MetricsRegistry)
+	}
+
+	paths := DefaultPaths()
+	if config.Paths.Summoner != "" {
+		paths.Summoner = config.Paths.Summoner
+	}
+	if config.Paths.Matches != "" {
+		paths.Matches = config.Paths.Matches
+	}
+	if config.Paths.Analyze != "" {
+		paths.Analyze = config.Paths.Analyze
+	}
+
+	sockets := unixsocket.New()
+	dataServiceURL := sockets.Register("data", config.DataServiceURL)
+	cortexServiceURL := sockets.Register("cortex", config.CortexServiceURL)
+	shadowCortexURL := sockets.Register("cortex-shadow", config.Mirror.CortexServiceURL)
+	transport := newCredentialTransport(newTransport(config, sockets, upstreamMetrics), config.Auth, map[string]string{
+		dataServiceURL:   audienceData,
+		cortexServiceURL: audienceCortex,
+		shadowCortexURL:  audienceCortex,
+	})
+	httpClient := &http.Client{Transport: transport}
+
 	return &ServiceProxy{
 		dataServiceURL:   dataServiceURL,
 		cortexServiceURL: cortexServiceURL,
-		httpClient:       &http.Client{},
+		paths:            paths,
+		httpClient:       httpClient,
+		clientMetrics:    upstreamMetrics,
+		gzipMinBytes:     config.GzipMinBytes,
+		maxResponseBytes: config.MaxResponseBytes,
+		mirror:           newMirror(shadowCortexURL+paths.Analyze, config.Mirror, httpClient, config.MetricsRegistry),
 	}
 }
 
 // GetSummonerByRiotID retrieves summoner data from opgl-data service using Riot ID
-func (proxy *ServiceProxy) GetSummonerByRiotID(region string, gameName string, tagLine string) (*models.Summoner, error) {
-	url := proxy.dataServiceURL + "/api/v1/summoner"
+func (proxy *ServiceProxy) GetSummonerByRiotID(ctx context.Context, region string, gameName string, tagLine string) (*models.Summoner, error) {
+	url := proxy.dataServiceURL + proxy.paths.Summoner
 
 	requestBody := map[string]string{
 		"region":   region,
@@ -41,8 +143,11 @@ func (proxy *ServiceProxy) GetSummonerByRiotID(region string, gameName string, t
 		return nil, apierrors.InternalError("Failed to prepare request")
 	}
 
-	response, err := proxy.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	response, err := proxy.postJSON(ctx, url, jsonData)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		return nil, apierrors.DataServiceError("Unable to connect to data service")
 	}
 	defer response.Body.Close()
@@ -52,17 +157,17 @@ func (proxy *ServiceProxy) GetSummonerByRiotID(region string, gameName string, t
 		return nil, proxy.handleDataServiceError(response, gameName, tagLine)
 	}
 
-	var summoner models.Summoner
-	if err := json.NewDecoder(response.Body).Decode(&summoner); err != nil {
-		return nil, apierrors.InternalError("Failed to process summoner data")
+	summoner, err := decodeSummoner(response)
+	if err != nil {
+		return nil, proxy.responseError(err, apierrors.InternalError("Failed to process summoner data"))
 	}
 
-	return &summoner, nil
+	return summoner, nil
 }
 
 // GetMatchesByRiotID retrieves match history from opgl-data service using Riot ID
-func (proxy *ServiceProxy) GetMatchesByRiotID(region string, gameName string, tagLine string, count int) ([]models.Match, error) {
-	url := proxy.dataServiceURL + "/api/v1/matches"
+func (proxy *ServiceProxy) GetMatchesByRiotID(ctx context.Context, region string, gameName string, tagLine string, count int) ([]models.Match, error) {
+	url := proxy.dataServiceURL + proxy.paths.Matches
 
 	requestBody := map[string]interface{}{
 		"region":   region,
@@ -76,8 +181,11 @@ func (proxy *ServiceProxy) GetMatchesByRiotID(region string, gameName string, ta
 		return nil, apierrors.InternalError("Failed to prepare request")
 	}
 
-	response, err := proxy.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	response, err := proxy.postJSON(ctx, url, jsonData)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		return nil, apierrors.DataServiceError("Unable to connect to data service")
 	}
 	defer response.Body.Close()
@@ -87,17 +195,17 @@ func (proxy *ServiceProxy) GetMatchesByRiotID(region string, gameName string, ta
 		return nil, proxy.handleDataServiceError(response, gameName, tagLine)
 	}
 
-	var matches []models.Match
-	if err := json.NewDecoder(response.Body).Decode(&matches); err != nil {
-		return nil, apierrors.InternalError("Failed to process match data")
+	matches, err := decodeMatches(response)
+	if err != nil {
+		return nil, proxy.responseError(err, apierrors.InternalError("Failed to process match data"))
 	}
 
 	return matches, nil
 }
 
 // GetMatchesByPUUID retrieves match history from opgl-data service using PUUID (internal use)
-func (proxy *ServiceProxy) GetMatchesByPUUID(region string, puuid string, count int) ([]models.Match, error) {
-	url := proxy.dataServiceURL + "/api/v1/matches"
+func (proxy *ServiceProxy) GetMatchesByPUUID(ctx context.Context, region string, puuid string, count int) ([]models.Match, error) {
+	url := proxy.dataServiceURL + proxy.paths.Matches
 
 	requestBody := map[string]interface{}{
 		"region": region,
@@ -110,8 +218,11 @@ func (proxy *ServiceProxy) GetMatchesByPUUID(region string, puuid string, count
 		return nil, apierrors.InternalError("Failed to prepare request")
 	}
 
-	response, err := proxy.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	response, err := proxy.postJSON(ctx, url, jsonData)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		return nil, apierrors.DataServiceError("Unable to connect to data service")
 	}
 	defer response.Body.Close()
@@ -121,29 +232,36 @@ func (proxy *ServiceProxy) GetMatchesByPUUID(region string, puuid string, count
 		return nil, proxy.handleDataServiceErrorByPUUID(response)
 	}
 
-	var matches []models.Match
-	if err := json.NewDecoder(response.Body).Decode(&matches); err != nil {
-		return nil, apierrors.InternalError("Failed to process match data")
+	matches, err := decodeMatches(response)
+	if err != nil {
+		return nil, proxy.responseError(err, apierrors.InternalError("Failed to process match data"))
 	}
 
 	return matches, nil
 }
 
 // AnalyzePlayer sends analysis request to opgl-cortex-engine
-func (proxy *ServiceProxy) AnalyzePlayer(summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error) {
+func (proxy *ServiceProxy) AnalyzePlayer(ctx context.Context, summoner *models.Summoner, matches []models.Match) (*models.AnalysisResult, error) {
 	requestBody := map[string]interface{}{
 		"summoner": summoner,
 		"matches":  matches,
 	}
+	// Let cortex pick the analysis model of the caller's experiment variants
+	if assignments := experiments.FromContext(ctx); len(assignments) > 0 {
+		requestBody["experiments"] = experiments.Variants(assignments)
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, apierrors.InternalError("Failed to prepare request")
 	}
 
-	url := proxy.cortexServiceURL + "/api/v1/analyze"
-	response, err := proxy.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	url := proxy.cortexServiceURL + proxy.paths.Analyze
+	response, err := proxy.postJSON(ctx, url, jsonData)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		return nil, apierrors.CortexServiceError("Unable to connect to analysis service")
 	}
 	defer response.Body.Close()
@@ -153,20 +271,120 @@ func (proxy *ServiceProxy) AnalyzePlayer(summoner *models.Summoner, matches []mo
 		return nil, proxy.handleCortexServiceError(response)
 	}
 
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		return nil, proxy.responseError(err, apierrors.CortexServiceError("Unable to read analysis response"))
+	}
+
 	var analysisResult models.AnalysisResult
-	if err := json.NewDecoder(response.Body).Decode(&analysisResult); err != nil {
+	if err := json.Unmarshal(responseBody, &analysisResult); err != nil {
 		return nil, apierrors.InternalError("Failed to process analysis data")
 	}
 
+	// Compare a sample of analyses with the shadow cortex without delaying the response
+	proxy.mirror.maybeSend(ctx, proxy.paths.Analyze, jsonData, responseBody)
+
 	return &analysisResult, nil
 }
 
+// contextError converts a finished context into the matching APIError
+func contextError(ctx context.Context) *apierrors.APIError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return apierrors.GatewayTimeout("Upstream service did not respond before the request deadline")
+	}
+	return apierrors.RequestCancelled()
+}
+
+// postJSON sends a JSON POST request that is cancelled together with ctx
+// Large bodies are gzip-compressed when enabled. An upstream that rejects the
+// compressed body with 415 Unsupported Media Type gets the request again
+// uncompressed, and later requests to it are no longer compressed.
+func (proxy *ServiceProxy) postJSON(ctx context.Context, url string, jsonData []byte) (*http.Response, error) {
+	if proxy.shouldCompress(url, jsonData) {
+		compressedData, err := gzipCompress(jsonData)
+		if err == nil {
+			response, err := proxy.sendJSON(ctx, url, compressedData, "gzip")
+			if err != nil || response.StatusCode != http.StatusUnsupportedMediaType {
+				return response, err
+			}
+
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			proxy.gzipUnsupported.Store(url, true)
+		}
+	}
+
+	return proxy.sendJSON(ctx, url, jsonData, "")
+}
+
+// sendJSON performs a single JSON POST with an optional Content-Encoding
+func (proxy *ServiceProxy) sendJSON(ctx context.Context, url string, body []byte, contentEncoding string) (*http.Response, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if contentEncoding != "" {
+		httpRequest.Header.Set("Content-Encoding", contentEncoding)
+	}
+	if proxy.clientMetrics != nil {
+		httpRequest = httpRequest.WithContext(proxy.clientMetrics.withTrace(ctx, hostLabel(httpRequest)))
+	}
+
+	response, err := proxy.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	proxy.limitResponse(response)
+	return response, nil
+}
+
+// shouldCompress reports whether a request body to url should be gzip-compressed
+func (proxy *ServiceProxy) shouldCompress(url string, jsonData []byte) bool {
+	if proxy.gzipMinBytes <= 0 || len(jsonData) < proxy.gzipMinBytes {
+		return false
+	}
+
+	_, unsupported := proxy.gzipUnsupported.Load(url)
+	return !unsupported
+}
+
+// gzipCompress compresses data with gzip
+func gzipCompress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	if _, err := gzipWriter.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// upstreamErrorCode extracts the error code from an upstream error body in the shared
+// {"error": {"code": ...}} format, returning an empty code for any other body
+func upstreamErrorCode(body []byte) apierrors.ErrorCode {
+	var errorResponse apierrors.ErrorResponse
+	if err := json.Unmarshal(body, &errorResponse); err != nil {
+		return ""
+	}
+	return errorResponse.Error.Code
+}
+
 // handleDataServiceError converts data service HTTP errors to APIErrors
 func (proxy *ServiceProxy) handleDataServiceError(response *http.Response, gameName string, tagLine string) *apierrors.APIError {
 	body, _ := io.ReadAll(response.Body)
 
 	switch response.StatusCode {
 	case http.StatusNotFound:
+		// The matches endpoint also answers 404 when the player exists but has no matches
+		if upstreamErrorCode(body) == apierrors.ErrCodeMatchesNotFound {
+			return apierrors.MatchesNotFound("No matches found for this player")
+		}
 		return apierrors.PlayerNotFound(gameName, tagLine)
 	case http.StatusBadRequest:
 		return apierrors.InvalidRequestBody(string(body))
@@ -181,6 +399,9 @@ func (proxy *ServiceProxy) handleDataServiceErrorByPUUID(response *http.Response
 
 	switch response.StatusCode {
 	case http.StatusNotFound:
+		if upstreamErrorCode(body) == apierrors.ErrCodePlayerNotFound {
+			return apierrors.NewAPIError(apierrors.ErrCodePlayerNotFound, "Player not found", http.StatusNotFound)
+		}
 		return apierrors.MatchesNotFound("No matches found for this player")
 	case http.StatusBadRequest:
 		return apierrors.InvalidRequestBody(string(body))
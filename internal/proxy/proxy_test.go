@@ -1,12 +1,23 @@
 package proxy
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
 	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
 )
 
 // TestNewServiceProxy tests the NewServiceProxy constructor
@@ -61,7 +72,7 @@ func TestGetSummonerByRiotID_Success(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	summoner, err := proxy.GetSummonerByRiotID("na", "TestPlayer", "NA1")
+	summoner, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -81,7 +92,7 @@ func TestGetSummonerByRiotID_ServerError(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	summoner, err := proxy.GetSummonerByRiotID("na", "TestPlayer", "NA1")
+	summoner, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -92,12 +103,33 @@ func TestGetSummonerByRiotID_ServerError(t *testing.T) {
 	}
 }
 
+// TestGetSummonerByRiotID_NotFound tests that a data service 404 becomes PLAYER_NOT_FOUND
+func TestGetSummonerByRiotID_NotFound(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "Not Found", http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	_, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
+
+	apiError := apierrors.FromError(err)
+	if apiError.Code != apierrors.ErrCodePlayerNotFound {
+		t.Errorf("Expected code '%s', got '%s'", apierrors.ErrCodePlayerNotFound, apiError.Code)
+	}
+
+	if apiError.Status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, apiError.Status)
+	}
+}
+
 // TestGetSummonerByRiotID_ConnectionError tests connection error handling
 func TestGetSummonerByRiotID_ConnectionError(t *testing.T) {
 	// Use invalid URL to simulate connection error
 	proxy := NewServiceProxy("http://localhost:99999", "http://localhost:8082")
 
-	summoner, err := proxy.GetSummonerByRiotID("na", "TestPlayer", "NA1")
+	summoner, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -118,7 +150,7 @@ func TestGetSummonerByRiotID_InvalidJSON(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	summoner, err := proxy.GetSummonerByRiotID("na", "TestPlayer", "NA1")
+	summoner, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
 
 	if err == nil {
 		t.Error("Expected error for invalid JSON, got nil")
@@ -129,6 +161,50 @@ func TestGetSummonerByRiotID_InvalidJSON(t *testing.T) {
 	}
 }
 
+// TestGetSummonerByRiotID_ContextCancelled tests that a cancelled request context aborts the upstream call
+func TestGetSummonerByRiotID_ContextCancelled(t *testing.T) {
+	upstreamReleased := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Consume the body so the server can detect the client disconnecting, then block until it does
+		io.ReadAll(request.Body)
+		select {
+		case <-request.Context().Done():
+			close(upstreamReleased)
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	summoner, err := proxy.GetSummonerByRiotID(ctx, "na", "TestPlayer", "NA1")
+
+	if summoner != nil {
+		t.Error("Expected summoner to be nil on cancellation")
+	}
+
+	apiErr, ok := err.(*apierrors.APIError)
+	if !ok {
+		t.Fatalf("Expected *APIError, got %T", err)
+	}
+
+	if apiErr.Code != apierrors.ErrCodeRequestCancelled {
+		t.Errorf("Expected code '%s', got '%s'", apierrors.ErrCodeRequestCancelled, apiErr.Code)
+	}
+
+	select {
+	case <-upstreamReleased:
+	case <-time.After(time.Second):
+		t.Error("Expected upstream request to be cancelled")
+	}
+}
+
 // TestGetMatchesByRiotID_Success tests successful match history lookup
 func TestGetMatchesByRiotID_Success(t *testing.T) {
 	expectedMatches := []models.Match{
@@ -148,7 +224,7 @@ func TestGetMatchesByRiotID_Success(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	matches, err := proxy.GetMatchesByRiotID("na", "TestPlayer", "NA1", 10)
+	matches, err := proxy.GetMatchesByRiotID(context.Background(), "na", "TestPlayer", "NA1", 10)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -168,7 +244,7 @@ func TestGetMatchesByRiotID_ServerError(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	matches, err := proxy.GetMatchesByRiotID("na", "TestPlayer", "NA1", 10)
+	matches, err := proxy.GetMatchesByRiotID(context.Background(), "na", "TestPlayer", "NA1", 10)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -179,6 +255,23 @@ func TestGetMatchesByRiotID_ServerError(t *testing.T) {
 	}
 }
 
+// TestGetMatchesByRiotID_NoMatches tests that an upstream MATCHES_NOT_FOUND 404 is not reported as an unknown player
+func TestGetMatchesByRiotID_NoMatches(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		apierrors.WriteError(writer, apierrors.MatchesNotFound("No matches"))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	_, err := proxy.GetMatchesByRiotID(context.Background(), "na", "TestPlayer", "NA1", 10)
+
+	apiError := apierrors.FromError(err)
+	if apiError.Code != apierrors.ErrCodeMatchesNotFound {
+		t.Errorf("Expected code '%s', got '%s'", apierrors.ErrCodeMatchesNotFound, apiError.Code)
+	}
+}
+
 // TestGetMatchesByPUUID_Success tests successful match history lookup by PUUID
 func TestGetMatchesByPUUID_Success(t *testing.T) {
 	expectedMatches := []models.Match{
@@ -193,7 +286,7 @@ func TestGetMatchesByPUUID_Success(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	matches, err := proxy.GetMatchesByPUUID("na", "test-puuid", 20)
+	matches, err := proxy.GetMatchesByPUUID(context.Background(), "na", "test-puuid", 20)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -213,7 +306,7 @@ func TestGetMatchesByPUUID_ServerError(t *testing.T) {
 
 	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
 
-	matches, err := proxy.GetMatchesByPUUID("na", "test-puuid", 20)
+	matches, err := proxy.GetMatchesByPUUID(context.Background(), "na", "test-puuid", 20)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -246,7 +339,7 @@ func TestAnalyzePlayer_Success(t *testing.T) {
 	summoner := &models.Summoner{PUUID: "test-puuid"}
 	matches := []models.Match{{MatchID: "NA1_123"}}
 
-	result, err := proxy.AnalyzePlayer(summoner, matches)
+	result, err := proxy.AnalyzePlayer(context.Background(), summoner, matches)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -257,6 +350,28 @@ func TestAnalyzePlayer_Success(t *testing.T) {
 	}
 }
 
+// TestAnalyzePlayer_Experiments tests that experiment variants are sent to cortex
+func TestAnalyzePlayer_Experiments(t *testing.T) {
+	var receivedBody map[string]interface{}
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewDecoder(request.Body).Decode(&receivedBody)
+		json.NewEncoder(writer).Encode(models.AnalysisResult{})
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy("http://localhost:8081", mockServer.URL)
+	ctx := experiments.WithAssignments(context.Background(), []experiments.Assignment{{Experiment: "analysis-model", Variant: "cortex-v2"}})
+
+	if _, err := proxy.AnalyzePlayer(ctx, &models.Summoner{PUUID: "test-puuid"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variants, _ := receivedBody["experiments"].(map[string]interface{})
+	if variants["analysis-model"] != "cortex-v2" {
+		t.Errorf("Expected experiments {analysis-model: cortex-v2} in the payload, got %v", receivedBody["experiments"])
+	}
+}
+
 // TestAnalyzePlayer_ServerError tests server error handling
 func TestAnalyzePlayer_ServerError(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -269,7 +384,7 @@ func TestAnalyzePlayer_ServerError(t *testing.T) {
 	summoner := &models.Summoner{PUUID: "test-puuid"}
 	matches := []models.Match{{MatchID: "NA1_123"}}
 
-	result, err := proxy.AnalyzePlayer(summoner, matches)
+	result, err := proxy.AnalyzePlayer(context.Background(), summoner, matches)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -287,7 +402,7 @@ func TestAnalyzePlayer_ConnectionError(t *testing.T) {
 	summoner := &models.Summoner{PUUID: "test-puuid"}
 	matches := []models.Match{{MatchID: "NA1_123"}}
 
-	result, err := proxy.AnalyzePlayer(summoner, matches)
+	result, err := proxy.AnalyzePlayer(context.Background(), summoner, matches)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -298,6 +413,255 @@ func TestAnalyzePlayer_ConnectionError(t *testing.T) {
 	}
 }
 
+// TestPostJSON_GzipCompression tests that large request bodies are gzip-compressed
+func TestPostJSON_GzipCompression(t *testing.T) {
+	var receivedBody []byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected Content-Encoding 'gzip', got '%s'", request.Header.Get("Content-Encoding"))
+		}
+
+		gzipReader, err := gzip.NewReader(request.Body)
+		if err != nil {
+			t.Fatalf("Failed to read gzip body: %v", err)
+		}
+		receivedBody, _ = io.ReadAll(gzipReader)
+
+		writer.WriteHeader(http.StatusOK)
+		json.NewEncoder(writer).Encode(models.AnalysisResult{})
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxyWithConfig(Config{CortexServiceURL: mockServer.URL, GzipMinBytes: 1})
+
+	summoner := &models.Summoner{ID: "test-id", Name: "TestPlayer"}
+	if _, err := proxy.AnalyzePlayer(context.Background(), summoner, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(string(receivedBody), "TestPlayer") {
+		t.Errorf("Expected decompressed body to contain summoner, got %s", receivedBody)
+	}
+}
+
+// TestPostJSON_GzipFallback tests that a 415 response disables compression for that upstream
+func TestPostJSON_GzipFallback(t *testing.T) {
+	var encodings []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		encodings = append(encodings, request.Header.Get("Content-Encoding"))
+		if request.Header.Get("Content-Encoding") == "gzip" {
+			writer.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		json.NewEncoder(writer).Encode(models.AnalysisResult{})
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxyWithConfig(Config{CortexServiceURL: mockServer.URL, GzipMinBytes: 1})
+	summoner := &models.Summoner{ID: "test-id"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := proxy.AnalyzePlayer(context.Background(), summoner, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expectedEncodings := []string{"gzip", "", ""}
+	if strings.Join(encodings, ",") != strings.Join(expectedEncodings, ",") {
+		t.Errorf("Expected encodings %q, got %q", expectedEncodings, encodings)
+	}
+}
+
+// TestServiceProxy_ClientMetrics tests that connection reuse and pool size are recorded
+func TestServiceProxy_ClientMetrics(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		io.ReadAll(request.Body)
+		json.NewEncoder(writer).Encode(models.Summoner{ID: "test-id"})
+	}))
+	defer mockServer.Close()
+
+	registry := metrics.NewRegistry()
+	proxy := NewServiceProxyWithConfig(Config{DataServiceURL: mockServer.URL, MetricsRegistry: registry})
+
+	for i := 0; i < 2; i++ {
+		if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	output := recorder.Body.String()
+
+	host := strings.TrimPrefix(mockServer.URL, "http://")
+	expectedLines := []string{
+		`opgl_gateway_upstream_connections_total{host="` + host + `",reused="false"} 1`,
+		`opgl_gateway_upstream_connections_total{host="` + host + `",reused="true"} 1`,
+		`opgl_gateway_upstream_open_connections{host="` + host + `"} 1`,
+		`opgl_gateway_upstream_connect_duration_seconds_count{host="` + host + `"} 1`,
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(output, expectedLine) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expectedLine, output)
+		}
+	}
+}
+
+// TestServiceProxy_CustomDialer tests that upstream connections go through the configured dialer
+func TestServiceProxy_CustomDialer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(models.Summoner{ID: "test-id"})
+	}))
+	defer mockServer.Close()
+
+	// Route every connection to the mock server, as a bastion dialer would route them through a tunnel
+	var dialedAddresses []string
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL: "http://data.internal:8081",
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			dialedAddresses = append(dialedAddresses, address)
+			return (&net.Dialer{}).DialContext(ctx, network, mockServer.Listener.Addr().String())
+		},
+	})
+
+	if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(dialedAddresses) != 1 || dialedAddresses[0] != "data.internal:8081" {
+		t.Errorf("Expected one dial to data.internal:8081, got %v", dialedAddresses)
+	}
+}
+
+// TestServiceProxy_Forward tests that declared routes are proxied without client credentials
+func TestServiceProxy_Forward(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/internal/ranked" || request.URL.RawQuery != "queue=solo" {
+			t.Errorf("Expected '/internal/ranked?queue=solo', got '%s'", request.URL.RequestURI())
+		}
+		if request.Header.Get("X-API-Key") != "" {
+			t.Error("Expected X-API-Key to be stripped")
+		}
+		body, _ := io.ReadAll(request.Body)
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write(body)
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:0")
+	handler := proxy.Forward(routes.Route{Upstream: "data", UpstreamPath: "/internal/ranked"})
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ranked?queue=solo", strings.NewReader(`{"puuid":"abc"}`))
+	request.Header.Set("X-API-Key", "client-key")
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusCreated || responseRecorder.Body.String() != `{"puuid":"abc"}` {
+		t.Errorf("Expected upstream response to pass through, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	// Unreachable upstreams map to the service's error code
+	unreachable := NewServiceProxy("http://localhost:0", "http://localhost:0").Forward(routes.Route{Upstream: "cortex", UpstreamPath: "/x"})
+	responseRecorder = httptest.NewRecorder()
+	unreachable.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, "/x", nil))
+	if responseRecorder.Code != http.StatusBadGateway || !strings.Contains(responseRecorder.Body.String(), "CORTEX_SERVICE_ERROR") {
+		t.Errorf("Expected 502 CORTEX_SERVICE_ERROR, got %d '%s'", responseRecorder.Code, responseRecorder.Body.String())
+	}
+}
+
+// TestServiceProxy_ForwardTransform tests header injection, field renames and the consumer header
+func TestServiceProxy_ForwardTransform(t *testing.T) {
+	var receivedHeaders http.Header
+	var receivedBody map[string]string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedHeaders = request.Header
+		json.NewDecoder(request.Body).Decode(&receivedBody)
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "")
+	handler := proxy.Forward(routes.Route{
+		Upstream:     "data",
+		UpstreamPath: "/api/v1/ranked",
+		Transform: routes.Transform{
+			SetHeaders:     map[string]string{"X-Source": "gateway"},
+			RenameFields:   map[string]string{"gameName": "riotGameName"},
+			ConsumerHeader: "X-OPGL-Consumer",
+		},
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ranked", strings.NewReader(`{"gameName":"Faker","tagLine":"KR1"}`))
+	request.Header.Set("X-OPGL-Consumer", "spoofed")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if receivedHeaders.Get("X-Source") != "gateway" {
+		t.Errorf("Expected X-Source 'gateway', got '%s'", receivedHeaders.Get("X-Source"))
+	}
+	if receivedHeaders.Get("X-OPGL-Consumer") != "" {
+		t.Errorf("Expected client-supplied consumer header to be dropped, got '%s'", receivedHeaders.Get("X-OPGL-Consumer"))
+	}
+	if receivedBody["riotGameName"] != "Faker" || receivedBody["gameName"] != "" || receivedBody["tagLine"] != "KR1" {
+		t.Errorf("Expected gameName to be renamed to riotGameName, got %v", receivedBody)
+	}
+}
+
+// TestServiceProxy_ForwardResponseRewrite tests stripping, renaming and injecting response fields
+func TestServiceProxy_ForwardResponseRewrite(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte(`{"tier":"GOLD","participants":[{"puuid":"p1","championId":1},{"puuid":"p2","championId":2}]}`))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "")
+	handler := proxy.Forward(routes.Route{
+		Upstream:     "data",
+		UpstreamPath: "/api/v1/ranked",
+		Response: routes.ResponseRewrite{
+			StripFields:  []string{"participants.puuid"},
+			RenameFields: map[string]string{"participants.championId": "champion"},
+			InjectFields: map[string]interface{}{"links": map[string]interface{}{"self": "/api/v1/ranked"}},
+		},
+	})
+
+	responseRecorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ranked", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(responseRecorder, request)
+
+	expectedBody := `{"links":{"self":"/api/v1/ranked"},"participants":[{"champion":1},{"champion":2}],"tier":"GOLD"}`
+	if responseRecorder.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, responseRecorder.Body.String())
+	}
+	if responseRecorder.Header().Get("Content-Length") != strconv.Itoa(len(expectedBody)) {
+		t.Errorf("Expected Content-Length %d, got '%s'", len(expectedBody), responseRecorder.Header().Get("Content-Length"))
+	}
+}
+
+// TestServiceProxy_CustomPaths tests that configured upstream paths replace the defaults
+func TestServiceProxy_CustomPaths(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/data/v2/summoner" {
+			t.Errorf("Expected path '/data/v2/summoner', got '%s'", request.URL.Path)
+		}
+		json.NewEncoder(writer).Encode(models.Summoner{ID: "test-id"})
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxyWithConfig(Config{
+		DataServiceURL: mockServer.URL,
+		Paths:          Paths{Summoner: "/data/v2/summoner"},
+	})
+
+	if _, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if proxy.paths.Matches != "/api/v1/matches" {
+		t.Errorf("Expected default matches path, got '%s'", proxy.paths.Matches)
+	}
+}
+
 // TestServiceProxyImplementsInterface verifies ServiceProxy implements ServiceProxyInterface
 func TestServiceProxyImplementsInterface(t *testing.T) {
 	var proxyInterface ServiceProxyInterface = NewServiceProxy("http://localhost:8081", "http://localhost:8082")
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+)
+
+// maxRewrittenBodyBytes bounds upstream bodies read into memory for rewriting
+const maxRewrittenBodyBytes = 8 << 20
+
+// rewriteResponse applies a route's response rewrite to a successful JSON upstream response
+// Other responses, and bodies that turn out not to be JSON, are passed through unchanged.
+func rewriteResponse(response *http.Response, rewrite routes.ResponseRewrite) error {
+	if response.StatusCode < 200 || response.StatusCode >= 300 || response.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxRewrittenBodyBytes+1))
+	if err != nil {
+		response.Body.Close()
+		return err
+	}
+
+	// Bodies too large to rewrite are streamed on unchanged, starting with the bytes already read
+	if len(body) > maxRewrittenBodyBytes {
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+		return nil
+	}
+	response.Body.Close()
+
+	if rewrittenBody, err := rewriteJSON(body, rewrite); err == nil {
+		body = rewrittenBody
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// rewriteJSON strips, renames and injects fields in a JSON document
+// Field paths are dot-separated and descend through nested objects and arrays,
+// so "participants.puuid" strips the PUUID of every participant.
+func rewriteJSON(body []byte, rewrite routes.ResponseRewrite) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	for _, path := range rewrite.StripFields {
+		stripField(document, strings.Split(path, "."))
+	}
+	for path, newName := range rewrite.RenameFields {
+		renameField(document, strings.Split(path, "."), newName)
+	}
+	if len(rewrite.InjectFields) > 0 {
+		injectFields(document, rewrite.InjectFields)
+	}
+
+	return json.Marshal(document)
+}
+
+// stripField removes the field at path from every object it reaches
+func stripField(value interface{}, path []string) {
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, element := range typed {
+			stripField(element, path)
+		}
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(typed, path[0])
+			return
+		}
+		stripField(typed[path[0]], path[1:])
+	}
+}
+
+// renameField renames the field at path to newName within the same object
+func renameField(value interface{}, path []string, newName string) {
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, element := range typed {
+			renameField(element, path, newName)
+		}
+	case map[string]interface{}:
+		if len(path) > 1 {
+			renameField(typed[path[0]], path[1:], newName)
+			return
+		}
+		if fieldValue, exists := typed[path[0]]; exists {
+			delete(typed, path[0])
+			typed[newName] = fieldValue
+		}
+	}
+}
+
+// injectFields adds static fields to a top-level object, or to each object of a top-level array
+func injectFields(document interface{}, fields map[string]interface{}) {
+	switch typed := document.(type) {
+	case []interface{}:
+		for _, element := range typed {
+			if object, ok := element.(map[string]interface{}); ok {
+				for name, value := range fields {
+					object[name] = value
+				}
+			}
+		}
+	case map[string]interface{}:
+		for name, value := range fields {
+			typed[name] = value
+		}
+	}
+}
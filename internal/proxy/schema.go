@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/models"
+)
+
+// SchemaVersionHeader is set by the data service to the shape of its response body
+// Responses without it are version 1, so the data service can move to a new shape
+// before or after the gateway learns it, instead of both releasing in lockstep.
+const SchemaVersionHeader = "X-OPGL-Schema-Version"
+
+// Data service payload shapes
+const (
+	// schemaV1 is the original shape, which matches the gateway's models
+	schemaV1 = "1"
+	// schemaV2 follows Riot's Riot ID migration: summoner names are replaced by gameName/tagLine
+	// and matches keep match-v5's metadata/info split with gameCreation in epoch milliseconds
+	schemaV2 = "2"
+)
+
+// unsupportedSchemaError reports a data service response in a shape the gateway can't read
+type unsupportedSchemaError struct {
+	version string
+}
+
+// Error describes the unsupported version
+func (schemaErr *unsupportedSchemaError) Error() string {
+	return fmt.Sprintf("data service sent unsupported schema version %q", schemaErr.version)
+}
+
+// schemaVersion returns the payload shape of a data service response
+func schemaVersion(response *http.Response) string {
+	version := strings.TrimSpace(response.Header.Get(SchemaVersionHeader))
+	if version == "" {
+		return schemaV1
+	}
+	return version
+}
+
+// summonerV2 is a version 2 summoner
+type summonerV2 struct {
+	PUUID         string `json:"puuid"`
+	GameName      string `json:"gameName"`
+	TagLine       string `json:"tagLine"`
+	ProfileIconID int    `json:"profileIconId"`
+	SummonerLevel int64  `json:"summonerLevel"`
+	// ID and AccountID are deprecated by Riot and may be absent
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+}
+
+// matchV2 is a version 2 match
+type matchV2 struct {
+	Metadata struct {
+		MatchID string `json:"matchId"`
+	} `json:"metadata"`
+	Info struct {
+		// GameCreation is epoch milliseconds
+		GameCreation int64           `json:"gameCreation"`
+		GameDuration int             `json:"gameDuration"`
+		GameMode     string          `json:"gameMode"`
+		GameType     string          `json:"gameType"`
+		Participants []participantV2 `json:"participants"`
+	} `json:"info"`
+}
+
+// participantV2 is a version 2 match participant
+type participantV2 struct {
+	PUUID                       string `json:"puuid"`
+	RiotIDGameName              string `json:"riotIdGameName"`
+	RiotIDTagline               string `json:"riotIdTagline"`
+	ChampionID                  int    `json:"championId"`
+	ChampionName                string `json:"championName"`
+	Kills                       int    `json:"kills"`
+	Deaths                      int    `json:"deaths"`
+	Assists                     int    `json:"assists"`
+	GoldEarned                  int    `json:"goldEarned"`
+	TotalDamageDealtToChampions int    `json:"totalDamageDealtToChampions"`
+	TotalDamageTaken            int    `json:"totalDamageTaken"`
+	VisionScore                 int    `json:"visionScore"`
+	TotalMinionsKilled          int    `json:"totalMinionsKilled"`
+	Win                         bool   `json:"win"`
+	TeamPosition                string `json:"teamPosition"`
+}
+
+// riotID joins a game name and tag line the way players write them, e.g. "Faker#KR1"
+func riotID(gameName string, tagLine string) string {
+	if tagLine == "" {
+		return gameName
+	}
+	return gameName + "#" + tagLine
+}
+
+// decodeSummoner reads a data service summoner response in any supported shape
+func decodeSummoner(response *http.Response) (*models.Summoner, error) {
+	switch version := schemaVersion(response); version {
+	case schemaV1:
+		var summoner models.Summoner
+		if err := json.NewDecoder(response.Body).Decode(&summoner); err != nil {
+			return nil, err
+		}
+		return &summoner, nil
+	case schemaV2:
+		var summoner summonerV2
+		if err := json.NewDecoder(response.Body).Decode(&summoner); err != nil {
+			return nil, err
+		}
+		return &models.Summoner{
+			ID:            summoner.ID,
+			AccountID:     summoner.AccountID,
+			PUUID:         summoner.PUUID,
+			Name:          riotID(summoner.GameName, summoner.TagLine),
+			ProfileIconID: summoner.ProfileIconID,
+			SummonerLevel: summoner.SummonerLevel,
+		}, nil
+	default:
+		io.Copy(io.Discard, response.Body)
+		return nil, &unsupportedSchemaError{version: version}
+	}
+}
+
+// decodeMatches reads a data service match list response in any supported shape
+func decodeMatches(response *http.Response) ([]models.Match, error) {
+	switch version := schemaVersion(response); version {
+	case schemaV1:
+		var matches []models.Match
+		if err := json.NewDecoder(response.Body).Decode(&matches); err != nil {
+			return nil, err
+		}
+		return matches, nil
+	case schemaV2:
+		var matchesV2 []matchV2
+		if err := json.NewDecoder(response.Body).Decode(&matchesV2); err != nil {
+			return nil, err
+		}
+
+		matches := make([]models.Match, len(matchesV2))
+		for i, match := range matchesV2 {
+			participants := make([]models.Participant, len(match.Info.Participants))
+			for j, participant := range match.Info.Participants {
+				participants[j] = models.Participant{
+					PUUID:                       participant.PUUID,
+					SummonerName:                riotID(participant.RiotIDGameName, participant.RiotIDTagline),
+					ChampionID:                  participant.ChampionID,
+					ChampionName:                participant.ChampionName,
+					Kills:                       participant.Kills,
+					Deaths:                      participant.Deaths,
+					Assists:                     participant.Assists,
+					GoldEarned:                  participant.GoldEarned,
+					TotalDamageDealtToChampions: participant.TotalDamageDealtToChampions,
+					TotalDamageTaken:            participant.TotalDamageTaken,
+					VisionScore:                 participant.VisionScore,
+					TotalMinionsKilled:          participant.TotalMinionsKilled,
+					Win:                         participant.Win,
+					TeamPosition:                participant.TeamPosition,
+				}
+			}
+
+			matches[i] = models.Match{
+				MatchID:      match.Metadata.MatchID,
+				GameCreation: time.UnixMilli(match.Info.GameCreation).UTC(),
+				GameDuration: match.Info.GameDuration,
+				GameMode:     match.Info.GameMode,
+				GameType:     match.Info.GameType,
+				Participants: participants,
+			}
+		}
+		return matches, nil
+	default:
+		io.Copy(io.Discard, response.Body)
+		return nil, &unsupportedSchemaError{version: version}
+	}
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+)
+
+// TestGetSummonerByRiotID_SchemaV2 tests that a version 2 summoner is translated into the stable model
+func TestGetSummonerByRiotID_SchemaV2(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set(SchemaVersionHeader, "2")
+		writer.Write([]byte(`{"puuid":"test-puuid","gameName":"TestPlayer","tagLine":"NA1","profileIconId":1234,"summonerLevel":100}`))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	summoner, err := proxy.GetSummonerByRiotID(context.Background(), "na", "TestPlayer", "NA1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if summoner.PUUID != "test-puuid" || summoner.Name != "TestPlayer#NA1" || summoner.ProfileIconID != 1234 || summoner.SummonerLevel != 100 {
+		t.Errorf("Unexpected summoner %+v", summoner)
+	}
+}
+
+// TestGetMatchesByPUUID_SchemaV2 tests that version 2 matches are translated into the stable model
+func TestGetMatchesByPUUID_SchemaV2(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set(SchemaVersionHeader, "2")
+		writer.Write([]byte(`[{"metadata":{"matchId":"NA1_123"},"info":{"gameCreation":1700000000000,"gameDuration":1800,"gameMode":"CLASSIC","gameType":"MATCHED_GAME","participants":[{"puuid":"test-puuid","riotIdGameName":"TestPlayer","riotIdTagline":"NA1","championId":157,"championName":"Yasuo","kills":10,"deaths":2,"assists":5,"win":true,"teamPosition":"MIDDLE"}]}}]`))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	matches, err := proxy.GetMatchesByPUUID(context.Background(), "na", "test-puuid", 20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(matches))
+	}
+	match := matches[0]
+	if match.MatchID != "NA1_123" || match.GameDuration != 1800 || match.GameMode != "CLASSIC" {
+		t.Errorf("Unexpected match %+v", match)
+	}
+	if !match.GameCreation.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Expected gameCreation %v, got %v", time.UnixMilli(1700000000000), match.GameCreation)
+	}
+	participant := match.Participants[0]
+	if participant.SummonerName != "TestPlayer#NA1" || participant.ChampionName != "Yasuo" || participant.Kills != 10 || !participant.Win {
+		t.Errorf("Unexpected participant %+v", participant)
+	}
+}
+
+// TestGetMatchesByRiotID_UnsupportedSchema tests that an unknown schema version is a data service error
+func TestGetMatchesByRiotID_UnsupportedSchema(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set(SchemaVersionHeader, "3")
+		writer.Write([]byte(`{"matches":[]}`))
+	}))
+	defer mockServer.Close()
+
+	proxy := NewServiceProxy(mockServer.URL, "http://localhost:8082")
+
+	matches, err := proxy.GetMatchesByRiotID(context.Background(), "na", "TestPlayer", "NA1", 20)
+	if matches != nil {
+		t.Error("Expected matches to be nil on error")
+	}
+	if err == nil {
+		t.Fatal("Expected error for an unsupported schema version, got nil")
+	}
+	if apiError := apierrors.FromError(err); apiError.Code != apierrors.ErrCodeDataServiceError {
+		t.Errorf("Expected %s, got %s", apierrors.ErrCodeDataServiceError, apiError.Code)
+	}
+}
@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+	"github.com/OPGLOL/opgl-gateway-service/internal/unixsocket"
+)
+
+// clientMetrics records connection-level metrics for upstream calls
+// Timings come from httptrace; open and idle connection counts come from wrapping
+// every dialed connection, since http.Transport doesn't expose its pool size.
+type clientMetrics struct {
+	dnsDuration     *metrics.Summary
+	connectDuration *metrics.Summary
+	tlsDuration     *metrics.Summary
+	connections     *metrics.Counter
+	openConnections *metrics.Gauge
+	idleConnections *metrics.Gauge
+	responseBytes   *metrics.Summary
+	tooLarge        *metrics.Counter
+}
+
+// newClientMetrics registers the upstream client metrics
+func newClientMetrics(registry *metrics.Registry) *clientMetrics {
+	return &clientMetrics{
+		dnsDuration: registry.NewSummary(
+			"opgl_gateway_upstream_dns_duration_seconds",
+			"Time spent resolving upstream hostnames.",
+			"host",
+		),
+		connectDuration: registry.NewSummary(
+			"opgl_gateway_upstream_connect_duration_seconds",
+			"Time spent establishing TCP connections to upstreams.",
+			"host",
+		),
+		tlsDuration: registry.NewSummary(
+			"opgl_gateway_upstream_tls_handshake_duration_seconds",
+			"Time spent in TLS handshakes with upstreams.",
+			"host",
+		),
+		connections: registry.NewCounter(
+			"opgl_gateway_upstream_connections_total",
+			"Connections used for upstream requests, by whether they were reused from the pool.",
+			"host", "reused",
+		),
+		openConnections: registry.NewGauge(
+			"opgl_gateway_upstream_open_connections",
+			"Connections currently open to each upstream.",
+			"host",
+		),
+		idleConnections: registry.NewGauge(
+			"opgl_gateway_upstream_idle_connections",
+			"Open connections currently idle in the pool.",
+			"host",
+		),
+		responseBytes: registry.NewSummary(
+			"opgl_gateway_upstream_response_bytes",
+			"Size of upstream response bodies read by the gateway.",
+			"host",
+		),
+		tooLarge: registry.NewCounter(
+			"opgl_gateway_upstream_responses_too_large_total",
+			"Upstream responses aborted for exceeding the response size limit.",
+			"host",
+		),
+	}
+}
+
+// recordResponseSize records the bytes read from one upstream response
+func (clientMetrics *clientMetrics) recordResponseSize(host string, read int64, tooLarge bool) {
+	clientMetrics.responseBytes.Observe(float64(read), host)
+	if tooLarge {
+		clientMetrics.tooLarge.Inc(host)
+	}
+}
+
+// withTrace attaches an httptrace.ClientTrace that records metrics for one request to host
+func (clientMetrics *clientMetrics) withTrace(ctx context.Context, host string) context.Context {
+	// Connect callbacks can run concurrently when dialing several addresses
+	var mutex sync.Mutex
+	var dnsStart, tlsStart time.Time
+	connectStarts := make(map[string]time.Time)
+	var usedConn *trackedConn
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			clientMetrics.dnsDuration.Observe(time.Since(dnsStart).Seconds(), host)
+		},
+		ConnectStart: func(network string, address string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			connectStarts[address] = time.Now()
+		},
+		ConnectDone: func(network string, address string, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				clientMetrics.connectDuration.Observe(time.Since(connectStarts[address]).Seconds(), host)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				clientMetrics.tlsDuration.Observe(time.Since(tlsStart).Seconds(), host)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			clientMetrics.connections.Inc(host, strconv.FormatBool(info.Reused))
+
+			conn := unwrapTrackedConn(info.Conn)
+			if conn != nil {
+				conn.setIdle(false)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			usedConn = conn
+		},
+		PutIdleConn: func(err error) {
+			mutex.Lock()
+			conn := usedConn
+			mutex.Unlock()
+
+			if err == nil && conn != nil {
+				conn.setIdle(true)
+			}
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, trace)
+}
+
+// dialContext wraps dial so every new connection is counted until it is closed
+func (clientMetrics *clientMetrics) dialContext(dial func(ctx context.Context, network string, address string) (net.Conn, error)) func(ctx context.Context, network string, address string) (net.Conn, error) {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		clientMetrics.openConnections.Add(1, address)
+		return &trackedConn{Conn: conn, host: address, clientMetrics: clientMetrics}, nil
+	}
+}
+
+// trackedConn is a net.Conn that keeps the open and idle connection gauges up to date
+type trackedConn struct {
+	net.Conn
+	host          string
+	clientMetrics *clientMetrics
+
+	mutex  sync.Mutex
+	idle   bool
+	closed bool
+}
+
+// setIdle moves the connection in or out of the idle gauge
+func (conn *trackedConn) setIdle(idle bool) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+
+	if conn.closed || conn.idle == idle {
+		return
+	}
+	conn.idle = idle
+
+	if idle {
+		conn.clientMetrics.idleConnections.Add(1, conn.host)
+	} else {
+		conn.clientMetrics.idleConnections.Add(-1, conn.host)
+	}
+}
+
+// Close closes the connection and removes it from the gauges
+func (conn *trackedConn) Close() error {
+	conn.mutex.Lock()
+	if !conn.closed {
+		conn.closed = true
+		conn.clientMetrics.openConnections.Add(-1, conn.host)
+		if conn.idle {
+			conn.idle = false
+			conn.clientMetrics.idleConnections.Add(-1, conn.host)
+		}
+	}
+	conn.mutex.Unlock()
+
+	return conn.Conn.Close()
+}
+
+// unwrapTrackedConn returns the trackedConn underneath conn, looking through TLS
+func unwrapTrackedConn(conn net.Conn) *trackedConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	tracked, _ := conn.(*trackedConn)
+	return tracked
+}
+
+// newTransport builds the upstream transport from config
+// Requests go through HTTP_PROXY/HTTPS_PROXY (honoring NO_PROXY), dial through
+// config.DialContext when set, resolve hostnames through a DNS cache when
+// config.DNSCacheTTL is positive, and are instrumented when clientMetrics is set.
+// Hosts registered in sockets skip all of that except instrumentation and dial their unix socket.
+func newTransport(config Config, sockets *unixsocket.Sockets, clientMetrics *clientMetrics) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = sockets.Proxy(http.ProxyFromEnvironment)
+	if config.TLSClientConfig != nil {
+		transport.TLSClientConfig = config.TLSClientConfig
+	}
+
+	dial := unixsocket.DialFunc(config.DialContext)
+	if dial == nil {
+		dial = config.EgressGuard.Dialer().DialContext
+	}
+	if config.DNSCacheTTL > 0 {
+		dial = newDNSCache(net.DefaultResolver, config.DNSCacheTTL).dialContext(dial)
+	}
+	dial = sockets.DialContext(dial)
+	if clientMetrics != nil {
+		dial = clientMetrics.dialContext(dial)
+	}
+	transport.DialContext = dial
+	return transport
+}
+
+// hostLabel returns host:port for a request URL so trace metrics match connection gauges
+func hostLabel(request *http.Request) string {
+	if request.URL.Port() != "" {
+		return request.URL.Host
+	}
+	if request.URL.Scheme == "https" {
+		return net.JoinHostPort(request.URL.Hostname(), "443")
+	}
+	return net.JoinHostPort(request.URL.Hostname(), "80")
+}
@@ -0,0 +1,393 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/filters"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
+	"gopkg.in/yaml.v3"
+)
+
+// Named upstreams a route can point at; any other upstream must be an absolute http(s) URL
+const (
+	UpstreamData   = "data"
+	UpstreamCortex = "cortex"
+)
+
+// Built-in middleware names usable in a group's chain (declared filters may be used too)
+const (
+	MiddlewareSLO               = "slo"
+	MiddlewareRateLimit         = "ratelimit"
+	MiddlewareRateLimitOptional = "ratelimit-optional"
+	MiddlewareSignature         = "signature"
+	MiddlewareIdempotency       = "idempotency"
+	MiddlewareTimeout           = "timeout"
+	MiddlewareCache             = "cache"
+	MiddlewareCompress          = "compress"
+	MiddlewareExperiments       = "experiments"
+	MiddlewareAnomaly           = "anomaly"
+	MiddlewareConcurrency       = "concurrency"
+	MiddlewareScope             = "scope"
+	MiddlewareIPAllowlist       = "ip-allowlist"
+	MiddlewareBot               = "bot"
+	MiddlewareAuth              = "auth"
+	MiddlewareAuthOptional      = "optional-auth"
+)
+
+// knownMiddleware lists the names accepted in a group's middleware chain
+var knownMiddleware = map[string]bool{
+	MiddlewareSLO:               true,
+	MiddlewareRateLimit:         true,
+	MiddlewareRateLimitOptional: true,
+	MiddlewareSignature:         true,
+	MiddlewareIdempotency:       true,
+	MiddlewareTimeout:           true,
+	MiddlewareCache:             true,
+	MiddlewareCompress:          true,
+	MiddlewareExperiments:       true,
+	MiddlewareAnomaly:           true,
+	MiddlewareConcurrency:       true,
+	MiddlewareScope:             true,
+	MiddlewareIPAllowlist:       true,
+	MiddlewareBot:               true,
+	MiddlewareAuth:              true,
+	MiddlewareAuthOptional:      true,
+}
+
+// keyRestrictions enforce what the rate limit middleware learnt about the caller's key; they
+// pass requests through when no key was accepted, so a group chain that limits by key must
+// list them after its rate limit middleware
+var keyRestrictions = []string{MiddlewareIPAllowlist, MiddlewareScope}
+
+// Route is an additional proxied endpoint declared in the route file
+type Route struct {
+	// Path is the gateway path, e.g. /api/v1/ranked
+	Path string `yaml:"path"`
+	// Method is the HTTP method (POST when omitted)
+	Method string `yaml:"method"`
+	// Upstream is "data", "cortex" or an absolute http(s) URL
+	Upstream string `yaml:"upstream"`
+	// UpstreamPath is the path on the upstream (Path when omitted)
+	UpstreamPath string `yaml:"upstreamPath"`
+	// AuthRequired rejects requests without a valid API key; otherwise a key is optional
+	AuthRequired bool `yaml:"authRequired"`
+	// RateLimitClass is forwarded to the auth service so routes can have their own limits
+	RateLimitClass string `yaml:"rateLimitClass"`
+	// Scope restricts the route to API keys holding it when the auth service limits a key's scopes
+	// (any key may call a route without a scope)
+	Scope string `yaml:"scope"`
+	// RateLimitCost is the number of requests each call counts as against the rate limit (1 when omitted)
+	RateLimitCost int `yaml:"rateLimitCost"`
+	// CacheTTL caches successful responses for this long (0 disables caching)
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// Transform rewrites requests before they are sent upstream
+	Transform Transform `yaml:"transform"`
+	// Response rewrites successful JSON responses before they are returned to the client
+	Response ResponseRewrite `yaml:"response"`
+	// Warm lists requests replayed on startup and before each cacheTTL expiry to keep the cache filled
+	Warm []WarmRequest `yaml:"warm"`
+}
+
+// WarmRequest is a request the gateway sends itself to fill a route's response cache
+// It is cached under the same key as an identical client request.
+type WarmRequest struct {
+	// Query is the raw query string, e.g. queue=solo&region=na
+	Query string `yaml:"query"`
+	// Body is the request body, e.g. {"region": "na"}
+	Body string `yaml:"body"`
+}
+
+// Transform rewrites a declared route's request on its way upstream
+type Transform struct {
+	// SetHeaders injects static headers (an empty value removes the header)
+	SetHeaders map[string]string `yaml:"setHeaders"`
+	// RenameFields renames top-level fields of a JSON request body, old name to new name
+	RenameFields map[string]string `yaml:"renameFields"`
+	// ConsumerHeader names a header set to the caller's API key fingerprint; client values are dropped
+	ConsumerHeader string `yaml:"consumerHeader"`
+}
+
+// Group replaces the middleware chain of a set of API routes
+type Group struct {
+	// Name identifies the group in errors and logs
+	Name string `yaml:"name"`
+	// Paths are built-in API paths or declared route paths
+	Paths []string `yaml:"paths"`
+	// Middleware is the chain applied to every route in the group, outermost first
+	Middleware []string `yaml:"middleware"`
+}
+
+// File is the top-level structure of the route file
+type File struct {
+	// Filters are custom middleware that groups can add to their chain by name
+	Filters []filters.Spec `yaml:"filters"`
+	Groups  []Group        `yaml:"groups"`
+	Routes  []Route        `yaml:"routes"`
+	// Experiments assign API key holders to variants on the listed routes
+	Experiments []experiments.Experiment `yaml:"experiments"`
+	// Announcements are broadcast to clients at GET /api/v1/announcements while active
+	Announcements []announcements.Announcement `yaml:"announcements"`
+	// Tenants are organisations served on their own hostnames, with their branding at GET /api/v1/branding
+	Tenants []tenants.Tenant `yaml:"tenants"`
+}
+
+// Forwarder builds the handler that proxies a route's requests to its upstream
+type Forwarder interface {
+	Forward(route Route) http.Handler
+}
+
+// Methods are the HTTP methods a declared route may use
+var Methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// reservedRoutes are served by the gateway itself and can't be redeclared
+var reservedRoutes = map[string]bool{
+	"POST /health":              true,
+	"GET /readyz":               true,
+	"GET /metrics":              true,
+	"POST /api/v1/summoner":     true,
+	"POST /api/v1/matches":      true,
+	"POST /api/v1/analyze":      true,
+	"GET /api/v1/announcements": true,
+	"GET /api/v1/branding":      true,
+	"GET /api/v1/auth/csrf":     true,
+	"POST /api/v1/auth/login":   true,
+	"POST /api/v1/auth/refresh": true,
+	"POST /api/v1/auth/logout":  true,
+	"GET /docs":                 true,
+	"GET /docs/openapi.json":    true, // docs.SpecPath, spelled out since docs imports this package
+}
+
+// builtInAPIPaths are the built-in routes whose middleware chain a group may replace
+var builtInAPIPaths = map[string]bool{
+	"/api/v1/summoner": true,
+	"/api/v1/matches":  true,
+	"/api/v1/analyze":  true,
+}
+
+// LoadFile reads and validates the route file at path
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read route file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a route file, filling in defaults
+// Unknown keys are rejected so a typo doesn't silently drop a setting.
+func Parse(data []byte) (*File, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var file File
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse route file: %w", err)
+	}
+
+	declared := make(map[string]bool, len(file.Routes))
+	declaredPaths := make(map[string]bool, len(file.Routes))
+	for i := range file.Routes {
+		route := &file.Routes[i]
+		if route.Method == "" {
+			route.Method = http.MethodPost
+		}
+		route.Method = strings.ToUpper(route.Method)
+		if route.UpstreamPath == "" {
+			route.UpstreamPath = route.Path
+		}
+		if route.RateLimitCost == 0 {
+			route.RateLimitCost = 1
+		}
+
+		if err := validate(route); err != nil {
+			return nil, fmt.Errorf("route %d (%s %s): %w", i+1, route.Method, route.Path, err)
+		}
+
+		key := route.Method + " " + route.Path
+		if reservedRoutes[key] {
+			return nil, fmt.Errorf("route %d (%s): already served by the gateway", i+1, key)
+		}
+		if declared[key] {
+			return nil, fmt.Errorf("route %d (%s): declared more than once", i+1, key)
+		}
+		declared[key] = true
+		declaredPaths[route.Path] = true
+	}
+
+	filterNames := make(map[string]bool, len(file.Filters))
+	for i, spec := range file.Filters {
+		switch {
+		case spec.Name == "":
+			return nil, fmt.Errorf("filter %d: name is required", i+1)
+		case knownMiddleware[spec.Name]:
+			return nil, fmt.Errorf("filter %s: name is reserved for built-in middleware", spec.Name)
+		case filterNames[spec.Name]:
+			return nil, fmt.Errorf("filter %s: declared more than once", spec.Name)
+		case (spec.Type == "") == (spec.Plugin == ""):
+			return nil, fmt.Errorf("filter %s: exactly one of type or plugin is required", spec.Name)
+		}
+		filterNames[spec.Name] = true
+	}
+
+	grouped := make(map[string]string)
+	for i, group := range file.Groups {
+		if group.Name == "" {
+			return nil, fmt.Errorf("group %d: name is required", i+1)
+		}
+		for _, name := range group.Middleware {
+			if !knownMiddleware[name] && !filterNames[name] {
+				return nil, fmt.Errorf("group %s: unknown middleware %q", group.Name, name)
+			}
+		}
+		rateLimit := slices.IndexFunc(group.Middleware, func(name string) bool {
+			return name == MiddlewareRateLimit || name == MiddlewareRateLimitOptional
+		})
+		if rateLimit >= 0 {
+			for _, required := range keyRestrictions {
+				if !slices.Contains(group.Middleware[rateLimit+1:], required) {
+					return nil, fmt.Errorf("group %s: %s must follow %s", group.Name, required, group.Middleware[rateLimit])
+				}
+			}
+		}
+		for _, path := range group.Paths {
+			if !builtInAPIPaths[path] && !declaredPaths[path] {
+				return nil, fmt.Errorf("group %s: %s is not an API route", group.Name, path)
+			}
+			if otherGroup, exists := grouped[path]; exists {
+				return nil, fmt.Errorf("group %s: %s is already in group %s", group.Name, path, otherGroup)
+			}
+			grouped[path] = group.Name
+		}
+	}
+
+	if err := experiments.Validate(file.Experiments); err != nil {
+		return nil, err
+	}
+	for _, experiment := range file.Experiments {
+		for _, path := range experiment.Paths {
+			if !builtInAPIPaths[path] && !declaredPaths[path] {
+				return nil, fmt.Errorf("experiment %s: %s is not an API route", experiment.Name, path)
+			}
+		}
+	}
+
+	if err := announcements.Validate(file.Announcements); err != nil {
+		return nil, err
+	}
+
+	if err := tenants.Validate(file.Tenants); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// Chains maps each grouped route path to its middleware chain
+func (file *File) Chains() map[string][]string {
+	chains := make(map[string][]string)
+	for _, group := range file.Groups {
+		for _, path := range group.Paths {
+			chains[path] = group.Middleware
+		}
+	}
+	return chains
+}
+
+// ResponseRewrite reshapes a declared route's JSON response for external clients
+// Field paths are dot-separated and descend through nested objects and arrays.
+type ResponseRewrite struct {
+	// StripFields removes fields, e.g. participants.puuid
+	StripFields []string `yaml:"stripFields"`
+	// RenameFields renames the field at a path to a new name in the same object
+	RenameFields map[string]string `yaml:"renameFields"`
+	// InjectFields adds static fields (links, metadata) to the top-level object or each top-level array element
+	InjectFields map[string]interface{} `yaml:"injectFields"`
+}
+
+// IsZero reports whether the rewrite changes nothing
+func (rewrite ResponseRewrite) IsZero() bool {
+	return len(rewrite.StripFields) == 0 && len(rewrite.RenameFields) == 0 && len(rewrite.InjectFields) == 0
+}
+
+// validate checks a single route after defaults have been applied
+func validate(route *Route) error {
+	if !strings.HasPrefix(route.Path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	// Declared routes are matched before the built-in ones, so a {variable} could shadow them
+	if strings.ContainsAny(route.Path, "{}") {
+		return fmt.Errorf("path cannot contain path variables")
+	}
+	if !strings.HasPrefix(route.UpstreamPath, "/") {
+		return fmt.Errorf("upstreamPath must start with /")
+	}
+
+	if !slices.Contains(Methods, route.Method) {
+		return fmt.Errorf("unsupported method")
+	}
+
+	if route.Upstream != UpstreamData && route.Upstream != UpstreamCortex {
+		upstreamURL, err := url.Parse(route.Upstream)
+		if err != nil || (upstreamURL.Scheme != "http" && upstreamURL.Scheme != "https") || upstreamURL.Host == "" {
+			return fmt.Errorf("upstream must be data, cortex or an absolute http(s) URL, got %q", route.Upstream)
+		}
+	}
+
+	if route.RateLimitCost < 1 {
+		return fmt.Errorf("rateLimitCost must be 1 or more")
+	}
+
+	if route.CacheTTL < 0 {
+		return fmt.Errorf("cacheTTL cannot be negative")
+	}
+	// The upstream answers each consumer differently, so one consumer's response can't be shared
+	if route.CacheTTL > 0 && route.Transform.ConsumerHeader != "" {
+		return fmt.Errorf("cacheTTL cannot be combined with transform.consumerHeader")
+	}
+	if len(route.Warm) > 0 && route.CacheTTL < time.Second {
+		return fmt.Errorf("warm requires a cacheTTL of at least 1s")
+	}
+	for _, warmRequest := range route.Warm {
+		if _, err := url.ParseQuery(warmRequest.Query); err != nil {
+			return fmt.Errorf("warm has an invalid query %q", warmRequest.Query)
+		}
+	}
+
+	for oldName, newName := range route.Transform.RenameFields {
+		if oldName == "" || newName == "" {
+			return fmt.Errorf("transform.renameFields cannot contain empty field names")
+		}
+	}
+
+	for _, path := range route.Response.StripFields {
+		if !validFieldPath(path) {
+			return fmt.Errorf("response.stripFields has an invalid field path %q", path)
+		}
+	}
+	for path, newName := range route.Response.RenameFields {
+		if !validFieldPath(path) || newName == "" || strings.Contains(newName, ".") {
+			return fmt.Errorf("response.renameFields must map a field path to a plain field name, got %q: %q", path, newName)
+		}
+	}
+
+	return nil
+}
+
+// validFieldPath accepts dot-separated field paths without empty segments
+func validFieldPath(path string) bool {
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParse_Defaults tests that a minimal route is filled in with defaults
+func TestParse_Defaults(t *testing.T) {
+	file, err := Parse([]byte(`
+routes:
+  - path: /api/v1/ranked
+    upstream: data
+    authRequired: true
+    rateLimitClass: lookups
+    cacheTTL: 30s
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(file.Routes) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(file.Routes))
+	}
+
+	route := file.Routes[0]
+	if route.Method != "POST" {
+		t.Errorf("Expected default method POST, got '%s'", route.Method)
+	}
+	if route.UpstreamPath != "/api/v1/ranked" {
+		t.Errorf("Expected upstream path to default to the route path, got '%s'", route.UpstreamPath)
+	}
+	if !route.AuthRequired || route.RateLimitClass != "lookups" {
+		t.Errorf("Expected auth and rate-limit class to be kept, got %+v", route)
+	}
+	if route.CacheTTL != 30*time.Second {
+		t.Errorf("Expected cache TTL 30s, got %s", route.CacheTTL)
+	}
+	if route.RateLimitCost != 1 {
+		t.Errorf("Expected default rate limit cost 1, got %d", route.RateLimitCost)
+	}
+}
+
+// TestParse_ExampleFile tests that the shipped example route file is valid
+func TestParse_ExampleFile(t *testing.T) {
+	data, err := os.ReadFile("../../routes.example.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := Parse(data); err != nil {
+		t.Errorf("Expected routes.example.yaml to parse, got %v", err)
+	}
+}
+
+// TestParse_Invalid tests that invalid route files are rejected with a useful error
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		file          string
+		expectedError string
+	}{
+		{"unknown key", "routes:\n  - path: /x\n    upstream: data\n    cache: 5s\n", "field cache not found"},
+		{"relative path", "routes:\n  - path: x\n    upstream: data\n", "path must start with /"},
+		{"path variable", "routes:\n  - path: /api/v1/{name}\n    upstream: data\n", "cannot contain path variables"},
+		{"unknown upstream", "routes:\n  - path: /x\n    upstream: ranked\n", "upstream must be"},
+		{"unsupported method", "routes:\n  - path: /x\n    method: TRACE\n    upstream: data\n", "unsupported method"},
+		{"negative cost", "routes:\n  - path: /x\n    upstream: data\n    rateLimitCost: -2\n", "rateLimitCost must be 1 or more"},
+		{"negative TTL", "routes:\n  - path: /x\n    upstream: data\n    cacheTTL: -1s\n", "cacheTTL cannot be negative"},
+		{"cached consumer header", "routes:\n  - path: /x\n    upstream: data\n    cacheTTL: 5s\n    transform: {consumerHeader: X-Consumer}\n", "cannot be combined with transform.consumerHeader"},
+		{"warm without cache", "routes:\n  - path: /x\n    upstream: data\n    warm: [{body: '{}'}]\n", "warm requires a cacheTTL"},
+		{"built-in route", "routes:\n  - path: /api/v1/summoner\n    upstream: data\n", "already served by the gateway"},
+		{"CSRF token", "routes:\n  - path: /api/v1/auth/csrf\n    method: GET\n    upstream: data\n", "already served by the gateway"},
+		{"session login", "routes:\n  - path: /api/v1/auth/login\n    upstream: data\n", "already served by the gateway"},
+		{"docs page", "routes:\n  - path: /docs\n    method: GET\n    upstream: data\n", "already served by the gateway"},
+		{"OpenAPI document", "routes:\n  - path: /docs/openapi.json\n    method: GET\n    upstream: data\n", "already served by the gateway"},
+		{"unknown middleware", "groups:\n  - name: public\n    paths: [/api/v1/summoner]\n    middleware: [gzip]\n", "unknown middleware"},
+		{"unknown group path", "groups:\n  - name: public\n    paths: [/api/v1/ranked]\n", "not an API route"},
+		{"group without scope", "groups:\n  - name: history\n    paths: [/api/v1/matches]\n    middleware: [slo, ratelimit, ip-allowlist, timeout]\n", "scope must follow ratelimit"},
+		{"scope before rate limit", "groups:\n  - name: public\n    paths: [/api/v1/matches]\n    middleware: [scope, ratelimit-optional, ip-allowlist]\n", "scope must follow ratelimit-optional"},
+		{"group without IP allowlist", "groups:\n  - name: history\n    paths: [/api/v1/matches]\n    middleware: [slo, ratelimit, scope]\n", "ip-allowlist must follow ratelimit"},
+		{"path in two groups", "groups:\n  - name: a\n    paths: [/api/v1/matches]\n  - name: b\n    paths: [/api/v1/matches]\n", "already in group a"},
+		{"filter shadowing middleware", "filters:\n  - name: slo\n    type: headers\n", "reserved for built-in middleware"},
+		{"filter without type", "filters:\n  - name: tenant\n", "exactly one of type or plugin"},
+		{"empty strip path", "routes:\n  - path: /x\n    upstream: data\n    response:\n      stripFields: [participants.]\n", "invalid field path"},
+		{"dotted rename target", "routes:\n  - path: /x\n    upstream: data\n    response:\n      renameFields: {puuid: player.id}\n", "plain field name"},
+		{"experiment on unknown path", "experiments:\n  - name: model\n    paths: [/api/v1/ranked]\n    variants: [{name: a, weight: 1}, {name: b, weight: 1}]\n", "not an API route"},
+		{"duplicate route", "routes:\n  - path: /x\n    upstream: data\n  - path: /x\n    method: post\n    upstream: cortex\n", "declared more than once"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Parse([]byte(testCase.file))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("Expected error containing '%s', got '%v'", testCase.expectedError, err)
+			}
+		})
+	}
+}
+
+// TestFile_Chains tests that groups map their paths to the group's chain
+func TestFile_Chains(t *testing.T) {
+	file, err := Parse([]byte(`
+filters:
+  - name: tenant
+    type: headers
+groups:
+  - name: public
+    paths: [/api/v1/champions]
+    middleware: [slo, ratelimit-optional, ip-allowlist, scope, tenant, timeout]
+  - name: history
+    paths: [/api/v1/matches]
+    middleware: [slo, ratelimit, ip-allowlist, scope, compress, timeout]
+routes:
+  - path: /api/v1/champions
+    method: GET
+    upstream: data
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	chains := file.Chains()
+	if strings.Join(chains["/api/v1/champions"], ",") != "slo,ratelimit-optional,ip-allowlist,scope,tenant,timeout" {
+		t.Errorf("Unexpected chain for /api/v1/champions: %v", chains["/api/v1/champions"])
+	}
+	if strings.Join(chains["/api/v1/matches"], ",") != "slo,ratelimit,ip-allowlist,scope,compress,timeout" {
+		t.Errorf("Unexpected chain for /api/v1/matches: %v", chains["/api/v1/matches"])
+	}
+	if _, exists := chains["/api/v1/summoner"]; exists {
+		t.Error("Expected ungrouped routes to keep their default chain")
+	}
+}
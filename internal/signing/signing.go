@@ -0,0 +1,53 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// signatureVersion prefixes every signature so the scheme can evolve
+const signatureVersion = "v1"
+
+// Sign returns the signature for message at the given unix timestamp
+// The signed payload is "<timestamp>.<message>" and the result has the form "v1=<hex HMAC-SHA256>"
+func Sign(secret string, timestamp int64, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(message)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signatureHeader contains a valid signature for message
+// The header may carry several comma-separated signatures (e.g. during secret rotation);
+// any one of them matching is sufficient.
+func Verify(secret string, timestamp int64, message []byte, signatureHeader string) bool {
+	expectedSignature := []byte(Sign(secret, timestamp, message))
+
+	for _, candidate := range strings.Split(signatureHeader, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(candidate)), expectedSignature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestMessage builds the canonical message signed for an HTTP request
+// requestURI is the escaped path and query as sent (url.URL.RequestURI), so the query can't be
+// altered either. The nonce is part of the signed message so it can't be swapped to replay a request.
+func RequestMessage(method string, requestURI string, nonce string, body []byte) []byte {
+	message := make([]byte, 0, len(method)+len(requestURI)+len(nonce)+len(body)+3)
+	message = append(message, method...)
+	message = append(message, '.')
+	message = append(message, requestURI...)
+	message = append(message, '.')
+	message = append(message, nonce...)
+	message = append(message, '.')
+	message = append(message, body...)
+	return message
+}
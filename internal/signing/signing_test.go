@@ -0,0 +1,76 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSign_Format tests that signatures carry the version prefix
+func TestSign_Format(t *testing.T) {
+	signature := Sign("secret", 1700000000, []byte("payload"))
+
+	if !strings.HasPrefix(signature, "v1=") {
+		t.Errorf("Expected signature to start with 'v1=', got '%s'", signature)
+	}
+
+	// 32-byte SHA-256 digest is 64 hex characters
+	if len(signature) != len("v1=")+64 {
+		t.Errorf("Expected signature length %d, got %d", len("v1=")+64, len(signature))
+	}
+}
+
+// TestVerify_Valid tests that a matching signature verifies
+func TestVerify_Valid(t *testing.T) {
+	signature := Sign("secret", 1700000000, []byte("payload"))
+
+	if !Verify("secret", 1700000000, []byte("payload"), signature) {
+		t.Error("Expected signature to verify")
+	}
+}
+
+// TestVerify_Tampered tests that altered inputs fail verification
+func TestVerify_Tampered(t *testing.T) {
+	signature := Sign("secret", 1700000000, []byte("payload"))
+
+	testCases := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		message   string
+	}{
+		{"different secret", "other-secret", 1700000000, "payload"},
+		{"different timestamp", "secret", 1700000001, "payload"},
+		{"different message", "secret", 1700000000, "payload2"},
+	}
+
+	for _, testCase := range testCases {
+		if Verify(testCase.secret, testCase.timestamp, []byte(testCase.message), signature) {
+			t.Errorf("%s: expected verification to fail", testCase.name)
+		}
+	}
+}
+
+// TestVerify_MultipleSignatures tests that any signature in a comma-separated header is accepted
+func TestVerify_MultipleSignatures(t *testing.T) {
+	oldSignature := Sign("old-secret", 1700000000, []byte("payload"))
+	newSignature := Sign("new-secret", 1700000000, []byte("payload"))
+	header := newSignature + ", " + oldSignature
+
+	if !Verify("old-secret", 1700000000, []byte("payload"), header) {
+		t.Error("Expected old secret to verify against rotated header")
+	}
+
+	if !Verify("new-secret", 1700000000, []byte("payload"), header) {
+		t.Error("Expected new secret to verify against rotated header")
+	}
+}
+
+// TestRequestMessage tests the canonical request message layout
+func TestRequestMessage(t *testing.T) {
+	message := RequestMessage("GET", "/api/v1/ranked?queue=solo", "nonce-123", []byte(`{"region":"na"}`))
+
+	expected := `GET./api/v1/ranked?queue=solo.nonce-123.{"region":"na"}`
+	if string(message) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, string(message))
+	}
+}
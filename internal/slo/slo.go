@@ -0,0 +1,94 @@
+package slo
+
+import (
+	"net/http"
+	"time"
+
+	apierrors "github.com/OPGLOL/opgl-gateway-service/internal/errors"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// Definition describes a single service level objective
+// An availability SLO counts 5xx responses as bad. A latency SLO (LatencyThreshold > 0)
+// counts non-5xx responses slower than the threshold as bad.
+type Definition struct {
+	Name             string
+	Objective        float64
+	LatencyThreshold time.Duration
+	// Routes limits the SLO to the given route templates (empty applies it to every route)
+	Routes []string
+}
+
+// Tracker classifies requests against SLO definitions and counts good and bad events
+// Alerting computes burn rates from these counters and the exported objectives:
+// (bad / (good + bad)) / (1 - objective).
+type Tracker struct {
+	definitions []Definition
+	events      *metrics.Counter
+}
+
+// NewTracker creates a new Tracker and registers its metrics
+func NewTracker(registry *metrics.Registry, definitions ...Definition) *Tracker {
+	objectives := registry.NewGauge(
+		"opgl_gateway_slo_objective",
+		"Target ratio of good events for each SLO.",
+		"slo",
+	)
+	for _, definition := range definitions {
+		objectives.Set(definition.Objective, definition.Name)
+	}
+
+	return &Tracker{
+		definitions: definitions,
+		events: registry.NewCounter(
+			"opgl_gateway_slo_events_total",
+			"Requests counted against each SLO, split into good and bad.",
+			"slo", "result",
+		),
+	}
+}
+
+// Record classifies a completed request against every applicable SLO
+// Cancelled requests (499) are skipped since the client gave up, not the gateway.
+func (tracker *Tracker) Record(route string, statusCode int, duration time.Duration) {
+	if statusCode == apierrors.StatusClientClosedRequest {
+		return
+	}
+
+	for _, definition := range tracker.definitions {
+		if !definition.appliesTo(route) {
+			continue
+		}
+
+		var good bool
+		if definition.LatencyThreshold > 0 {
+			// Failed requests are already counted by the availability SLO
+			if statusCode >= http.StatusInternalServerError {
+				continue
+			}
+			good = duration <= definition.LatencyThreshold
+		} else {
+			good = statusCode < http.StatusInternalServerError
+		}
+
+		result := "bad"
+		if good {
+			result = "good"
+		}
+		tracker.events.Inc(definition.Name, result)
+	}
+}
+
+// appliesTo reports whether the SLO covers the given route template
+func (definition Definition) appliesTo(route string) bool {
+	if len(definition.Routes) == 0 {
+		return true
+	}
+
+	for _, definitionRoute := range definition.Routes {
+		if definitionRoute == route {
+			return true
+		}
+	}
+	return false
+}
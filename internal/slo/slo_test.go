@@ -0,0 +1,70 @@
+package slo
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
+)
+
+// renderMetrics scrapes the registry through its HTTP handler
+func renderMetrics(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	return recorder.Body.String()
+}
+
+// TestTracker_Availability tests that only 5xx responses are bad for availability
+func TestTracker_Availability(t *testing.T) {
+	registry := metrics.NewRegistry()
+	tracker := NewTracker(registry, Definition{Name: "availability", Objective: 0.995})
+
+	tracker.Record("/api/v1/summoner", 200, time.Millisecond)
+	tracker.Record("/api/v1/summoner", 404, time.Millisecond)
+	tracker.Record("/api/v1/summoner", 503, time.Millisecond)
+	tracker.Record("/api/v1/summoner", 499, time.Millisecond)
+
+	output := renderMetrics(t, registry)
+
+	expectedLines := []string{
+		`opgl_gateway_slo_objective{slo="availability"} 0.995`,
+		`opgl_gateway_slo_events_total{slo="availability",result="good"} 2`,
+		`opgl_gateway_slo_events_total{slo="availability",result="bad"} 1`,
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(output, expectedLine) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expectedLine, output)
+		}
+	}
+}
+
+// TestTracker_Latency tests threshold classification and route filtering for latency SLOs
+func TestTracker_Latency(t *testing.T) {
+	registry := metrics.NewRegistry()
+	tracker := NewTracker(registry, Definition{
+		Name:             "lookup-latency",
+		Objective:        0.99,
+		LatencyThreshold: time.Second,
+		Routes:           []string{"/api/v1/summoner"},
+	})
+
+	tracker.Record("/api/v1/summoner", 200, 500*time.Millisecond)
+	tracker.Record("/api/v1/summoner", 200, 2*time.Second)
+	tracker.Record("/api/v1/summoner", 500, 2*time.Second)
+	tracker.Record("/api/v1/analyze", 200, 2*time.Second)
+
+	output := renderMetrics(t, registry)
+
+	expectedLines := []string{
+		`opgl_gateway_slo_events_total{slo="lookup-latency",result="good"} 1`,
+		`opgl_gateway_slo_events_total{slo="lookup-latency",result="bad"} 1`,
+	}
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(output, expectedLine) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expectedLine, output)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package tenants
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/validation"
+)
+
+// hexColorPattern matches the #rrggbb colors accepted in branding
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Branding is the presentation metadata a tenant's clients render
+type Branding struct {
+	DisplayName string `yaml:"displayName" json:"displayName,omitempty"`
+	// LogoURL must be an https URL
+	LogoURL string `yaml:"logoUrl" json:"logoUrl,omitempty"`
+	// PrimaryColor is a #rrggbb color
+	PrimaryColor string `yaml:"primaryColor" json:"primaryColor,omitempty"`
+	SupportURL   string `yaml:"supportUrl" json:"supportUrl,omitempty"`
+}
+
+// Tenant is an organisation reaching the gateway through its own hostnames
+type Tenant struct {
+	// ID identifies the tenant in logs and the branding response
+	ID string `yaml:"id"`
+	// Hosts are the tenant's hostnames, matched against the request Host without its port
+	Hosts []string `yaml:"hosts"`
+	// AllowedOrigins are browser origins allowed on the tenant's hosts, on top of OPGL_CORS_ALLOWED_ORIGINS
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	// DefaultRegion is used for requests on the tenant's hosts that don't name a region
+	DefaultRegion string   `yaml:"defaultRegion"`
+	Branding      Branding `yaml:"branding"`
+}
+
+// AllowsOrigin reports whether origin is one of the tenant's allowed origins
+func (tenant *Tenant) AllowsOrigin(origin string) bool {
+	for _, allowedOrigin := range tenant.AllowedOrigins {
+		if allowedOrigin == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks tenant IDs, hosts, origins, regions and branding, normalising hosts and regions
+// A host may belong to only one tenant.
+func Validate(tenants []Tenant) error {
+	ids := make(map[string]bool, len(tenants))
+	hostOwners := make(map[string]string)
+	for i := range tenants {
+		tenant := &tenants[i]
+		if tenant.ID == "" {
+			return fmt.Errorf("tenant %d: id is required", i+1)
+		}
+		if ids[tenant.ID] {
+			return fmt.Errorf("tenant %s: declared more than once", tenant.ID)
+		}
+		ids[tenant.ID] = true
+
+		if len(tenant.Hosts) == 0 {
+			return fmt.Errorf("tenant %s: at least one host is required", tenant.ID)
+		}
+		for j, host := range tenant.Hosts {
+			if strings.ContainsAny(host, ":/ *") || normalizeHost(host) == "" {
+				return fmt.Errorf("tenant %s: host %q must be a bare hostname without scheme, port or wildcard", tenant.ID, host)
+			}
+			host = normalizeHost(host)
+			if owner, taken := hostOwners[host]; taken {
+				return fmt.Errorf("tenant %s: host %s already belongs to tenant %s", tenant.ID, host, owner)
+			}
+			hostOwners[host] = tenant.ID
+			tenant.Hosts[j] = host
+		}
+
+		for _, origin := range tenant.AllowedOrigins {
+			parsedOrigin, err := url.Parse(origin)
+			if err != nil || (parsedOrigin.Scheme != "http" && parsedOrigin.Scheme != "https") || parsedOrigin.Host == "" || strings.TrimSuffix(parsedOrigin.Path, "/") != "" {
+				return fmt.Errorf("tenant %s: allowed origin %q must be scheme://host[:port]", tenant.ID, origin)
+			}
+		}
+
+		if tenant.DefaultRegion != "" {
+			tenant.DefaultRegion = validation.NormalizeRegion(tenant.DefaultRegion)
+			if !validation.ValidRegions[tenant.DefaultRegion] {
+				return fmt.Errorf("tenant %s: unknown default region %q", tenant.ID, tenant.DefaultRegion)
+			}
+		}
+
+		if tenant.Branding.LogoURL != "" && !strings.HasPrefix(tenant.Branding.LogoURL, "https://") {
+			return fmt.Errorf("tenant %s: logoUrl must be an https URL", tenant.ID)
+		}
+		if tenant.Branding.PrimaryColor != "" && !hexColorPattern.MatchString(tenant.Branding.PrimaryColor) {
+			return fmt.Errorf("tenant %s: primaryColor must be a #rrggbb color", tenant.ID)
+		}
+	}
+	return nil
+}
+
+// normalizeHost lowercases a hostname and drops its port and trailing dot
+func normalizeHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// Directory resolves request hosts to tenants
+type Directory struct {
+	tenantsByHost map[string]*Tenant
+}
+
+// NewDirectory creates a Directory for validated tenants
+func NewDirectory(tenants []Tenant) *Directory {
+	directory := &Directory{tenantsByHost: make(map[string]*Tenant)}
+	for i := range tenants {
+		for _, host := range tenants[i].Hosts {
+			directory.tenantsByHost[host] = &tenants[i]
+		}
+	}
+	return directory
+}
+
+// Match returns the tenant owning host (a request Host, with or without port), or nil
+func (directory *Directory) Match(host string) *Tenant {
+	return directory.tenantsByHost[normalizeHost(host)]
+}
+
+// tenantContextKey is the context key for the request's tenant
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant the request's host belongs to
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// FromContext returns the request's tenant, or nil on the gateway's own hosts
+func FromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(tenantContextKey{}).(*Tenant)
+	return tenant
+}
+
+// brandingResponse is the body of GET /api/v1/branding
+type brandingResponse struct {
+	// Tenant is empty on the gateway's own hosts
+	Tenant        string    `json:"tenant,omitempty"`
+	DefaultRegion string    `json:"defaultRegion,omitempty"`
+	Branding      *Branding `json:"branding"`
+}
+
+// Handler serves GET /api/v1/branding with the branding of the request's tenant
+// Hosts without a tenant get a null branding, so clients fall back to their defaults.
+func (directory *Directory) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		response := brandingResponse{}
+		if tenant := directory.Match(request.Host); tenant != nil {
+			response = brandingResponse{Tenant: tenant.ID, DefaultRegion: tenant.DefaultRegion, Branding: &tenant.Branding}
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		// The body depends on the host, so shared caches must key on it
+		writer.Header().Set("Cache-Control", "public, max-age=300")
+		writer.Header().Add("Vary", "Host")
+		json.NewEncoder(writer).Encode(response)
+	})
+}
@@ -0,0 +1,96 @@
+package tenants
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestDirectory creates a directory with one tenant on two hosts
+func newTestDirectory(t *testing.T) *Directory {
+	testTenants := []Tenant{{
+		ID:             "acme",
+		Hosts:          []string{"Stats.Acme.GG", "acme.opgl.gg"},
+		AllowedOrigins: []string{"https://stats.acme.gg"},
+		DefaultRegion:  "EUW",
+		Branding:       Branding{DisplayName: "Acme Stats", PrimaryColor: "#ff6600"},
+	}}
+	if err := Validate(testTenants); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return NewDirectory(testTenants)
+}
+
+// TestDirectory_Match tests host matching with ports, case and trailing dots
+func TestDirectory_Match(t *testing.T) {
+	directory := newTestDirectory(t)
+
+	for _, host := range []string{"stats.acme.gg", "STATS.acme.gg:443", "acme.opgl.gg."} {
+		tenant := directory.Match(host)
+		if tenant == nil || tenant.ID != "acme" {
+			t.Errorf("Expected %s to match tenant acme, got %v", host, tenant)
+		}
+	}
+	if tenant := directory.Match("api.opgl.gg"); tenant != nil {
+		t.Errorf("Expected no tenant for the gateway's own host, got %s", tenant.ID)
+	}
+	if tenant := directory.Match("stats.acme.gg"); tenant.DefaultRegion != "euw" || !tenant.AllowsOrigin("https://stats.acme.gg") {
+		t.Errorf("Unexpected tenant %+v", tenant)
+	}
+}
+
+// TestValidate_Errors tests that invalid tenants are rejected
+func TestValidate_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tenants []Tenant
+	}{
+		{"missing id", []Tenant{{Hosts: []string{"a.gg"}}}},
+		{"duplicate id", []Tenant{{ID: "a", Hosts: []string{"a.gg"}}, {ID: "a", Hosts: []string{"b.gg"}}}},
+		{"no hosts", []Tenant{{ID: "a"}}},
+		{"host with scheme", []Tenant{{ID: "a", Hosts: []string{"https://a.gg"}}}},
+		{"wildcard host", []Tenant{{ID: "a", Hosts: []string{"*.a.gg"}}}},
+		{"shared host", []Tenant{{ID: "a", Hosts: []string{"a.gg"}}, {ID: "b", Hosts: []string{"A.gg"}}}},
+		{"origin with path", []Tenant{{ID: "a", Hosts: []string{"a.gg"}, AllowedOrigins: []string{"https://a.gg/app"}}}},
+		{"unknown region", []Tenant{{ID: "a", Hosts: []string{"a.gg"}, DefaultRegion: "mars"}}},
+		{"http logo", []Tenant{{ID: "a", Hosts: []string{"a.gg"}, Branding: Branding{LogoURL: "http://a.gg/logo.png"}}}},
+		{"named color", []Tenant{{ID: "a", Hosts: []string{"a.gg"}, Branding: Branding{PrimaryColor: "orange"}}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := Validate(testCase.tenants); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+// TestDirectory_Handler tests the branding response on tenant and non-tenant hosts
+func TestDirectory_Handler(t *testing.T) {
+	directory := newTestDirectory(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/branding", nil)
+	request.Host = "stats.acme.gg"
+	responseRecorder := httptest.NewRecorder()
+	directory.Handler().ServeHTTP(responseRecorder, request)
+
+	var response brandingResponse
+	json.NewDecoder(responseRecorder.Body).Decode(&response)
+	if response.Tenant != "acme" || response.DefaultRegion != "euw" || response.Branding == nil || response.Branding.DisplayName != "Acme Stats" {
+		t.Errorf("Unexpected branding response %+v", response)
+	}
+	if vary := responseRecorder.Header().Get("Vary"); vary != "Host" {
+		t.Errorf("Expected Vary 'Host', got '%s'", vary)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/branding", nil)
+	request.Host = "api.opgl.gg"
+	responseRecorder = httptest.NewRecorder()
+	directory.Handler().ServeHTTP(responseRecorder, request)
+
+	if body := responseRecorder.Body.String(); body != "{\"branding\":null}\n" {
+		t.Errorf("Expected a null branding, got %s", body)
+	}
+}
@@ -0,0 +1,68 @@
+package unixsocket
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Prefix marks an upstream URL as a unix domain socket, e.g. unix:///var/run/opgl/data.sock
+const Prefix = "unix://"
+
+// DialFunc opens a connection, matching http.Transport.DialContext
+type DialFunc func(ctx context.Context, network string, address string) (net.Conn, error)
+
+// Sockets maps placeholder HTTP hosts to unix domain socket paths
+// Requests to a unix:// upstream are addressed to http://<name>.sock instead, and the
+// transport dials the socket whenever it connects to that placeholder host.
+// Register every upstream before the transport is used; lookups are not synchronized.
+type Sockets struct {
+	paths map[string]string
+}
+
+// New creates an empty Sockets
+func New() *Sockets {
+	return &Sockets{paths: make(map[string]string)}
+}
+
+// Register returns the base URL to use for an upstream
+// unix:// URLs are replaced by a placeholder http:// URL routed to the socket;
+// any other URL is returned unchanged.
+func (sockets *Sockets) Register(name string, serviceURL string) string {
+	socketPath, isSocket := strings.CutPrefix(serviceURL, Prefix)
+	if !isSocket {
+		return serviceURL
+	}
+
+	host := name + ".sock"
+	sockets.paths[net.JoinHostPort(host, "80")] = socketPath
+	return "http://" + host
+}
+
+// DialContext wraps dial so connections to registered hosts open their unix socket
+func (sockets *Sockets) DialContext(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		socketPath, found := sockets.paths[address]
+		if !found {
+			return dial(ctx, network, address)
+		}
+
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+}
+
+// Proxy wraps proxy so requests to registered hosts never go through an HTTP proxy
+func (sockets *Sockets) Proxy(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(request *http.Request) (*url.URL, error) {
+		if _, found := sockets.paths[net.JoinHostPort(request.URL.Hostname(), "80")]; found {
+			return nil, nil
+		}
+		if proxy == nil {
+			return nil, nil
+		}
+		return proxy(request)
+	}
+}
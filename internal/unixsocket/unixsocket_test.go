@@ -0,0 +1,75 @@
+package unixsocket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRegister tests that only unix:// URLs are rewritten
+func TestRegister(t *testing.T) {
+	sockets := New()
+
+	if baseURL := sockets.Register("data", "http://localhost:8081"); baseURL != "http://localhost:8081" {
+		t.Errorf("Expected TCP URL to be unchanged, got '%s'", baseURL)
+	}
+
+	if baseURL := sockets.Register("data", "unix:///var/run/opgl/data.sock"); baseURL != "http://data.sock" {
+		t.Errorf("Expected 'http://data.sock', got '%s'", baseURL)
+	}
+
+	if socketPath := sockets.paths["data.sock:80"]; socketPath != "/var/run/opgl/data.sock" {
+		t.Errorf("Expected socket path '/var/run/opgl/data.sock', got '%s'", socketPath)
+	}
+}
+
+// TestTransport_UnixSocket tests an HTTP round trip over a registered socket
+func TestTransport_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "data.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer os.Remove(socketPath)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		io.WriteString(writer, request.URL.Path)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	sockets := New()
+	baseURL := sockets.Register("data", Prefix+socketPath)
+
+	tcpDialed := false
+	transport := &http.Transport{
+		DialContext: sockets.DialContext(func(ctx context.Context, network string, address string) (net.Conn, error) {
+			tcpDialed = true
+			return nil, errors.New("unexpected TCP dial")
+		}),
+		Proxy: sockets.Proxy(func(*http.Request) (*url.URL, error) {
+			return url.Parse("http://proxy.invalid:3128")
+		}),
+	}
+	client := &http.Client{Transport: transport}
+
+	response, err := client.Get(baseURL + "/api/v1/summoner")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != "/api/v1/summoner" {
+		t.Errorf("Expected path '/api/v1/summoner', got '%s'", body)
+	}
+	if tcpDialed {
+		t.Error("Expected the socket to be dialed instead of TCP")
+	}
+}
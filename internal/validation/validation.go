@@ -2,7 +2,12 @@ package validation
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // ValidRegions contains all valid Riot API region codes
@@ -25,6 +30,16 @@ var ValidRegions = map[string]bool{
 	"vn":   true, // Vietnam
 }
 
+// Patterns for Riot ID and PUUID validation
+var (
+	validGameNamePattern = regexp.MustCompile(`^[\p{L}\p{M}\p{N} _]+$`)
+	validTagLinePattern  = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	validPUUIDPattern    = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// MaxMatchCount is the largest match count the Riot API returns per request
+const MaxMatchCount = 100
+
 // ValidationError represents a single validation error
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -142,19 +157,24 @@ func validateGameName(gameName string, result *ValidationResult) {
 		return
 	}
 
-	// Riot game names must be 3-16 characters
-	if len(gameName) < 3 {
+	if containsControlCharacter(gameName) {
+		result.AddError("gameName", "gameName cannot contain control characters")
+		return
+	}
+
+	// Riot game names must be 3-16 characters (counted in characters, not bytes)
+	gameNameLength := utf8.RuneCountInString(gameName)
+	if gameNameLength < 3 {
 		result.AddError("gameName", "gameName must be at least 3 characters")
 		return
 	}
 
-	if len(gameName) > 16 {
+	if gameNameLength > 16 {
 		result.AddError("gameName", "gameName must be at most 16 characters")
 		return
 	}
 
-	// Game names can only contain letters, numbers, spaces, and underscores
-	validGameNamePattern := regexp.MustCompile(`^[a-zA-Z0-9 _]+$`)
+	// Game names can only contain letters (any script), numbers, spaces, and underscores
 	if !validGameNamePattern.MatchString(gameName) {
 		result.AddError("gameName", "gameName can only contain letters, numbers, spaces, and underscores")
 	}
@@ -179,7 +199,6 @@ func validateTagLine(tagLine string, result *ValidationResult) {
 	}
 
 	// Tag lines can only contain alphanumeric characters
-	validTagLinePattern := regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 	if !validTagLinePattern.MatchString(tagLine) {
 		result.AddError("tagLine", "tagLine can only contain letters and numbers")
 	}
@@ -199,7 +218,6 @@ func validatePUUID(puuid string, result *ValidationResult) {
 	}
 
 	// PUUIDs contain alphanumeric characters, hyphens, and underscores
-	validPUUIDPattern := regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 	if !validPUUIDPattern.MatchString(puuid) {
 		result.AddError("puuid", "puuid contains invalid characters")
 	}
@@ -214,9 +232,40 @@ func validateCount(count int, result *ValidationResult) {
 	}
 
 	// Riot API allows max 100 matches per request
-	if count > 100 {
-		result.AddError("count", "count cannot exceed 100")
+	if count > MaxMatchCount {
+		result.AddError("count", "count cannot exceed "+strconv.Itoa(MaxMatchCount))
+	}
+}
+
+// NormalizeRiotID cleans up a game name and tag line before validation
+// Both are NFC-normalized so visually identical names map to the same upstream lookup
+// and cache key, invisible formatting characters (zero-width spaces, joiners, BOMs)
+// are removed, and surrounding whitespace is trimmed. Control characters are kept
+// so validation can reject them.
+func NormalizeRiotID(gameName string, tagLine string) (string, string) {
+	return normalizeRiotIDPart(gameName), normalizeRiotIDPart(tagLine)
+}
+
+// normalizeRiotIDPart applies NormalizeRiotID to a single value
+func normalizeRiotIDPart(value string) string {
+	normalized := norm.NFC.String(value)
+	normalized = strings.Map(func(character rune) rune {
+		if unicode.Is(unicode.Cf, character) {
+			return -1
+		}
+		return character
+	}, normalized)
+	return strings.TrimSpace(normalized)
+}
+
+// containsControlCharacter reports whether value contains a Unicode control character
+func containsControlCharacter(value string) bool {
+	for _, character := range value {
+		if unicode.IsControl(character) {
+			return true
+		}
 	}
+	return false
 }
 
 // NormalizeRegion converts region to lowercase for consistent API calls
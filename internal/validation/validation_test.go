@@ -418,3 +418,82 @@ func TestValidateSummonerRequest_MultipleErrors(t *testing.T) {
 		t.Errorf("Expected 3 errors, got %d: %s", len(result.Errors), result.GetErrorMessages())
 	}
 }
+
+// TestNormalizeRiotID tests Unicode normalization, invisible character removal, and trimming
+func TestNormalizeRiotID(t *testing.T) {
+	testCases := []struct {
+		name             string
+		gameName         string
+		tagLine          string
+		expectedGameName string
+		expectedTagLine  string
+	}{
+		{"trims whitespace", "  TestPlayer ", " NA1 ", "TestPlayer", "NA1"},
+		{"removes zero-width characters", "Test\u200bPlayer\ufeff", "N\u200dA1", "TestPlayer", "NA1"},
+		{"composes decomposed characters", "Zoe\u0301", "EUW", "Zo\u00e9", "EUW"},
+		{"keeps control characters", "Test\x00Player", "NA1", "Test\x00Player", "NA1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gameName, tagLine := NormalizeRiotID(testCase.gameName, testCase.tagLine)
+
+			if gameName != testCase.expectedGameName {
+				t.Errorf("Expected gameName %q, got %q", testCase.expectedGameName, gameName)
+			}
+
+			if tagLine != testCase.expectedTagLine {
+				t.Errorf("Expected tagLine %q, got %q", testCase.expectedTagLine, tagLine)
+			}
+		})
+	}
+}
+
+// TestValidateSummonerRequest_UnicodeGameName tests that non-Latin game names are accepted
+func TestValidateSummonerRequest_UnicodeGameName(t *testing.T) {
+	request := &SummonerRequest{
+		Region:   "kr",
+		GameName: "페이커",
+		TagLine:  "KR1",
+	}
+
+	result := ValidateSummonerRequest(request)
+
+	if !result.IsValid() {
+		t.Errorf("Expected valid request, got errors: %s", result.GetErrorMessages())
+	}
+}
+
+// TestValidateSummonerRequest_GameNameLengthInCharacters tests that length limits count characters, not bytes
+func TestValidateSummonerRequest_GameNameLengthInCharacters(t *testing.T) {
+	request := &SummonerRequest{
+		Region:   "kr",
+		GameName: "가나다라마바사아자차카타파하가나",
+		TagLine:  "KR1",
+	}
+
+	result := ValidateSummonerRequest(request)
+
+	if !result.IsValid() {
+		t.Errorf("Expected 16-character game name to be valid, got errors: %s", result.GetErrorMessages())
+	}
+}
+
+// TestValidateSummonerRequest_ControlCharacters tests that control characters are rejected
+func TestValidateSummonerRequest_ControlCharacters(t *testing.T) {
+	request := &SummonerRequest{
+		Region:   "na",
+		GameName: "Test\nPlayer",
+		TagLine:  "NA1",
+	}
+
+	result := ValidateSummonerRequest(request)
+
+	if result.IsValid() {
+		t.Error("Expected invalid request for control characters")
+	}
+
+	if !strings.Contains(result.GetErrorMessages(), "control characters") {
+		t.Errorf("Expected control character error, got: %s", result.GetErrorMessages())
+	}
+}
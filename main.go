@@ -2,90 +2,586 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/OPGLOL/opgl-gateway-service/internal/announcements"
+	"github.com/OPGLOL/opgl-gateway-service/internal/anomaly"
 	"github.com/OPGLOL/opgl-gateway-service/internal/api"
+	"github.com/OPGLOL/opgl-gateway-service/internal/config"
+	"github.com/OPGLOL/opgl-gateway-service/internal/docs"
+	"github.com/OPGLOL/opgl-gateway-service/internal/egress"
+	"github.com/OPGLOL/opgl-gateway-service/internal/events"
+	"github.com/OPGLOL/opgl-gateway-service/internal/experiments"
+	"github.com/OPGLOL/opgl-gateway-service/internal/filters"
+	"github.com/OPGLOL/opgl-gateway-service/internal/health"
+	"github.com/OPGLOL/opgl-gateway-service/internal/logsink"
+	"github.com/OPGLOL/opgl-gateway-service/internal/metrics"
 	"github.com/OPGLOL/opgl-gateway-service/internal/middleware"
 	"github.com/OPGLOL/opgl-gateway-service/internal/proxy"
+	"github.com/OPGLOL/opgl-gateway-service/internal/routes"
+	"github.com/OPGLOL/opgl-gateway-service/internal/slo"
+	"github.com/OPGLOL/opgl-gateway-service/internal/tenants"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// version is the build version, set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// configFlags maps command-line flags to the environment variables they override
+var configFlags = []struct {
+	name   string
+	envKey string
+	usage  string
+}{
+	{"env", "APP_ENV", "configuration profile: dev, staging or prod"},
+	{"port", "PORT", "server port"},
+	{"log-format", "OPGL_LOG_FORMAT", "log format: console or json"},
+	{"log-level", "OPGL_LOG_LEVEL", "log level: debug, info, warn or error"},
+	{"data-url", "OPGL_DATA_URL", "opgl-data-service URL"},
+	{"cortex-url", "OPGL_CORTEX_URL", "opgl-cortex-engine-service URL"},
+	{"auth-url", "OPGL_AUTH_URL", "opgl-auth-service URL"},
+}
+
 func main() {
-	// Initialize zerolog with colorized console output for development
-	log.Logger = zerolog.New(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
-	}).With().Timestamp().Caller().Logger()
+	// Without a subcommand (or with only flags) the gateway serves, as it always has
+	command := "serve"
+	arguments := os.Args[1:]
+	if len(arguments) > 0 && arguments[0] != "" && arguments[0][0] != '-' {
+		command = arguments[0]
+		arguments = arguments[1:]
+	}
+
+	switch command {
+	case "serve":
+		runServe(arguments)
+	case "check-config":
+		runCheckConfig(arguments)
+	case "version":
+		fmt.Println("opgl-gateway", version)
+	case "help":
+		printUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		printUsage()
+		os.Exit(2)
+	}
+}
 
-	// Set global log level (can be configured via environment variable)
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: opgl-gateway [command] [flags]
 
-	log.Info().Msg("Starting OPGL Gateway")
+Commands:
+  serve          Start the HTTP server (default)
+  check-config   Validate configuration and print the resolved settings
+  version        Print the build version
+  help           Show this message
 
-	// Get configuration from environment variables
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+Run "opgl-gateway <command> -h" to list the flags of a command.`)
+}
+
+// loadConfig parses the configuration flags of a subcommand and loads the configuration
+// Flags that were explicitly set override the matching environment variables.
+func loadConfig(command string, arguments []string) (*config.Config, error) {
+	flagSet := flag.NewFlagSet(command, flag.ExitOnError)
+	flagValues := make(map[string]*string, len(configFlags))
+	envKeys := make(map[string]string, len(configFlags))
+	for _, configFlag := range configFlags {
+		flagValues[configFlag.name] = flagSet.String(configFlag.name, "", configFlag.usage+" (overrides "+configFlag.envKey+")")
+		envKeys[configFlag.name] = configFlag.envKey
+	}
+	flagSet.Parse(arguments)
+
+	var overrideErr error
+	flagSet.Visit(func(setFlag *flag.Flag) {
+		if err := os.Setenv(envKeys[setFlag.Name], *flagValues[setFlag.Name]); err != nil && overrideErr == nil {
+			overrideErr = err
+		}
+	})
+	if overrideErr != nil {
+		return nil, overrideErr
 	}
 
-	dataServiceURL := os.Getenv("OPGL_DATA_URL")
-	if dataServiceURL == "" {
-		dataServiceURL = "http://localhost:8081"
+	return config.Load()
+}
+
+// upstreamTLSConfig loads the mTLS client certificate and extra CA for https upstreams
+// It returns nil when neither is configured, keeping Go's default TLS settings.
+func upstreamTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.UpstreamTLSCert == "" && cfg.UpstreamTLSCA == "" {
+		return nil, nil
 	}
 
-	cortexServiceURL := os.Getenv("OPGL_CORTEX_URL")
-	if cortexServiceURL == "" {
-		cortexServiceURL = "http://localhost:8082"
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.UpstreamTLSCert != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.UpstreamTLSCert, cfg.UpstreamTLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading upstream client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
 	}
+	if cfg.UpstreamTLSCA != "" {
+		caPEM, err := os.ReadFile(cfg.UpstreamTLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading upstream CA: %w", err)
+		}
+		// Keep the system roots so external https upstreams still verify
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("upstream CA %s contains no PEM certificates", cfg.UpstreamTLSCA)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
 
-	authServiceURL := os.Getenv("OPGL_AUTH_URL")
-	if authServiceURL == "" {
-		authServiceURL = "http://localhost:8083"
+// runCheckConfig validates the configuration without starting the server
+func runCheckConfig(arguments []string) {
+	cfg, err := loadConfig("check-config", arguments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
 	}
 
+	fmt.Printf("profile:              %s\n", cfg.Profile)
+	fmt.Printf("port:                 %s\n", cfg.Port)
+	fmt.Printf("log:                  %s (%s)\n", cfg.LogFormat, cfg.LogLevel)
+	fmt.Printf("data service:         %s\n", cfg.DataServiceURL)
+	fmt.Printf("cortex service:       %s\n", cfg.CortexServiceURL)
+	fmt.Printf("auth service:         %s\n", cfg.AuthServiceURL)
+	fmt.Printf("upstream paths:       %s %s %s\n", cfg.DataSummonerPath, cfg.DataMatchesPath, cfg.CortexAnalyzePath)
+	fmt.Printf("cors allowed origins: %v\n", cfg.CORSAllowedOrigins)
+	if _, err := upstreamTLSConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("upstream auth:        %s (mTLS %t)\n", cfg.UpstreamAuthMode, cfg.UpstreamTLSCert != "")
+	fmt.Printf("egress allow/deny:    %v / %v\n", cfg.EgressAllow, cfg.EgressDeny)
+	if cfg.MirrorCortexURL != "" {
+		fmt.Printf("mirror:               %s%% of analyses to %s\n", strconv.FormatFloat(cfg.MirrorPercent, 'f', -1, 64), cfg.MirrorCortexURL)
+	}
+	if cfg.RoutesFile != "" {
+		routeFile, err := routes.LoadFile(cfg.RoutesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := filters.Load(routeFile.Filters); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("route file:           %s (%d routes, %d middleware groups, %d filters, %d experiments, %d announcements, %d tenants)\n", cfg.RoutesFile, len(routeFile.Routes), len(routeFile.Groups), len(routeFile.Filters), len(routeFile.Experiments), len(routeFile.Announcements), len(routeFile.Tenants))
+	}
+	fmt.Printf("ip rate limit:        %d per %s (%d trusted proxies)\n", cfg.IPRateLimit, cfg.IPRateLimitWindow, len(cfg.TrustedProxies))
+	fmt.Printf("log sinks:            loki=%q syslog=%q s3=%q\n", cfg.LogSinkLokiURL, cfg.LogSinkSyslogAddress, cfg.LogSinkS3Bucket)
+	fmt.Printf("key concurrency:      %d\n", cfg.KeyMaxConcurrency)
+	fmt.Printf("rate limit failures:  fail %s (last answer reused for %s)\n", cfg.RateLimitFailurePolicy, cfg.RateLimitFallbackTTL)
+	fmt.Printf("ready requires:       %v (checked every %s)\n", cfg.ReadyRequiredUpstreams, cfg.HealthCheckInterval)
+	fmt.Printf("cookie sessions:      %t\n", cfg.CookieSessions)
+	fmt.Printf("api docs:             %t\n", cfg.DocsEnabled)
+	fmt.Printf("lookup timeout:       %s\n", cfg.LookupTimeout)
+	fmt.Printf("analyze timeout:      %s\n", cfg.AnalyzeTimeout)
+	fmt.Printf("rate limit costs:     lookup %d, analyze %d\n", cfg.LookupRateLimitCost, cfg.AnalyzeRateLimitCost)
+	fmt.Println("Configuration OK")
+}
+
+// runServe starts the HTTP server and blocks until it receives a shutdown signal
+func runServe(arguments []string) {
+	// Load configuration from environment variables (APP_ENV selects the profile)
+	cfg, err := loadConfig("serve", arguments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize zerolog: colorized console output for development, JSON elsewhere
+	var logOutput io.Writer = os.Stdout
+	if cfg.LogFormat == config.LogFormatConsole {
+		logOutput = zerolog.ConsoleWriter{
+			Out:        os.Stdout,
+			TimeFormat: time.RFC3339,
+		}
+	}
+	log.Logger = zerolog.New(logOutput).With().Timestamp().Caller().Logger()
+	zerolog.SetGlobalLevel(cfg.LogLevel)
+
 	log.Info().
-		Str("port", port).
-		Str("data_service_url", dataServiceURL).
-		Str("cortex_service_url", cortexServiceURL).
-		Str("auth_service_url", authServiceURL).
+		Str("profile", string(cfg.Profile)).
+		Str("version", version).
+		Msg("Starting OPGL Gateway")
+
+	log.Info().
+		Str("port", cfg.Port).
+		Str("data_service_url", cfg.DataServiceURL).
+		Str("cortex_service_url", cfg.CortexServiceURL).
+		Str("auth_service_url", cfg.AuthServiceURL).
+		Strs("cors_allowed_origins", cfg.CORSAllowedOrigins).
+		Dur("lookup_timeout", cfg.LookupTimeout).
+		Dur("analyze_timeout", cfg.AnalyzeTimeout).
+		Int("lookup_rate_limit_cost", cfg.LookupRateLimitCost).
+		Int("analyze_rate_limit_cost", cfg.AnalyzeRateLimitCost).
+		Dur("upstream_dns_cache_ttl", cfg.UpstreamDNSCacheTTL).
+		Int("ip_rate_limit", cfg.IPRateLimit).
+		Int("key_max_concurrency", cfg.KeyMaxConcurrency).
+		Strs("ready_required_upstreams", cfg.ReadyRequiredUpstreams).
+		Dur("health_check_interval", cfg.HealthCheckInterval).
+		Str("upstream_auth_mode", cfg.UpstreamAuthMode).
+		Bool("upstream_mtls", cfg.UpstreamTLSCert != "").
+		Dur("idempotency_ttl", cfg.IdempotencyTTL).
+		Dur("signature_max_skew", cfg.SignatureMaxSkew).
+		Bool("cookie_sessions", cfg.CookieSessions).
+		Bool("docs_enabled", cfg.DocsEnabled).
 		Msg("Configuration loaded")
 
+	// Refuse outbound connections to metadata endpoints and anything outside the egress lists
+	egressGuard, err := egress.NewGuard(cfg.EgressAllow, cfg.EgressDeny)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid egress settings")
+	}
+
+	// Copy the JSON log to every configured external sink
+	var logSinks []logsink.Sink
+	if cfg.LogSinkLokiURL != "" {
+		logSinks = append(logSinks, logsink.NewLokiSink(cfg.LogSinkLokiURL, map[string]string{"env": string(cfg.Profile)}, egressGuard.Transport()))
+	}
+	if cfg.LogSinkSyslogAddress != "" {
+		syslogSink, err := logsink.NewSyslogSink(cfg.LogSinkSyslogAddress, egressGuard.Dialer())
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid syslog sink")
+		}
+		logSinks = append(logSinks, syslogSink)
+	}
+	if cfg.LogSinkS3Bucket != "" {
+		logSinks = append(logSinks, logsink.NewS3Sink(logsink.S3Config{
+			Bucket:   cfg.LogSinkS3Bucket,
+			Region:   cfg.LogSinkS3Region,
+			Prefix:   cfg.LogSinkS3Prefix,
+			Endpoint: cfg.LogSinkS3Endpoint,
+		}, logsink.S3Credentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}, egressGuard.Transport()))
+	}
+	var logExporter *logsink.Exporter
+	if len(logSinks) > 0 {
+		logExporter = logsink.NewExporter(logsink.Config{BatchSize: cfg.LogSinkBatchSize, FlushInterval: cfg.LogSinkFlushInterval}, logSinks...)
+		log.Logger = zerolog.New(zerolog.MultiLevelWriter(logOutput, logExporter)).With().Timestamp().Caller().Logger()
+
+		sinkNames := make([]string, len(logSinks))
+		for i, sink := range logSinks {
+			sinkNames[i] = sink.Name()
+		}
+		log.Info().
+			Strs("sinks", sinkNames).
+			Int("batch_size", cfg.LogSinkBatchSize).
+			Dur("flush_interval", cfg.LogSinkFlushInterval).
+			Msg("Exporting logs to external sinks")
+	}
+
+	// Initialize event bus with every configured publisher
+	var eventPublishers []events.Publisher
+	if cfg.NATSURL != "" {
+		natsPublisher, err := events.NewNATSPublisher(cfg.NATSURL, cfg.EventsSubjectPrefix)
+		if err != nil {
+			log.Fatal().Err(err).Str("nats_url", cfg.NATSURL).Msg("Failed to connect to NATS")
+		}
+		eventPublishers = append(eventPublishers, natsPublisher)
+		log.Info().
+			Str("nats_url", cfg.NATSURL).
+			Str("subject_prefix", cfg.EventsSubjectPrefix).
+			Msg("Event publishing enabled via NATS")
+	}
+	if cfg.EventsWebhookURL != "" {
+		eventPublishers = append(eventPublishers, events.NewWebhookPublisher(cfg.EventsWebhookURL, cfg.EventsWebhookSecrets, egressGuard.Transport()))
+		log.Info().
+			Str("webhook_url", cfg.EventsWebhookURL).
+			Int("active_secrets", len(cfg.EventsWebhookSecrets)).
+			Msg("Event delivery enabled via signed webhook")
+	}
+	if len(eventPublishers) == 0 {
+		eventPublishers = append(eventPublishers, events.NewLogPublisher())
+	}
+	eventBus := events.NewBus(1024, eventPublishers...)
+
+	// Initialize metrics registry shared by the proxy and SLO tracking
+	metricsRegistry := metrics.NewRegistry()
+
+	// Load the mTLS identity presented to upstreams
+	tlsClientConfig, err := upstreamTLSConfig(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load upstream TLS settings")
+	}
+
 	// Initialize service proxy
-	serviceProxy := proxy.NewServiceProxy(dataServiceURL, cortexServiceURL)
+	serviceProxy := proxy.NewServiceProxyWithConfig(proxy.Config{
+		DataServiceURL:   cfg.DataServiceURL,
+		CortexServiceURL: cfg.CortexServiceURL,
+		Paths: proxy.Paths{
+			Summoner: cfg.DataSummonerPath,
+			Matches:  cfg.DataMatchesPath,
+			Analyze:  cfg.CortexAnalyzePath,
+		},
+		GzipMinBytes:     cfg.UpstreamGzipMinBytes,
+		MaxResponseBytes: cfg.UpstreamMaxResponseBytes,
+		DNSCacheTTL:      cfg.UpstreamDNSCacheTTL,
+		EgressGuard:      egressGuard,
+		MetricsRegistry:  metricsRegistry,
+		Auth: proxy.UpstreamAuth{
+			Mode:      cfg.UpstreamAuthMode,
+			Token:     cfg.UpstreamAuthToken,
+			JWTSecret: cfg.UpstreamJWTSecret,
+			JWTTTL:    cfg.UpstreamJWTTTL,
+		},
+		TLSClientConfig: tlsClientConfig,
+		Mirror: proxy.MirrorConfig{
+			CortexServiceURL: cfg.MirrorCortexURL,
+			Percent:          cfg.MirrorPercent,
+			EventBus:         eventBus,
+		},
+	})
+	if cfg.MirrorCortexURL != "" && cfg.MirrorPercent > 0 {
+		log.Info().
+			Str("mirror_cortex_url", cfg.MirrorCortexURL).
+			Float64("mirror_percent", cfg.MirrorPercent).
+			Msg("Mirroring analysis requests to shadow cortex")
+	}
+
+	// Initialize upstream health checker for /health and /readyz
+	requiredUpstreams := make(map[string]bool, len(cfg.ReadyRequiredUpstreams))
+	for _, name := range cfg.ReadyRequiredUpstreams {
+		requiredUpstreams[name] = true
+	}
+	healthChecker := health.NewChecker(
+		health.Upstream{Name: "data", URL: cfg.DataServiceURL, Required: requiredUpstreams["data"]},
+		health.Upstream{Name: "cortex", URL: cfg.CortexServiceURL, Required: requiredUpstreams["cortex"]},
+		health.Upstream{Name: "auth", URL: cfg.AuthServiceURL, Required: requiredUpstreams["auth"]},
+	)
+
+	// Probe upstreams in the background so X-OPGL-Degraded and the degraded/recovered events
+	// follow outages even when nobody is polling /health
+	watchContext, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go healthChecker.Watch(watchContext, cfg.HealthCheckInterval, func(report *health.Report) {
+		downUpstreams := []string{}
+		for name, upstream := range report.Upstreams {
+			if upstream.Status != health.UpstreamUp {
+				downUpstreams = append(downUpstreams, name)
+			}
+		}
+		sort.Strings(downUpstreams)
+
+		if report.Status == health.StatusDegraded {
+			log.Warn().Strs("upstreams_down", downUpstreams).Msg("Gateway degraded")
+			eventBus.Publish(events.TypeGatewayDegraded, map[string]interface{}{"upstreamsDown": downUpstreams})
+			return
+		}
+		log.Info().Msg("Gateway recovered")
+		eventBus.Publish(events.TypeGatewayRecovered, map[string]interface{}{})
+	})
 
 	// Initialize HTTP handler
-	handler := api.NewHandler(serviceProxy)
+	handler := api.NewHandler(serviceProxy, eventBus, healthChecker, api.MatchCountLimit{
+		Max:   cfg.MatchCountMax,
+		Clamp: cfg.MatchCountClamp,
+	})
 
 	// Initialize rate limit client for auth service
-	rateLimitClient := middleware.NewRateLimitServiceClient(authServiceURL)
+	rateLimitClient := middleware.NewRateLimitServiceClientWithConfig(middleware.RateLimitClientConfig{
+		BaseURL:         cfg.AuthServiceURL,
+		FailurePolicy:   cfg.RateLimitFailurePolicy,
+		FallbackTTL:     cfg.RateLimitFallbackTTL,
+		MetricsRegistry: metricsRegistry,
+		Transport:       egressGuard.Transport(),
+	})
 	log.Info().
-		Str("auth_service_url", authServiceURL).
+		Str("auth_service_url", cfg.AuthServiceURL).
+		Str("failure_policy", cfg.RateLimitFailurePolicy).
+		Dur("fallback_ttl", cfg.RateLimitFallbackTTL).
 		Msg("Rate limiting enabled via auth service")
 
+	// Initialize SLO tracking (lookups and analysis have separate latency targets)
+	sloTracker := slo.NewTracker(metricsRegistry,
+		slo.Definition{Name: "availability", Objective: cfg.SLOAvailabilityObjective},
+		slo.Definition{
+			Name:             "lookup-latency",
+			Objective:        cfg.SLOLatencyObjective,
+			LatencyThreshold: cfg.SLOLookupLatency,
+			Routes:           []string{"/api/v1/summoner", "/api/v1/matches"},
+		},
+		slo.Definition{
+			Name:             "analyze-latency",
+			Objective:        cfg.SLOLatencyObjective,
+			LatencyThreshold: cfg.SLOAnalyzeLatency,
+			Routes:           []string{"/api/v1/analyze"},
+		},
+	)
+
+	// Load additional proxied routes and middleware groups from the route file
+	routeFile := &routes.File{}
+	if cfg.RoutesFile != "" {
+		routeFile, err = routes.LoadFile(cfg.RoutesFile)
+		if err != nil {
+			log.Fatal().Err(err).Str("routes_file", cfg.RoutesFile).Msg("Failed to load route file")
+		}
+		log.Info().
+			Str("routes_file", cfg.RoutesFile).
+			Int("routes", len(routeFile.Routes)).
+			Int("middleware_groups", len(routeFile.Groups)).
+			Int("filters", len(routeFile.Filters)).
+			Int("experiments", len(routeFile.Experiments)).
+			Int("announcements", len(routeFile.Announcements)).
+			Int("tenants", len(routeFile.Tenants)).
+			Msg("Route file loaded")
+	}
+
+	// Build the route file's filters (compiled-in types and Go plugins)
+	routeFilters, err := filters.Load(routeFile.Filters)
+	if err != nil {
+		log.Fatal().Err(err).Str("routes_file", cfg.RoutesFile).Msg("Failed to load filters")
+	}
+
+	// Assign API key holders to the route file's A/B experiments
+	var experimentAssigner *experiments.Assigner
+	if len(routeFile.Experiments) > 0 {
+		experimentAssigner = experiments.NewAssigner(routeFile.Experiments)
+	}
+
+	// Limit anonymous callers of public routes per client IP
+	var ipRateLimiter *middleware.IPRateLimiter
+	if cfg.IPRateLimit > 0 {
+		ipRateLimiter = middleware.NewIPRateLimiter(cfg.IPRateLimit, cfg.IPRateLimitWindow, cfg.TrustedProxies)
+	}
+
+	// Broadcast the route file's announcements
+	var announcementBoard *announcements.Board
+	if len(routeFile.Announcements) > 0 {
+		announcementBoard = announcements.NewBoard(routeFile.Announcements)
+	}
+
+	// Resolve the route file's tenants from the request host
+	var tenantDirectory *tenants.Directory
+	if len(routeFile.Tenants) > 0 {
+		tenantDirectory = tenants.NewDirectory(routeFile.Tenants)
+	}
+
+	// Slow or challenge likely scrapers on public routes
+	var botChallenge middleware.ChallengeVerifier
+	if cfg.BotChallengeVerifyURL != "" {
+		botChallenge = middleware.NewSiteverifyChallenge(cfg.BotChallengeVerifyURL, cfg.BotChallengeSecret, egressGuard.Transport())
+	}
+	botDetector := middleware.NewBotDetector(cfg.BotBlockedUserAgents, cfg.BotTarpit, botChallenge)
+
+	// Watch per-key traffic for anomalies (optionally suspending keys automatically)
+	var anomalyDetector *anomaly.Detector
+	if cfg.AnomalyWindow > 0 {
+		anomalyDetector = anomaly.NewDetector(anomaly.Config{
+			Window:          cfg.AnomalyWindow,
+			SuspendSeverity: cfg.AnomalySuspendSeverity,
+			SuspendDuration: cfg.AnomalySuspendDuration,
+		}, eventBus)
+		log.Info().
+			Dur("window", cfg.AnomalyWindow).
+			Int("suspend_severity", cfg.AnomalySuspendSeverity).
+			Dur("suspend_duration", cfg.AnomalySuspendDuration).
+			Msg("Anomaly detection enabled")
+	}
+
+	// Document the built-in and declared routes for the /docs API explorer
+	var apiDocs *docs.Docs
+	if cfg.DocsEnabled {
+		apiDocs, err = docs.New(version, routeFile.Routes)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate API docs")
+		}
+	}
+
+	// Access tokens are validated by the auth service, for the auth chain middleware and cookie sessions
+	authClient := middleware.NewAuthServiceClient(cfg.AuthServiceURL, egressGuard.Transport())
+
+	// Cookie session mode keeps browser tokens in HttpOnly cookies set by the gateway
+	var sessionHandler *middleware.SessionHandler
+	if cfg.CookieSessions {
+		sessionHandler = middleware.NewSessionHandler(authClient)
+	}
+
 	// Set up router with all handlers
+	cacheWarmer := middleware.NewCacheWarmer()
 	routerConfig := &api.RouterConfig{
-		Handler:         handler,
-		RateLimitClient: rateLimitClient,
+		Handler:              handler,
+		RateLimitClient:      rateLimitClient,
+		EventBus:             eventBus,
+		LookupTimeout:        cfg.LookupTimeout,
+		AnalyzeTimeout:       cfg.AnalyzeTimeout,
+		LookupRateLimitCost:  cfg.LookupRateLimitCost,
+		AnalyzeRateLimitCost: cfg.AnalyzeRateLimitCost,
+		SignatureVerifier:    middleware.NewSignatureVerifier(cfg.SignatureRequiredKeyHashes, cfg.SignatureMaxSkew, middleware.NewMemoryNonceStore()),
+		IdempotencyStore:     middleware.NewIdempotencyStore(cfg.IdempotencyTTL),
+		MetricsRegistry:      metricsRegistry,
+		Docs:                 apiDocs,
+		SLOTracker:           sloTracker,
+		Routes:               routeFile.Routes,
+		RouteForwarder:       serviceProxy,
+		MiddlewareChains:     routeFile.Chains(),
+		Filters:              routeFilters,
+		Experiments:          experimentAssigner,
+		BotDetector:          botDetector,
+		AnomalyDetector:      anomalyDetector,
+		CookieSessions:       cfg.CookieSessions,
+		SessionHandler:       sessionHandler,
+		AuthClient:           authClient,
+		IPRateLimiter:        ipRateLimiter,
+		TrustedProxies:       cfg.TrustedProxies,
+		ConcurrencyLimiter:   middleware.NewConcurrencyLimiter(cfg.KeyMaxConcurrency),
+		Announcements:        announcementBoard,
+		Tenants:              tenantDirectory,
+		CacheWarmer:          cacheWarmer,
 	}
 	router := api.SetupRouter(routerConfig)
 
+	// Fill declared routes' caches in the background and keep them filled until shutdown
+	warmContext, stopWarming := context.WithCancel(context.Background())
+	defer stopWarming()
+	cacheWarmer.Run(warmContext)
+
 	// Wrap router with CORS middleware first to handle preflight requests
-	corsRouter := middleware.CORSMiddleware(router)
+	corsRouter := middleware.CORSMiddleware(cfg.CORSAllowedOrigins)(router)
+
+	// Resolve the tenant before CORS so its allowed origins apply
+	tenantRouter := corsRouter
+	if tenantDirectory != nil {
+		tenantRouter = middleware.TenantMiddleware(tenantDirectory)(corsRouter)
+	}
 
 	// Wrap with logging middleware
-	loggedRouter := middleware.LoggingMiddleware(corsRouter)
+	loggedRouter := middleware.LoggingMiddleware(tenantRouter)
+
+	// Read the X-OPGL-Client tag before logging so log lines and metrics carry it
+	taggedRouter := middleware.ClientTagMiddleware(middleware.NewClientTagMetrics(metricsRegistry))(loggedRouter)
+
+	// Assign request IDs outermost so logs and error bodies share the same ID
+	requestIDRouter := middleware.RequestIDMiddleware(taggedRouter)
 
 	// Create HTTP server
-	serverAddress := fmt.Sprintf(":%s", port)
+	serverAddress := fmt.Sprintf(":%s", cfg.Port)
 	server := &http.Server{
 		Addr:    serverAddress,
-		Handler: loggedRouter,
+		Handler: requestIDRouter,
 	}
 
 	// Channel to listen for shutdown signals
@@ -96,7 +592,7 @@ func main() {
 	go func() {
 		log.Info().
 			Str("address", serverAddress).
-			Str("port", port).
+			Str("port", cfg.Port).
 			Msg("OPGL Gateway listening")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -112,10 +608,32 @@ func main() {
 	shutdownContext, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelShutdown()
 
-	// Gracefully shutdown HTTP server
+	// Stop refreshing caches, then gracefully shutdown HTTP server
+	stopWarming()
+	stopWatching()
 	if err := server.Shutdown(shutdownContext); err != nil {
 		log.Error().Err(err).Msg("Server shutdown error")
 	}
 
+	// Let shadow analyses finish so their diff events are published below
+	if err := serviceProxy.WaitForMirrors(shutdownContext); err != nil {
+		log.Warn().Err(err).Msg("Shadow requests were still in flight at shutdown")
+	}
+
+	// Flush queued events before exiting
+	if err := eventBus.Close(); err != nil {
+		log.Error().Err(err).Msg("Event bus shutdown error")
+	}
+
 	log.Info().Msg("Server stopped")
+
+	// Send the last log lines, including the ones above, before exiting
+	if logExporter != nil {
+		if err := logExporter.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Log export shutdown error: %v\n", err)
+		}
+		if dropped := logExporter.Dropped(); dropped > 0 {
+			fmt.Fprintf(os.Stderr, "Log export dropped %d lines because the sinks fell behind\n", dropped)
+		}
+	}
 }
@@ -0,0 +1,241 @@
+// Package client is a typed Go client for the OPGL gateway API
+// It wraps the summoner, matches, analyze and health endpoints, authenticates with an
+// API key or bearer token and retries throttled and unavailable responses, waiting as
+// long as the gateway's Retry-After header asks.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+	"github.com/google/uuid"
+)
+
+// Defaults applied by New for unset Config fields
+const (
+	defaultMaxRetries     = 2
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxRetryWait   = 30 * time.Second
+	// maxErrorBodyBytes bounds how much of an error response is read
+	maxErrorBodyBytes = 1 << 20
+)
+
+// Config holds client settings
+type Config struct {
+	// BaseURL is the gateway address, e.g. https://api.opgl.gg
+	BaseURL string
+	// APIKey is sent as X-API-Key when set
+	APIKey string
+	// SignRequests signs every request with APIKey (X-OPGL-Signature, X-OPGL-Timestamp and X-OPGL-Nonce),
+	// as the gateway requires for keys listed in OPGL_SIGNATURE_REQUIRED_KEYS
+	SignRequests bool
+	// BearerToken is sent as Authorization: Bearer when set (an opgl-auth-service access token)
+	BearerToken string
+	// UserAgent overrides the default User-Agent header
+	UserAgent string
+	// HTTPClient sends the requests (nil means a client with a 60s timeout)
+	HTTPClient *http.Client
+	// MaxRetries is the number of retries after the first attempt (0 means 2, negative disables)
+	MaxRetries int
+	// InitialBackoff is the first retry delay when the gateway sends no Retry-After (doubled per retry)
+	InitialBackoff time.Duration
+	// MaxRetryWait caps any single wait, including Retry-After; a longer Retry-After fails the call instead
+	MaxRetryWait time.Duration
+}
+
+// Client calls the gateway API
+// A Client is safe for concurrent use.
+type Client struct {
+	baseURL        string
+	apiKey         string
+	signRequests   bool
+	bearerToken    string
+	userAgent      string
+	httpClient     *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxRetryWait   time.Duration
+}
+
+// New creates a Client from config
+func New(config Config) (*Client, error) {
+	if config.BaseURL == "" {
+		return nil, errors.New("client: BaseURL is required")
+	}
+	if config.SignRequests && config.APIKey == "" {
+		return nil, errors.New("client: SignRequests requires an APIKey")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = "opgl-gateway-go-client"
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultInitialBackoff
+	}
+	if config.MaxRetryWait <= 0 {
+		config.MaxRetryWait = defaultMaxRetryWait
+	}
+
+	return &Client{
+		baseURL:        strings.TrimRight(config.BaseURL, "/"),
+		apiKey:         config.APIKey,
+		signRequests:   config.SignRequests,
+		bearerToken:    config.BearerToken,
+		userAgent:      config.UserAgent,
+		httpClient:     config.HTTPClient,
+		maxRetries:     config.MaxRetries,
+		initialBackoff: config.InitialBackoff,
+		maxRetryWait:   config.MaxRetryWait,
+	}, nil
+}
+
+// Health returns the gateway's health report, including upstream reachability when the gateway reports it
+func (client *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := client.post(ctx, "/health", struct{}{}, "", &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// GetSummoner looks up a player by Riot ID
+func (client *Client) GetSummoner(ctx context.Context, request SummonerRequest) (*Summoner, error) {
+	var summoner Summoner
+	if err := client.post(ctx, "/api/v1/summoner", request, "", &summoner); err != nil {
+		return nil, err
+	}
+	return &summoner, nil
+}
+
+// GetMatches returns a player's most recent matches, newest first
+// The gateway returns a single page of up to request.Count matches; there is no cursor.
+func (client *Client) GetMatches(ctx context.Context, request MatchesRequest) ([]Match, error) {
+	var matches []Match
+	if err := client.post(ctx, "/api/v1/matches", request, "", &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// Analyze runs a player analysis
+// Every attempt carries the same Idempotency-Key, so a retry after a lost response
+// replays the stored result instead of starting a second analysis.
+func (client *Client) Analyze(ctx context.Context, request AnalyzeRequest) (*Analysis, error) {
+	var analysis Analysis
+	if err := client.post(ctx, "/api/v1/analyze", request, uuid.NewString(), &analysis); err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+// post sends body to path, retrying when allowed, and decodes a 2xx response into result
+func (client *Client) post(ctx context.Context, path string, body interface{}, idempotencyKey string, result interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("client: encode request: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := client.send(ctx, path, jsonData, idempotencyKey)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if attempt >= client.maxRetries {
+				return fmt.Errorf("client: POST %s: %w", path, err)
+			}
+			if waitErr := sleep(ctx, client.backoff(attempt)); waitErr != nil {
+				return waitErr
+			}
+			continue
+		}
+
+		if response.StatusCode >= 200 && response.StatusCode < 300 {
+			defer response.Body.Close()
+			if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+				return fmt.Errorf("client: decode %s response: %w", path, err)
+			}
+			return nil
+		}
+
+		apiError := readError(response)
+		wait, retry := client.retryWait(apiError, attempt)
+		if !retry {
+			return apiError
+		}
+		if waitErr := sleep(ctx, wait); waitErr != nil {
+			return waitErr
+		}
+	}
+}
+
+// send makes a single attempt
+func (client *Client) send(ctx context.Context, path string, jsonData []byte, idempotencyKey string) (*http.Response, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseURL+path, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Accept", "application/json")
+	httpRequest.Header.Set("User-Agent", client.userAgent)
+	if client.apiKey != "" {
+		httpRequest.Header.Set("X-API-Key", client.apiKey)
+	}
+	if client.bearerToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+client.bearerToken)
+	}
+	if idempotencyKey != "" {
+		httpRequest.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	// Each attempt gets its own nonce, since the gateway accepts a nonce only once
+	if client.signRequests {
+		timestamp := time.Now().Unix()
+		nonce := uuid.New().String()
+		message := signing.RequestMessage(httpRequest.Method, httpRequest.URL.RequestURI(), nonce, jsonData)
+		httpRequest.Header.Set("X-OPGL-Signature", signing.Sign(client.apiKey, timestamp, message))
+		httpRequest.Header.Set("X-OPGL-Timestamp", strconv.FormatInt(timestamp, 10))
+		httpRequest.Header.Set("X-OPGL-Nonce", nonce)
+	}
+
+	return client.httpClient.Do(httpRequest)
+}
+
+// readError builds an Error from a non-2xx response and closes its body
+func readError(response *http.Response) *Error {
+	defer response.Body.Close()
+
+	apiError := &Error{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+	var envelope errorResponse
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error.Code != "" {
+		apiError.Code = envelope.Error.Code
+		apiError.Message = envelope.Error.Message
+		apiError.Details = envelope.Error.Details
+		if envelope.Error.RequestID != "" {
+			apiError.RequestID = envelope.Error.RequestID
+		}
+	}
+	return apiError
+}
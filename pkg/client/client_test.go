@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OPGLOL/opgl-gateway-service/internal/signing"
+)
+
+// newTestClient creates a client for server with fast retries
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	client, err := New(Config{
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		InitialBackoff: time.Millisecond,
+		MaxRetryWait:   2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+// TestGetSummoner tests the request the client sends and the decoded response
+func TestGetSummoner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var summonerRequest SummonerRequest
+		json.NewDecoder(request.Body).Decode(&summonerRequest)
+		if request.URL.Path != "/api/v1/summoner" || request.Header.Get("X-API-Key") != "test-key" || summonerRequest.GameName != "Newyenn" {
+			t.Errorf("Unexpected request %s %s %+v", request.URL.Path, request.Header.Get("X-API-Key"), summonerRequest)
+		}
+		writer.Write([]byte(`{"id":"s1","name":"Newyenn","summonerLevel":420}`))
+	}))
+	defer server.Close()
+
+	summoner, err := newTestClient(t, server).GetSummoner(context.Background(), SummonerRequest{Region: "na", GameName: "Newyenn", TagLine: "GGEZ"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if summoner.ID != "s1" || summoner.SummonerLevel != 420 {
+		t.Errorf("Expected summoner s1 at level 420, got %+v", summoner)
+	}
+}
+
+// TestSignRequests tests that signed requests verify against the gateway's canonical message
+func TestSignRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		timestamp, _ := strconv.ParseInt(request.Header.Get("X-OPGL-Timestamp"), 10, 64)
+		message := signing.RequestMessage(request.Method, request.URL.RequestURI(), request.Header.Get("X-OPGL-Nonce"), body)
+		if !signing.Verify("test-key", timestamp, message, request.Header.Get("X-OPGL-Signature")) {
+			t.Error("Expected the request signature to verify")
+		}
+		writer.Write([]byte(`{"id":"s1"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, APIKey: "test-key", SignRequests: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := client.GetSummoner(context.Background(), SummonerRequest{Region: "na", GameName: "Newyenn", TagLine: "GGEZ"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := New(Config{BaseURL: server.URL, SignRequests: true}); err == nil {
+		t.Error("Expected an error for SignRequests without an APIKey")
+	}
+}
+
+// TestErrorResponse tests that gateway errors are returned as *Error without retrying
+func TestErrorResponse(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(`{"error":{"code":"PLAYER_NOT_FOUND","message":"Player not found: Newyenn#GGEZ","requestId":"req-1"}}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).GetSummoner(context.Background(), SummonerRequest{Region: "na", GameName: "Newyenn", TagLine: "GGEZ"})
+	var clientError *Error
+	if !errors.As(err, &clientError) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if clientError.StatusCode != http.StatusNotFound || clientError.Code != "PLAYER_NOT_FOUND" || clientError.RequestID != "req-1" {
+		t.Errorf("Unexpected error %+v", clientError)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt for a 404, got %d", attempts)
+	}
+}
+
+// TestRetry_RetryAfter tests that 429s are retried after Retry-After with the same Idempotency-Key
+func TestRetry_RetryAfter(t *testing.T) {
+	var attemptTimes []time.Time
+	var idempotencyKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attemptTimes = append(attemptTimes, time.Now())
+		idempotencyKeys = append(idempotencyKeys, request.Header.Get("Idempotency-Key"))
+		if len(attemptTimes) == 1 {
+			writer.Header().Set("Retry-After", "1")
+			writer.WriteHeader(http.StatusTooManyRequests)
+			writer.Write([]byte(`{"error":{"code":"RATE_LIMIT_EXCEEDED","message":"slow down"}}`))
+			return
+		}
+		writer.Write([]byte(`{"playerStats":{"kda":3.2},"improvementAreas":[]}`))
+	}))
+	defer server.Close()
+
+	analysis, err := newTestClient(t, server).Analyze(context.Background(), AnalyzeRequest{Region: "na", GameName: "Newyenn", TagLine: "GGEZ"})
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if string(analysis.PlayerStats) != `{"kda":3.2}` {
+		t.Errorf("Expected raw player stats, got %s", analysis.PlayerStats)
+	}
+	if len(attemptTimes) != 2 || attemptTimes[1].Sub(attemptTimes[0]) < time.Second {
+		t.Errorf("Expected a second attempt at least 1s later, got %v", attemptTimes)
+	}
+	if idempotencyKeys[0] == "" || idempotencyKeys[0] != idempotencyKeys[1] {
+		t.Errorf("Expected both attempts to share an Idempotency-Key, got %v", idempotencyKeys)
+	}
+}
+
+// TestRetry_Exhausted tests that the last error is returned once retries run out
+func TestRetry_Exhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).GetMatches(context.Background(), MatchesRequest{Region: "na", GameName: "Newyenn", TagLine: "GGEZ"})
+	var clientError *Error
+	if !errors.As(err, &clientError) || clientError.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a 503 *Error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+// TestParseRetryAfter tests both Retry-After forms
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"Thu, 01 Jan 2026 12:00:30 GMT", 30 * time.Second},
+		{"Thu, 01 Jan 2026 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, testCase := range testCases {
+		if actual := parseRetryAfter(testCase.value, now); actual != testCase.expected {
+			t.Errorf("Expected %v for %q, got %v", testCase.expected, testCase.value, actual)
+		}
+	}
+}
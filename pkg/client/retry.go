@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryableStatuses are responses that may succeed when the request is sent again
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retryWait reports whether a failed attempt should be retried and how long to wait first
+// Retry-After wins over the backoff; a Retry-After beyond maxRetryWait isn't worth
+// blocking the caller for, so the error is returned instead.
+func (client *Client) retryWait(apiError *Error, attempt int) (time.Duration, bool) {
+	if attempt >= client.maxRetries || !retryableStatuses[apiError.StatusCode] {
+		return 0, false
+	}
+	// An oversized upstream body is just as large the next time
+	if apiError.Code == "UPSTREAM_RESPONSE_TOO_LARGE" {
+		return 0, false
+	}
+
+	if apiError.RetryAfter > 0 {
+		if apiError.RetryAfter > client.maxRetryWait {
+			return 0, false
+		}
+		return apiError.RetryAfter, true
+	}
+	return client.backoff(attempt), true
+}
+
+// backoff is the exponential delay before retry number attempt+1
+func (client *Client) backoff(attempt int) time.Duration {
+	delay := client.initialBackoff << attempt
+	if delay <= 0 || delay > client.maxRetryWait {
+		return client.maxRetryWait
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After value in delta-seconds or HTTP-date form
+// Missing or malformed values return 0.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if retryAt, err := http.ParseTime(value); err == nil && retryAt.After(now) {
+		return retryAt.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for delay or until ctx is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SummonerRequest identifies a player by Riot ID
+type SummonerRequest struct {
+	Region   string `json:"region"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
+
+// MatchesRequest asks for a player's recent matches
+// Count 0 lets the gateway choose its default; counts above the gateway's
+// maximum are rejected with MATCH_COUNT_EXCEEDED or clamped, depending on its config.
+type MatchesRequest struct {
+	Region   string `json:"region"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+	Count    int    `json:"count,omitempty"`
+}
+
+// AnalyzeRequest identifies the player to analyze by Riot ID
+type AnalyzeRequest struct {
+	Region   string `json:"region"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
+
+// Summoner is a player account as returned by /api/v1/summoner
+type Summoner struct {
+	ID            string `json:"id"`
+	AccountID     string `json:"accountId"`
+	Name          string `json:"name"`
+	ProfileIconID int    `json:"profileIconId"`
+	SummonerLevel int64  `json:"summonerLevel"`
+}
+
+// Match is a single match as returned by /api/v1/matches
+type Match struct {
+	MatchID      string        `json:"matchId"`
+	GameCreation time.Time     `json:"gameCreation"`
+	GameDuration int           `json:"gameDuration"`
+	GameMode     string        `json:"gameMode"`
+	GameType     string        `json:"gameType"`
+	Participants []Participant `json:"participants"`
+}
+
+// Participant is a player's performance in a match
+type Participant struct {
+	SummonerName                string `json:"summonerName"`
+	ChampionID                  int    `json:"championId"`
+	ChampionName                string `json:"championName"`
+	Kills                       int    `json:"kills"`
+	Deaths                      int    `json:"deaths"`
+	Assists                     int    `json:"assists"`
+	GoldEarned                  int    `json:"goldEarned"`
+	TotalDamageDealtToChampions int    `json:"totalDamageDealtToChampions"`
+	TotalDamageTaken            int    `json:"totalDamageTaken"`
+	VisionScore                 int    `json:"visionScore"`
+	TotalMinionsKilled          int    `json:"totalMinionsKilled"`
+	Win                         bool   `json:"win"`
+	TeamPosition                string `json:"teamPosition"`
+}
+
+// Analysis is the result of /api/v1/analyze
+// The stats and improvement areas are produced by the cortex engine and left raw,
+// so new fields reach callers without an SDK release.
+type Analysis struct {
+	PlayerStats      json.RawMessage `json:"playerStats"`
+	ImprovementAreas json.RawMessage `json:"improvementAreas"`
+	AnalyzedAt       time.Time       `json:"analyzedAt"`
+}
+
+// Health is the gateway's /health report
+type Health struct {
+	Status    string                    `json:"status"`
+	Service   string                    `json:"service"`
+	Upstreams map[string]UpstreamHealth `json:"upstreams,omitempty"`
+}
+
+// UpstreamHealth is the reachability of one backend service
+type UpstreamHealth struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Error is a non-2xx gateway response
+// Code is the gateway error code (e.g. PLAYER_NOT_FOUND, RATE_LIMIT_EXCEEDED);
+// RequestID is the ID to quote in support tickets.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+	// Details holds the error's machine-readable fields, e.g. the limit of a 429
+	Details json.RawMessage
+	// RetryAfter is the wait the gateway asked for, when it sent Retry-After
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (clientError *Error) Error() string {
+	if clientError.Code == "" {
+		return fmt.Sprintf("gateway returned status %d", clientError.StatusCode)
+	}
+	return fmt.Sprintf("gateway returned %d %s: %s", clientError.StatusCode, clientError.Code, clientError.Message)
+}
+
+// errorResponse is the gateway's JSON error envelope
+type errorResponse struct {
+	Error struct {
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"requestId"`
+		Details   json.RawMessage `json:"details"`
+	} `json:"error"`
+}